@@ -4,16 +4,13 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"path/filepath"
-
-	"github.com/mizuho-u/got/internal"
 	"github.com/mizuho-u/got/usecase"
 	"github.com/spf13/cobra"
 )
 
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:   "add",
+	Use:   "add <pathspec>...",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -23,16 +20,11 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		workspace, _ := cmd.Flags().GetString("path")
-		ctx := mustNewContext(workspace, cmd)
-		defer ctx.Close()
-
-		args, err := internal.MapE(args, func(p string) (string, error) {
-			return filepath.Abs(p)
-		})
+		ctx, err := newContext(cmd)
 		if err != nil {
 			return err
 		}
+		defer ctx.Close()
 
 		return usecase.Add(ctx, args...)
 	},
@@ -0,0 +1,81 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [--check] [--index] [-3] [-R] [-v] [--context=<n>] [-p<n>] [--whitespace=<action>] [--allow-empty] [<patch>...]",
+	Short: "Apply a patch to files and/or to the index",
+	Long: `Reads the supplied diff output (i.e. "a patch") and applies it to files.
+
+Reads the patches from the given files, or from the standard input if no file or "-" is given.
+With --index, the patch is also applied to the index. With --3way, a patch that does not apply
+cleanly is merged using the blobs recorded in its index lines.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		opts := usecase.ApplyOptions{}
+		opts.Check, _ = cmd.Flags().GetBool("check")
+		opts.Index, _ = cmd.Flags().GetBool("index")
+		opts.ThreeWay, _ = cmd.Flags().GetBool("3way")
+		opts.Reverse, _ = cmd.Flags().GetBool("reverse")
+		opts.Verbose, _ = cmd.Flags().GetBool("verbose")
+		opts.MinContext, _ = cmd.Flags().GetInt("context")
+		opts.Strip, _ = cmd.Flags().GetInt("strip")
+		opts.Whitespace, _ = cmd.Flags().GetString("whitespace")
+		opts.AllowEmpty, _ = cmd.Flags().GetBool("allow-empty")
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		if len(args) == 0 {
+			args = []string{"-"}
+		}
+
+		inputs := []usecase.PatchInput{}
+		for _, file := range args {
+
+			var data []byte
+			name := file
+			if file == "-" {
+				name = "<stdin>"
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(file)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			inputs = append(inputs, usecase.PatchInput{Name: name, Data: data})
+		}
+
+		return usecase.Apply(ctx, opts, inputs...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().Bool("check", false, "instead of applying the patch, see if the patch is applicable")
+	applyCmd.Flags().Bool("index", false, "apply the patch to both the index and the working tree")
+	applyCmd.Flags().BoolP("3way", "3", false, "attempt 3-way merge, fall back on normal patch if that fails")
+	applyCmd.Flags().BoolP("reverse", "R", false, "apply the patch in reverse")
+	applyCmd.Flags().BoolP("verbose", "v", false, "report progress to stderr")
+	applyCmd.Flags().Int("context", -1, "ensure at least <n> lines of context match. -C of the root command selects the directory")
+	applyCmd.Flags().IntP("strip", "p", 1, "remove <n> leading slashes from traditional diff paths")
+	applyCmd.Flags().String("whitespace", "", "detect new or modified lines that have whitespace errors. nowarn, warn, error or error-all")
+	applyCmd.Flags().Bool("allow-empty", false, "don't return error for empty patches")
+}
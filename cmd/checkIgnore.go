@@ -0,0 +1,49 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/mizuho-u/got/internal"
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// checkIgnoreCmd represents the check-ignore command
+var checkIgnoreCmd = &cobra.Command{
+	Use:   "check-ignore [-v] <pathname>...",
+	Short: "Debug gitignore / exclude files",
+	Long: `For each pathname given via the command-line, check whether the file is
+excluded by .gitignore (or other input files to the exclude mechanism) and
+output the path if it is excluded.
+
+With -v, output details about the matching pattern (if any) for each given pathname.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		args, err = internal.MapE(args, func(p string) (string, error) {
+			return filepath.Abs(p)
+		})
+		if err != nil {
+			return err
+		}
+
+		return usecase.CheckIgnore(ctx, verbose, args...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkIgnoreCmd)
+
+	checkIgnoreCmd.Flags().BoolP("verbose", "v", false, "output details about the matching pattern")
+}
@@ -5,14 +5,16 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"fmt"
+	"errors"
 
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
 	"github.com/spf13/cobra"
 )
 
 // checkoutCmd represents the checkout command
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout",
+	Use:   "checkout [<revision>] [--] [<pathspec>...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -20,8 +22,42 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("checkout called")
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		rev, paths := args, []string{}
+		if dash := cmd.ArgsLenAtDash(); dash != -1 {
+			rev, paths = args[:dash], args[dash:]
+		} else if len(args) > 1 {
+			rev, paths = args[:1], args[1:]
+		}
+
+		if len(rev) > 1 {
+			return errors.New("only one revision can be checked out")
+		}
+
+		revision, err := types.NewRevision("")
+		if len(rev) == 1 {
+			revision, err = types.NewRevision(rev[0])
+		}
+		if err != nil {
+			return &usecase.InvalidRevisionError{Revision: rev[0], Reason: err.Error()}
+		}
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		if len(paths) != 0 {
+			return usecase.CheckoutPaths(ctx, revision, paths...)
+		}
+
+		if len(rev) == 0 {
+			return errors.New("you must specify a revision or paths to checkout")
+		}
+
+		return usecase.Checkout(ctx, revision)
 	},
 }
 
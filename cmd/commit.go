@@ -4,8 +4,10 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"bufio"
+	"errors"
+	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mizuho-u/got/usecase"
@@ -14,7 +16,7 @@ import (
 
 // commitCmd represents the commit command
 var commitCmd = &cobra.Command{
-	Use:   "commit",
+	Use:   "commit [--] [<pathspec>...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -24,25 +26,122 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		workspace, _ := cmd.Flags().GetString("path")
+		messages, _ := cmd.Flags().GetStringArray("message")
+		file, _ := cmd.Flags().GetString("file")
+		edit, _ := cmd.Flags().GetBool("edit")
+		all, _ := cmd.Flags().GetBool("all")
+		amend, _ := cmd.Flags().GetBool("amend")
+		author, _ := cmd.Flags().GetString("author")
+		date, _ := cmd.Flags().GetString("date")
+		signingKey, _ := cmd.Flags().GetString("gpg-sign")
+		noVerify, _ := cmd.Flags().GetBool("no-verify")
+		allowEmpty, _ := cmd.Flags().GetBool("allow-empty")
+		allowEmptyMessage, _ := cmd.Flags().GetBool("allow-empty-message")
+		signoff, _ := cmd.Flags().GetBool("signoff")
+		trailers, _ := cmd.Flags().GetStringArray("trailer")
+		check, _ := cmd.Flags().GetBool("check")
 
-		var message string
-		sc := bufio.NewScanner(os.Stdin)
-		for sc.Scan() {
-			message += sc.Text()
+		if len(messages) != 0 && file != "" {
+			return errors.New("Option -m cannot be combined with -F.")
 		}
 
-		ctx := mustNewContext(workspace, cmd)
+		message, hasMessage, err := commitMessage(messages, file)
+		if err != nil {
+			return err
+		}
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
 		defer ctx.Close()
 
-		return usecase.Commit(ctx, message, time.Now())
+		if date == "" {
+			date = os.Getenv("GIT_AUTHOR_DATE")
+		}
+
+		opts := usecase.CommitOptions{
+			Message: message,
+			Edit:    edit || !hasMessage,
+			All:     all,
+			Amend:   amend,
+			Paths:   args,
+
+			Author:        author,
+			AuthorDate:    date,
+			CommitterDate: os.Getenv("GIT_COMMITTER_DATE"),
+
+			Sign:              cmd.Flags().Changed("gpg-sign"),
+			SigningKey:        strings.TrimSpace(signingKey),
+			NoVerify:          noVerify,
+			AllowEmpty:        allowEmpty,
+			AllowEmptyMessage: allowEmptyMessage,
+			Signoff:           signoff,
+			Trailers:          trailers,
+			CheckWhitespace:   check,
+		}
+
+		return usecase.Commit(ctx, time.Now(), opts)
 
 	},
 }
 
+// commitMessage -m は段落ごとに、-F はファイルから読む。どちらもなく標準入力が端末でなければ標準入力から読む
+func commitMessage(messages []string, file string) (message string, ok bool, err error) {
+
+	if len(messages) != 0 {
+		return strings.Join(messages, "\n\n"), true, nil
+	}
+
+	var data []byte
+	switch {
+	case file == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case file != "":
+		data, err = os.ReadFile(file)
+	case !isTerminal(os.Stdin):
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return string(data), true, nil
+}
+
+func isTerminal(f *os.File) bool {
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func init() {
 	rootCmd.AddCommand(commitCmd)
 
+	commitCmd.Flags().StringArrayP("message", "m", []string{}, "use the given message as the commit message. multiple -m are concatenated as separate paragraphs")
+	commitCmd.Flags().StringP("file", "F", "", "take the commit message from the given file. use - to read from the standard input")
+	commitCmd.Flags().BoolP("edit", "e", false, "further edit the message taken from -m, -F or --amend")
+	commitCmd.Flags().BoolP("all", "a", false, "stage modified and deleted tracked files before committing")
+	commitCmd.Flags().Bool("amend", false, "replace the tip of the current branch by creating a new commit")
+	commitCmd.Flags().String("author", "", "override the commit author. specify an explicit author using the standard A U Thor <author@example.com> format")
+	commitCmd.Flags().String("date", "", "override the author date used in the commit")
+	commitCmd.Flags().StringP("gpg-sign", "S", "", "sign the commit with the ssh key. the key defaults to user.signingKey")
+	// -Sだけなら鍵は指定なし。値は空白なので取り除いて使う
+	commitCmd.Flags().Lookup("gpg-sign").NoOptDefVal = " "
+	commitCmd.Flags().BoolP("no-verify", "n", false, "bypass the pre-commit and commit-msg hooks")
+	commitCmd.Flags().Bool("allow-empty", false, "allow recording a commit that has the exact same tree as its parent")
+	commitCmd.Flags().Bool("allow-empty-message", false, "allow recording a commit with an empty message")
+	commitCmd.Flags().BoolP("signoff", "s", false, "add a Signed-off-by trailer by the committer at the end of the commit log message")
+	commitCmd.Flags().StringArray("trailer", []string{}, "add a trailer to the commit message. specify <token>=<value> or <token>:<value>")
+	commitCmd.Flags().Bool("check", false, "refuse to commit if the staged changes introduce whitespace errors as configured by core.whitespace")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -5,13 +5,15 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
+
 	"github.com/mizuho-u/got/usecase"
 	"github.com/spf13/cobra"
 )
 
 // diffCmd represents the diff command
 var diffCmd = &cobra.Command{
-	Use:   "diff",
+	Use:   "diff [<commit> [<commit>]] [--] [<pathspec>...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -21,13 +23,71 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		workspace, _ := cmd.Flags().GetString("path")
 		cached, _ := cmd.Flags().GetBool("cached")
+		relative, _ := cmd.Flags().GetBool("relative")
+		noIndex, _ := cmd.Flags().GetBool("no-index")
 
-		ctx := mustNewContext(workspace, cmd)
+		// gitと同じく、リポジトリの外でふたつのパスを指定すれば--no-indexにする
+		ctx, err := newContext(cmd)
+		var notRepository *usecase.NotRepositoryError
+		if errors.As(err, &notRepository) && (noIndex || len(args) == 2) {
+			ctx, noIndex, err = newOutsideContext(cmd), true, nil
+		}
+		if err != nil {
+			return err
+		}
 		defer ctx.Close()
 
-		return usecase.Diff(ctx, cached)
+		if noIndex && len(args) != 2 {
+			return &usageError{err: errors.New("got diff --no-index takes two paths"), usage: cmd.UsageString()}
+		}
+
+		// --がなければ先頭からrevisionとして解決できる引数をrevisionにする
+		revisions, paths := args, []string{}
+		if noIndex {
+			revisions, paths = []string{}, args
+		} else if dash := cmd.ArgsLenAtDash(); dash != -1 {
+			revisions, paths = args[:dash], args[dash:]
+		} else if revisions, paths, err = usecase.SplitRevisionArgs(ctx, args); err != nil {
+			return err
+		}
+
+		opts := usecase.DiffOptions{Cached: cached, Relative: relative, Revisions: revisions, Paths: paths, StatWidth: terminalWidth(), NoIndex: noIndex}
+		opts.Raw, _ = cmd.Flags().GetBool("raw")
+		opts.NameOnly, _ = cmd.Flags().GetBool("name-only")
+		opts.NameStatus, _ = cmd.Flags().GetBool("name-status")
+		opts.NumStat, _ = cmd.Flags().GetBool("numstat")
+		opts.Stat, _ = cmd.Flags().GetBool("stat")
+		opts.ShortStat, _ = cmd.Flags().GetBool("shortstat")
+		opts.NullTerminated, _ = cmd.Flags().GetBool("null")
+		opts.NoRenames, _ = cmd.Flags().GetBool("no-renames")
+		opts.Algorithm, _ = cmd.Flags().GetString("diff-algorithm")
+		opts.Minimal, _ = cmd.Flags().GetBool("minimal")
+		opts.IgnoreAllSpace, _ = cmd.Flags().GetBool("ignore-all-space")
+		opts.IgnoreSpaceChange, _ = cmd.Flags().GetBool("ignore-space-change")
+		opts.IgnoreSpaceAtEOL, _ = cmd.Flags().GetBool("ignore-space-at-eol")
+		opts.IgnoreBlankLines, _ = cmd.Flags().GetBool("ignore-blank-lines")
+		opts.Binary, _ = cmd.Flags().GetBool("binary")
+		opts.WordDiff, _ = cmd.Flags().GetString("word-diff")
+		opts.WordDiffRegex, _ = cmd.Flags().GetString("word-diff-regex")
+		opts.ColorMoved, _ = cmd.Flags().GetString("color-moved")
+		opts.Check, _ = cmd.Flags().GetBool("check")
+		if cmd.Flags().Changed("unified") {
+			n, _ := cmd.Flags().GetInt("unified")
+			opts.Context = &n
+		}
+		if cmd.Flags().Changed("inter-hunk-context") {
+			n, _ := cmd.Flags().GetInt("inter-hunk-context")
+			opts.InterHunkContext = &n
+		}
+		opts.FindCopies = cmd.Flags().Changed("find-copies")
+		if cmd.Flags().Changed("find-renames") {
+			opts.FindRenames, _ = cmd.Flags().GetString("find-renames")
+		} else if opts.FindCopies {
+			opts.FindRenames, _ = cmd.Flags().GetString("find-copies")
+		}
+
+		return usecase.Diff(ctx, opts)
 
 	},
 }
@@ -36,6 +96,36 @@ func init() {
 	rootCmd.AddCommand(diffCmd)
 
 	diffCmd.Flags().Bool("cached", false, "")
+	diffCmd.Flags().Bool("no-index", false, "compare the two given paths on the filesystem, even outside a repository")
+	diffCmd.Flags().Bool("relative", false, "show only changes under the current directory, with paths relative to it")
+	diffCmd.Flags().Bool("raw", false, "generate the diff in raw format")
+	diffCmd.Flags().Bool("name-only", false, "show only names of changed files")
+	diffCmd.Flags().Bool("name-status", false, "show only names and status of changed files")
+	diffCmd.Flags().Bool("numstat", false, "show numbers of added and deleted lines in decimal notation")
+	diffCmd.Flags().Bool("stat", false, "generate a diffstat")
+	diffCmd.Flags().Bool("shortstat", false, "output only the last line of the --stat format")
+	diffCmd.Flags().BoolP("null", "z", false, "terminate paths with NULs in --raw, --name-only, --name-status and --numstat")
+	diffCmd.Flags().StringP("find-renames", "M", "", "detect renames whose similarity index is at least n. specify -M=90% or -M=9")
+	diffCmd.Flags().Lookup("find-renames").NoOptDefVal = "50%"
+	// -Cはルートの-Cと重なるので長い名前だけにする
+	diffCmd.Flags().String("find-copies", "", "detect copies as well as renames. the value is the same as --find-renames")
+	diffCmd.Flags().Lookup("find-copies").NoOptDefVal = "50%"
+	diffCmd.Flags().Bool("no-renames", false, "turn off rename detection, even when diff.renames is set")
+	diffCmd.Flags().String("diff-algorithm", "", "choose a diff algorithm. myers, minimal, patience or histogram. defaults to diff.algorithm")
+	diffCmd.Flags().Bool("minimal", false, "spend extra time to make sure the smallest possible diff is produced")
+	diffCmd.Flags().IntP("unified", "U", 3, "generate diffs with <n> lines of context. defaults to diff.context or 3")
+	diffCmd.Flags().Int("inter-hunk-context", 0, "show the context between diff hunks, up to the specified number of lines")
+	diffCmd.Flags().BoolP("ignore-all-space", "w", false, "ignore whitespace when comparing lines")
+	diffCmd.Flags().BoolP("ignore-space-change", "b", false, "ignore changes in amount of whitespace")
+	diffCmd.Flags().Bool("ignore-space-at-eol", false, "ignore changes in whitespace at EOL")
+	diffCmd.Flags().Bool("ignore-blank-lines", false, "ignore changes whose lines are all blank")
+	diffCmd.Flags().Bool("binary", false, "output a binary diff that can be applied with git apply")
+	diffCmd.Flags().String("word-diff", "", "show a word diff. plain, color, porcelain or none")
+	diffCmd.Flags().Lookup("word-diff").NoOptDefVal = "plain"
+	diffCmd.Flags().String("word-diff-regex", "", "use <regex> to decide what a word is. implies --word-diff")
+	diffCmd.Flags().String("color-moved", "", "color moved lines differently. no, default, plain, blocks, zebra or dimmed-zebra. defaults to diff.colorMoved")
+	diffCmd.Flags().Lookup("color-moved").NoOptDefVal = "default"
+	diffCmd.Flags().Bool("check", false, "warn if changes introduce whitespace errors as configured by core.whitespace. exits with 2 if found")
 
 	// Here you will define your flags and configuration settings.
 
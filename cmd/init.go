@@ -4,8 +4,6 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"os"
-
 	"github.com/mizuho-u/got/usecase"
 	"github.com/spf13/cobra"
 )
@@ -20,25 +18,35 @@ and usage of using your command. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 
 		var path string
-		if len(os.Args) == 3 {
-			path = os.Args[2]
-		} else {
-			path, _ = cmd.Flags().GetString("path")
+		if len(args) == 1 {
+			path = args[0]
 		}
 
-		ctx := mustNewContext(path, cmd)
+		branch, _ := cmd.Flags().GetString("initial-branch")
+		bare, _ := cmd.Flags().GetBool("bare")
+		template, _ := cmd.Flags().GetString("template")
+
+		ctx, err := newInitContext(path, bare, cmd)
+		if err != nil {
+			return err
+		}
 		defer ctx.Close()
 
-		return usecase.InitDir(ctx)
+		return usecase.InitDir(ctx, usecase.InitOptions{InitialBranch: branch, Bare: bare, Template: template})
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
 
+	initCmd.Flags().StringP("initial-branch", "b", "", "use the specified name for the initial branch in the newly created repository")
+	initCmd.Flags().Bool("bare", false, "create a bare repository")
+	initCmd.Flags().String("template", "", "directory from which templates will be used")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,77 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// interpretTrailersCmd represents the interpret-trailers command
+var interpretTrailersCmd = &cobra.Command{
+	Use:   "interpret-trailers [--where <placement>] [--if-exists <action>] [--if-missing <action>] [--trailer <token>=<value>]... [--parse] [<file>...]",
+	Short: "Add or parse structured information in commit messages",
+	Long: `Add or parse trailer lines that look similar to RFC 822 e-mail headers,
+at the end of the otherwise free-form part of a commit message.
+
+Reads the messages from the given files, or from the standard input if no file is given.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		trailers, _ := cmd.Flags().GetStringArray("trailer")
+		where, _ := cmd.Flags().GetString("where")
+		ifExists, _ := cmd.Flags().GetString("if-exists")
+		ifMissing, _ := cmd.Flags().GetString("if-missing")
+		parse, _ := cmd.Flags().GetBool("parse")
+
+		// リポジトリの外でも使える
+		ctx := usecase.NewContext(context.Background(), "", "", "", "", cmd.OutOrStdout(), cmd.ErrOrStderr())
+		defer ctx.Close()
+
+		opts := usecase.InterpretTrailersOptions{
+			Trailers:  trailers,
+			Where:     where,
+			IfExists:  ifExists,
+			IfMissing: ifMissing,
+			Parse:     parse,
+		}
+
+		if len(args) == 0 {
+
+			data, err := io.ReadAll(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			return usecase.InterpretTrailers(ctx, string(data), opts)
+		}
+
+		for _, file := range args {
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			if err := usecase.InterpretTrailers(ctx, string(data), opts); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(interpretTrailersCmd)
+
+	interpretTrailersCmd.Flags().StringArray("trailer", []string{}, "specify a <token>=<value> trailer to add")
+	interpretTrailersCmd.Flags().String("where", "", "where to place new trailers. end, start, after or before")
+	interpretTrailersCmd.Flags().String("if-exists", "", "what to do if a trailer with the same token exists. addIfDifferentNeighbor, addIfDifferent, add, replace or doNothing")
+	interpretTrailersCmd.Flags().String("if-missing", "", "what to do if no trailer with the same token exists. add or doNothing")
+	interpretTrailersCmd.Flags().Bool("parse", false, "output only the trailers, with continuation lines unfolded")
+}
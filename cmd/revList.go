@@ -0,0 +1,70 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// revListCmd represents the rev-list command
+var revListCmd = &cobra.Command{
+	Use:   "rev-list [<options>] <commit>...",
+	Short: "Lists commit objects in reverse chronological order",
+	Long: `Lists the commits reachable from the given commits but not from the ones
+given with a leading ^, e.g. main..topic, main...topic or topic --not main.
+
+Commits are shown newest first. --topo-order and --date-order never show a
+parent before all of its children.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		r, err := types.NewRevisionRange(revListNot.args(args)...)
+		if err != nil {
+			return &usecase.InvalidRevisionError{Revision: args[0], Reason: err.Error()}
+		}
+
+		opts := usecase.RevListOptions{}
+		opts.TopoOrder, _ = cmd.Flags().GetBool("topo-order")
+		opts.DateOrder, _ = cmd.Flags().GetBool("date-order")
+		opts.Reverse, _ = cmd.Flags().GetBool("reverse")
+		opts.FirstParent, _ = cmd.Flags().GetBool("first-parent")
+		opts.AncestryPath, _ = cmd.Flags().GetBool("ancestry-path")
+		opts.MaxCount, _ = cmd.Flags().GetInt("max-count")
+		opts.Since, _ = cmd.Flags().GetString("since")
+		opts.Until, _ = cmd.Flags().GetString("until")
+		opts.Parents, _ = cmd.Flags().GetBool("parents")
+		opts.Count, _ = cmd.Flags().GetBool("count")
+		opts.Objects, _ = cmd.Flags().GetBool("objects")
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		return usecase.RevList(ctx, r, opts)
+	},
+}
+
+var revListNot *notFlag
+
+func init() {
+	rootCmd.AddCommand(revListCmd)
+
+	revListNot = addNotFlag(revListCmd)
+
+	revListCmd.Flags().Bool("topo-order", false, "show no parents before all of its children, and avoid mixing histories")
+	revListCmd.Flags().Bool("date-order", false, "show no parents before all of its children, otherwise in commit timestamp order")
+	revListCmd.Flags().Bool("reverse", false, "output the commits in reverse order")
+	revListCmd.Flags().Bool("first-parent", false, "follow only the first parent commit upon seeing a merge commit")
+	revListCmd.Flags().Bool("ancestry-path", false, "only show commits that are descendants of the excluded commits")
+	revListCmd.Flags().IntP("max-count", "n", -1, "limit the number of commits to output")
+	revListCmd.Flags().String("since", "", "show commits more recent than a specific date")
+	revListCmd.Flags().String("until", "", "show commits older than a specific date")
+	revListCmd.Flags().Bool("parents", false, "print also the parents of the commit")
+	revListCmd.Flags().Bool("count", false, "print a number stating how many commits would have been listed")
+	revListCmd.Flags().Bool("objects", false, "print the object IDs of any object referenced by the listed commits")
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// revParseCmd represents the rev-parse command
+var revParseCmd = &cobra.Command{
+	Use:   "rev-parse <revision>...",
+	Short: "Show the object names of revisions",
+	Long: `Resolves each revision and prints its object name.
+
+Supports the revision syntax of gitrevisions(7), e.g. HEAD^2, HEAD~3, main@{1},
+@{upstream}, v1.0^{commit}, HEAD:path, :0:path and :/message.
+Ranges print the excluded commits with a leading ^: A..B, A...B, ^A and --not.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		ranges := []*types.RevisionRange{}
+		not := false
+		for _, arg := range revParseNot.args(args) {
+
+			if arg == "--not" {
+				not = !not
+				continue
+			}
+
+			rangeArgs := []string{arg}
+			if not {
+				rangeArgs = []string{"--not", arg}
+			}
+
+			r, err := types.NewRevisionRange(rangeArgs...)
+			if err != nil {
+				return &usecase.InvalidRevisionError{Revision: arg, Reason: err.Error()}
+			}
+			ranges = append(ranges, r)
+		}
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		return usecase.RevParse(ctx, ranges...)
+	},
+}
+
+var revParseNot *notFlag
+
+func init() {
+	rootCmd.AddCommand(revParseCmd)
+
+	revParseNot = addNotFlag(revParseCmd)
+}
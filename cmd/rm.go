@@ -0,0 +1,43 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// rmCmd represents the rm command
+var rmCmd = &cobra.Command{
+	Use:   "rm [-f] [-r] [--cached] <pathspec>...",
+	Short: "Remove files from the working tree and from the index",
+	Long: `Remove files matching pathspec from the index, or from the working tree and the index.
+
+The files being removed have to be identical to the tip of the branch, and no updates to
+their contents can be staged in the index, unless -f is given. With --cached, only the
+index is updated and the working tree files are left alone.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		cached, _ := cmd.Flags().GetBool("cached")
+		force, _ := cmd.Flags().GetBool("force")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		return usecase.Rm(ctx, usecase.RmOptions{Cached: cached, Force: force, Recursive: recursive}, args...)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+
+	rmCmd.Flags().Bool("cached", false, "only remove from the index")
+	rmCmd.Flags().BoolP("force", "f", false, "override the up-to-date check")
+	rmCmd.Flags().BoolP("recursive", "r", false, "allow recursive removal")
+}
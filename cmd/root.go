@@ -5,11 +5,15 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/mizuho-u/got/io/database"
 	"github.com/mizuho-u/got/usecase"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,7 +26,9 @@ examples and usage of using your application. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
-	SilenceUsage: true,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	PersistentPreRunE: changeDirectory,
 
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
@@ -34,10 +40,30 @@ to quickly create a Cobra application.`,
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(128)
+
+		msg, code := usecase.Report(err)
+		if msg != "" {
+			fmt.Fprintln(os.Stderr, msg)
+		}
+
+		os.Exit(code)
 	}
 }
 
+// usageError オプションの誤り。gitと同じく終了コード129で終わる
+type usageError struct {
+	err   error
+	usage string
+}
+
+func (e *usageError) Error() string {
+	return fmt.Sprintf("%s\n\n%s", e.err, e.usage)
+}
+
+func (e *usageError) ExitCode() int {
+	return usecase.ExitUsage
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
@@ -49,35 +75,157 @@ func init() {
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	rootCmd.PersistentFlags().StringP("path", "C", "", "path")
+
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{err: err, usage: cmd.UsageString()}
+	})
 }
 
 const gotdir string = ".git"
 
-func mustNewContext(workspace string, cmd *cobra.Command) usecase.GotContext {
+// changeDirectory -C で指定されたディレクトリで実行する
+func changeDirectory(cmd *cobra.Command, args []string) error {
 
-	if workspace == "" {
-		wd, err := os.Getwd()
-		if err != nil {
-			panic(err)
-		}
+	path, _ := cmd.Flags().GetString("path")
+	if path == "" {
+		return nil
+	}
 
-		workspace = wd
+	return os.Chdir(path)
+}
+
+// discover 作業ディレクトリからワークスペースとリポジトリを探す
+func discover() (worktree, gitdir, wd string, err error) {
+
+	wd, err = os.Getwd()
+	if err != nil {
+		return "", "", "", err
 	}
 
-	if !filepath.IsAbs(workspace) {
+	if dir := os.Getenv("GIT_DIR"); dir != "" {
 
-		abs, err := filepath.Abs(workspace)
+		if gitdir, err = filepath.Abs(dir); err != nil {
+			return "", "", "", err
+		}
+
+		if gitdir, err = database.ResolveGitfile(gitdir); err != nil {
+			return "", "", "", err
+		}
+
+		if bare, _ := database.NewFSDB("", gitdir).Config().Bool("core.bare"); !bare {
+			worktree = wd
+		}
+
+	} else {
+
+		ceilings := filepath.SplitList(os.Getenv("GIT_CEILING_DIRECTORIES"))
+
+		worktree, gitdir, err = database.Discover(wd, ceilings)
+		if errors.Is(err, database.ErrRepositoryNotFound) {
+			return "", "", "", &usecase.NotRepositoryError{Err: err}
+		}
 		if err != nil {
-			panic(err)
+			return "", "", "", err
 		}
+	}
 
-		workspace = abs
+	if tree := os.Getenv("GIT_WORK_TREE"); tree != "" {
+		if worktree, err = filepath.Abs(tree); err != nil {
+			return "", "", "", err
+		}
 	}
 
-	ctx, err := usecase.NewContextPager(context.Background(), workspace, gotdir, os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL"), cmd.OutOrStdout(), cmd.OutOrStderr())
+	return worktree, gitdir, wd, nil
+}
+
+func newContext(cmd *cobra.Command) (usecase.GotContext, error) {
+
+	worktree, gitdir, wd, err := discover()
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	return newPagerContext(cmd, worktree, gitdir, usecase.WithWorkingDirectory(wd)), nil
+}
+
+// newOutsideContext リポジトリの外で使うコマンドのコンテキスト
+func newOutsideContext(cmd *cobra.Command) usecase.GotContext {
+
+	wd, _ := os.Getwd()
+	return newPagerContext(cmd, "", "", usecase.WithWorkingDirectory(wd))
+}
+
+func newInitContext(workspace string, bare bool, cmd *cobra.Command) (usecase.GotContext, error) {
+
+	workspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	// ベアリポジトリは指定したディレクトリそのものがリポジトリになる
+	gitdir := gotdir
+	if bare {
+		workspace, gitdir = "", workspace
+	}
+
+	return newPagerContext(cmd, workspace, gitdir), nil
+}
+
+// newPagerContext ページャーが起動できなければそのまま出力する
+func newPagerContext(cmd *cobra.Command, workspace, gitdir string, options ...usecase.ContextOption) usecase.GotContext {
+
+	name, email := os.Getenv("GIT_AUTHOR_NAME"), os.Getenv("GIT_AUTHOR_EMAIL")
+	options = append(options, usecase.WithCommitter(os.Getenv("GIT_COMMITTER_NAME"), os.Getenv("GIT_COMMITTER_EMAIL")))
+
+	ctx, err := usecase.NewContextPager(context.Background(), workspace, gitdir, name, email, cmd.OutOrStdout(), cmd.ErrOrStderr(), options...)
+	if err != nil {
+		return usecase.NewContext(context.Background(), workspace, gitdir, name, email, cmd.OutOrStdout(), cmd.ErrOrStderr(), options...)
 	}
 
 	return ctx
 }
+
+// notFlag --notは引数の並びに意味があるので、何番目の引数の前にあったかを覚えておく
+type notFlag struct {
+	flags     *pflag.FlagSet
+	positions []int
+}
+
+// addNotFlag cmdに--notを追加する
+func addNotFlag(cmd *cobra.Command) *notFlag {
+
+	n := &notFlag{flags: cmd.Flags()}
+	cmd.Flags().Var(n, "not", "reverse the meaning of the ^ prefix for all following revisions")
+	cmd.Flags().Lookup("not").NoOptDefVal = "true"
+
+	return n
+}
+
+func (n *notFlag) Set(string) error {
+
+	// パース中のFlagSetにはそれまでの引数が入っている
+	n.positions = append(n.positions, len(n.flags.Args()))
+	return nil
+}
+
+func (n *notFlag) String() string { return "false" }
+func (n *notFlag) Type() string   { return "bool" }
+
+// args 引数の元の位置に--notを戻す。次の実行のために覚えた位置は消す
+func (n *notFlag) args(args []string) []string {
+
+	defer func() { n.positions = nil }()
+
+	withNot := []string{}
+	for i, arg := range args {
+
+		for _, p := range n.positions {
+			if p == i {
+				withNot = append(withNot, "--not")
+			}
+		}
+		withNot = append(withNot, arg)
+	}
+
+	return withNot
+}
@@ -11,7 +11,7 @@ import (
 
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [<pathspec>...]",
 	Short: "A brief description of your command",
 	Long: `A longer description that spans multiple lines and likely contains examples
 and usage of using your command. For example:
@@ -21,13 +21,15 @@ This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		workspace, _ := cmd.Flags().GetString("path")
 		porcelain, _ := cmd.Flags().GetBool("porcelain")
 
-		ctx := mustNewContext(workspace, cmd)
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
 		defer ctx.Close()
 
-		return usecase.Status(ctx, porcelain)
+		return usecase.Status(ctx, porcelain, args...)
 	},
 }
 
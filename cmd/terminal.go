@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalWidth COLUMNS、標準出力の端末の幅の順に見る。わからなければ0
+func terminalWidth() int {
+
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0
+	}
+
+	return int(ws.Col)
+}
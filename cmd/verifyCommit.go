@@ -0,0 +1,56 @@
+/*
+Copyright © 2023 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
+	"github.com/spf13/cobra"
+)
+
+// verifyCommitCmd represents the verify-commit command
+var verifyCommitCmd = &cobra.Command{
+	Use:   "verify-commit <commit>...",
+	Short: "Check the SSH signature of commits",
+	Long: `Validates the SSH signature created by "got commit -S" against the keys
+listed in gpg.ssh.allowedSignersFile.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		ctx, err := newContext(cmd)
+		if err != nil {
+			return err
+		}
+		defer ctx.Close()
+
+		// すべて検証して、最初に失敗したもののエラーを返す。ほかの失敗はここで出力する
+		var failed error
+		for _, arg := range args {
+
+			revision, err := types.NewRevision(arg)
+			if err != nil {
+				err = &usecase.InvalidRevisionError{Revision: arg, Reason: err.Error()}
+			} else {
+				err = usecase.VerifyCommit(ctx, revision)
+			}
+
+			switch {
+			case err == nil:
+			case failed == nil:
+				failed = err
+			default:
+				msg, _ := usecase.Report(err)
+				ctx.OutError(errors.New(msg))
+			}
+		}
+
+		return failed
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCommitCmd)
+}
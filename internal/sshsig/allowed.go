@@ -0,0 +1,147 @@
+package sshsig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// AllowedSigner allowed_signersファイルの1行。
+// `principals [options] keytype base64-key [comment]`
+type AllowedSigner struct {
+	Principals  []string
+	Namespaces  []string
+	ValidAfter  time.Time
+	ValidBefore time.Time
+	PublicKey   ssh.PublicKey
+}
+
+// ParseAllowedSigners 空行と#で始まる行は読み飛ばす
+func ParseAllowedSigners(data []byte) ([]*AllowedSigner, error) {
+
+	signers := []*AllowedSigner{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		signer, err := parseAllowedSigner(line)
+		if err != nil {
+			return nil, fmt.Errorf("allowed signers line %d: %w", n, err)
+		}
+
+		signers = append(signers, signer)
+	}
+
+	return signers, scanner.Err()
+}
+
+func parseAllowedSigner(line string) (*AllowedSigner, error) {
+
+	var principals, rest string
+	if strings.HasPrefix(line, `"`) {
+
+		end := strings.Index(line[1:], `"`)
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated principals")
+		}
+		principals, rest = line[1:end+1], line[end+2:]
+
+	} else {
+
+		var ok bool
+		if principals, rest, ok = strings.Cut(line, " "); !ok {
+			return nil, fmt.Errorf("missing key")
+		}
+	}
+
+	pub, _, options, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &AllowedSigner{Principals: strings.Split(principals, ","), PublicKey: pub}
+
+	for _, opt := range options {
+
+		key, value, _ := strings.Cut(opt, "=")
+		value = strings.Trim(value, `"`)
+
+		switch strings.ToLower(key) {
+		case "namespaces":
+			signer.Namespaces = strings.Split(value, ",")
+		case "valid-after":
+			if signer.ValidAfter, err = parseValidity(value); err != nil {
+				return nil, err
+			}
+		case "valid-before":
+			if signer.ValidBefore, err = parseValidity(value); err != nil {
+				return nil, err
+			}
+		case "cert-authority":
+			return nil, fmt.Errorf("cert-authority is not supported")
+		}
+	}
+
+	return signer, nil
+}
+
+// parseValidity YYYYMMDD[HHMM[SS]]。末尾にZがあればUTC、なければローカル時刻
+func parseValidity(s string) (time.Time, error) {
+
+	loc := time.Local
+	if strings.HasSuffix(s, "Z") {
+		s, loc = strings.TrimSuffix(s, "Z"), time.UTC
+	}
+
+	for _, layout := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(s) == len(layout) {
+			return time.ParseInLocation(layout, s, loc)
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// Allows pubがnamespaceの署名にatの時点で使えるか
+func (s *AllowedSigner) Allows(pub ssh.PublicKey, namespace string, at time.Time) bool {
+
+	if !bytes.Equal(s.PublicKey.Marshal(), pub.Marshal()) {
+		return false
+	}
+
+	if len(s.Namespaces) != 0 && !slices.Contains(s.Namespaces, namespace) {
+		return false
+	}
+
+	if !s.ValidAfter.IsZero() && at.Before(s.ValidAfter) {
+		return false
+	}
+
+	if !s.ValidBefore.IsZero() && !at.Before(s.ValidBefore) {
+		return false
+	}
+
+	return true
+}
+
+// FindPrincipal pubを許可している最初の行のprincipals
+func FindPrincipal(signers []*AllowedSigner, pub ssh.PublicKey, namespace string, at time.Time) (string, bool) {
+
+	for _, s := range signers {
+		if s.Allows(pub, namespace, at) {
+			return strings.Join(s.Principals, ","), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,171 @@
+// Package sshsig OpenSSHのsshsig形式(PROTOCOL.sshsig)の署名を作って検証する
+package sshsig
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	magicPreamble = "SSHSIG"
+	sigVersion    = 1
+	pemType       = "SSH SIGNATURE"
+)
+
+var (
+	ErrNotSSHSignature = errors.New("not an ssh signature")
+	ErrBadSignature    = errors.New("incorrect signature")
+)
+
+// signedData 署名の対象になるデータ
+type signedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// signatureBlob 署名の本体。armorしたものがgpgsigヘッダになる
+type signatureBlob struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// Signature 検証できた署名
+type Signature struct {
+	PublicKey ssh.PublicKey
+	Namespace string
+}
+
+// Sign messageにnamespaceで署名して、armorした署名を返す
+func Sign(signer ssh.Signer, namespace string, message []byte) (string, error) {
+
+	data, err := toBeSigned(namespace, "sha512", message)
+	if err != nil {
+		return "", err
+	}
+
+	var sig *ssh.Signature
+
+	// ssh-rsaのSHA-1での署名はsshsigでは使えない
+	if as, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = as.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte(magicPreamble), ssh.Marshal(signatureBlob{
+		Version:       sigVersion,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sig),
+	})...)
+
+	return armor(blob), nil
+}
+
+// Verify armorした署名がnamespaceでmessageに署名したものか確かめる
+func Verify(armored string, namespace string, message []byte) (*Signature, error) {
+
+	block, _ := pem.Decode([]byte(armored))
+	if block == nil || block.Type != pemType {
+		return nil, ErrNotSSHSignature
+	}
+
+	if !bytes.HasPrefix(block.Bytes, []byte(magicPreamble)) {
+		return nil, ErrNotSSHSignature
+	}
+
+	var blob signatureBlob
+	if err := ssh.Unmarshal(block.Bytes[len(magicPreamble):], &blob); err != nil {
+		return nil, err
+	}
+
+	if blob.Version != sigVersion {
+		return nil, fmt.Errorf("unsupported signature version %d", blob.Version)
+	}
+
+	if blob.Namespace != namespace {
+		return nil, fmt.Errorf("signature namespace %q does not match %q", blob.Namespace, namespace)
+	}
+
+	pub, err := ssh.ParsePublicKey(blob.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(blob.Signature, &sig); err != nil {
+		return nil, err
+	}
+
+	if pub.Type() == ssh.KeyAlgoRSA && sig.Format == ssh.KeyAlgoRSA {
+		return nil, errors.New("ssh-rsa signatures with SHA-1 are not allowed")
+	}
+
+	data, err := toBeSigned(blob.Namespace, blob.HashAlgorithm, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pub.Verify(data, &sig); err != nil {
+		return nil, ErrBadSignature
+	}
+
+	return &Signature{PublicKey: pub, Namespace: blob.Namespace}, nil
+}
+
+func toBeSigned(namespace, algorithm string, message []byte) ([]byte, error) {
+
+	var h hash.Hash
+	switch algorithm {
+	case "sha512":
+		h = sha512.New()
+	case "sha256":
+		h = sha256.New()
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s", algorithm)
+	}
+	h.Write(message)
+
+	return append([]byte(magicPreamble), ssh.Marshal(signedData{
+		Namespace:     namespace,
+		HashAlgorithm: algorithm,
+		Hash:          h.Sum(nil),
+	})...), nil
+}
+
+// armor ssh-keygenと同じく70文字で折り返す
+func armor(blob []byte) string {
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+
+	var b strings.Builder
+	b.WriteString("-----BEGIN " + pemType + "-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	b.WriteString(encoded + "\n")
+	b.WriteString("-----END " + pemType + "-----\n")
+
+	return b.String()
+}
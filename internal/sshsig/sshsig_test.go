@@ -0,0 +1,144 @@
+package sshsig
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ssh-keygen -Y sign -f key -n git で作った署名
+const (
+	opensshPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAG1A11NEZYbfkmtWgp0wMkg+eeHYwYi0Glin8SjaXyr test"
+	opensshSignature = `-----BEGIN SSH SIGNATURE-----
+U1NIU0lHAAAAAQAAADMAAAALc3NoLWVkMjU1MTkAAAAgAbUDXU0Rlht+Sa1aCnTAySD554
+djBiLQaWKfxKNpfKsAAAADZ2l0AAAAAAAAAAZzaGE1MTIAAABTAAAAC3NzaC1lZDI1NTE5
+AAAAQC1OaJPEYUXG5VF8vmHx+myFV7Thr/5pShAmAcDa0oqdDocpVYpMnPnwTxaliX/WQz
+Bzml4aSTt+pkjwINe73wU=
+-----END SSH SIGNATURE-----
+`
+)
+
+func TestVerifyOpenSSHSignature(t *testing.T) {
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(opensshPublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := Verify(opensshSignature, "git", []byte("hello sshsig\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ssh.FingerprintSHA256(sig.PublicKey) != ssh.FingerprintSHA256(pub) {
+		t.Fatalf("unexpected key %s", ssh.FingerprintSHA256(sig.PublicKey))
+	}
+
+	if _, err := Verify(opensshSignature, "git", []byte("tampered\n")); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expect bad signature, got %v", err)
+	}
+
+	if _, err := Verify(opensshSignature, "file", []byte("hello sshsig\n")); err == nil {
+		t.Fatal("expect namespace mismatch")
+	}
+
+}
+
+func TestSignAndVerify(t *testing.T) {
+
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []any{ed, rsaKey} {
+
+		signer, err := ssh.NewSignerFromKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		armored, err := Sign(signer, "git", []byte("message\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(armored, "-----BEGIN SSH SIGNATURE-----\n") || !strings.HasSuffix(armored, "-----END SSH SIGNATURE-----\n") {
+			t.Fatalf("unexpected armor %s", armored)
+		}
+
+		sig, err := Verify(armored, "git", []byte("message\n"))
+		if err != nil {
+			t.Fatalf("%s: %s", signer.PublicKey().Type(), err)
+		}
+
+		if ssh.FingerprintSHA256(sig.PublicKey) != ssh.FingerprintSHA256(signer.PublicKey()) {
+			t.Fatal("unexpected public key")
+		}
+	}
+
+}
+
+func TestAllowedSigners(t *testing.T) {
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(opensshPublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSigner, err := ssh.NewSignerFromKey(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := strings.TrimSuffix(opensshPublicKey, " test")
+	signers, err := ParseAllowedSigners([]byte(`# comment
+
+old@example.com namespaces="git",valid-before="20200101" ` + key + `
+"a@example.com,b@example.com" namespaces="file" ` + key + `
+me@example.com,*@example.org valid-after="20200101Z" ` + key + ` comment
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testt := []struct {
+		description string
+		key         ssh.PublicKey
+		namespace   string
+		at          time.Time
+		principal   string
+		found       bool
+	}{
+		{"valid before", pub, "git", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "old@example.com", true},
+		{"namespace", pub, "file", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "a@example.com,b@example.com", true},
+		{"valid after", pub, "git", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "me@example.com,*@example.org", true},
+		{"unknown key", otherSigner.PublicKey(), "git", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), "", false},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			principal, found := FindPrincipal(signers, tc.key, tc.namespace, tc.at)
+			if principal != tc.principal || found != tc.found {
+				t.Errorf("expect %s %v, got %s %v", tc.principal, tc.found, principal, found)
+			}
+		})
+	}
+
+}
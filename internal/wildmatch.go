@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CompileWildmatch gitのwildmatchをregexpに変換する。
+// pathnameがtrueなら * と ? はパス区切りにマッチせず、** だけがディレクトリをまたぐ
+func CompileWildmatch(pattern string, pathname, icase bool) (*regexp.Regexp, error) {
+
+	re := wildmatchToRegexp(pattern, pathname)
+	if icase {
+		re = "(?i)" + re
+	}
+
+	return regexp.Compile(re)
+}
+
+// HasWildcard patternにワイルドカードが含まれるか
+func HasWildcard(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+func wildmatchToRegexp(p string, pathname bool) string {
+
+	star, one := "[^/]*", "[^/]"
+	if !pathname {
+		star, one = ".*", "."
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(p); i++ {
+
+		switch c := p[i]; c {
+		case '*':
+
+			if pathname && i+1 < len(p) && p[i+1] == '*' && (i == 0 || p[i-1] == '/') {
+
+				j := i + 2
+				for j < len(p) && p[j] == '*' {
+					j++
+				}
+
+				if j == len(p) {
+					// 末尾の ** はすべてにマッチする
+					b.WriteString(".*")
+					i = j - 1
+					continue
+				}
+
+				if p[j] == '/' {
+					// **/ は0個以上のディレクトリにマッチする
+					b.WriteString("(?:.*/)?")
+					i = j
+					continue
+				}
+			}
+
+			for i+1 < len(p) && p[i+1] == '*' {
+				i++
+			}
+			b.WriteString(star)
+
+		case '?':
+			b.WriteString(one)
+
+		case '[':
+
+			class, n, ok := wildmatchClass(p[i:], pathname)
+			if !ok {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			b.WriteString(class)
+			i += n - 1
+
+		case '\\':
+
+			if i+1 < len(p) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(p[i])))
+
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// wildmatchClass [...]をregexpの文字クラスに変換する。消費したバイト数も返す
+func wildmatchClass(p string, pathname bool) (string, int, bool) {
+
+	var b strings.Builder
+	b.WriteString("[")
+
+	i, negate := 1, false
+	if i < len(p) && (p[i] == '!' || p[i] == '^') {
+		b.WriteString("^")
+		i, negate = i+1, true
+	}
+
+	for first := true; i < len(p); i, first = i+1, false {
+
+		c := p[i]
+
+		if c == ']' && !first {
+			if negate && pathname {
+				// パス区切りにはマッチさせない
+				b.WriteString("/")
+			}
+			b.WriteString("]")
+			return b.String(), i + 1, true
+		}
+
+		if c == '\\' && i+1 < len(p) {
+			i++
+			c = p[i]
+		}
+
+		switch c {
+		case '\\', '[', ']', '^':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+
+	return "", 0, false
+}
@@ -0,0 +1,44 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/mizuho-u/got/internal"
+)
+
+func TestWildmatch(t *testing.T) {
+
+	testt := []struct {
+		pattern  string
+		pathname bool
+		path     string
+		expect   bool
+	}{
+		{pattern: "*.txt", pathname: true, path: "a.txt", expect: true},
+		{pattern: "*.txt", pathname: true, path: "a/b.txt", expect: false},
+		{pattern: "*.txt", pathname: false, path: "a/b.txt", expect: true},
+		{pattern: "a/**/b", pathname: true, path: "a/b", expect: true},
+		{pattern: "a/**/b", pathname: true, path: "a/x/y/b", expect: true},
+		{pattern: "**/b", pathname: true, path: "x/y/b", expect: true},
+		{pattern: "a/**", pathname: true, path: "a/x/y", expect: true},
+		{pattern: "?.txt", pathname: true, path: "ab.txt", expect: false},
+		{pattern: "[a-c].txt", pathname: true, path: "b.txt", expect: true},
+		{pattern: "[!a-c].txt", pathname: true, path: "b.txt", expect: false},
+		{pattern: `\*.txt`, pathname: true, path: "*.txt", expect: true},
+		{pattern: `\*.txt`, pathname: true, path: "a.txt", expect: false},
+	}
+
+	for _, tc := range testt {
+
+		re, err := internal.CompileWildmatch(tc.pattern, tc.pathname, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if re.MatchString(tc.path) != tc.expect {
+			t.Errorf("%s (pathname %t): expect %t for %s", tc.pattern, tc.pathname, tc.expect, tc.path)
+		}
+
+	}
+
+}
@@ -1,25 +1,43 @@
 package database
 
 import (
+	"github.com/mizuho-u/got/io/database/internal/fs"
 	"github.com/mizuho-u/got/repository"
 	"github.com/mizuho-u/got/repository/object"
 	"github.com/mizuho-u/got/types"
 )
 
+// ErrRefNotFound 参照が存在しない
+var ErrRefNotFound = fs.ErrRefNotFound
+
 type Database interface {
-	Init() error
+	Init(opts InitOptions) (reinitialized bool, err error)
 	Refs() Refs
 	Objects() Objects
 	Index() index
+	Config() Config
 	Close() error
 }
 
+type Config interface {
+	Get(key string) (string, bool)
+	GetAll(key string) []string
+	Bool(key string) (bool, bool)
+	Int(key string) (int, bool)
+	Set(key, value string) error
+}
+
 type Refs interface {
 	Head() (object.Commit, error)
-	UpdateHeadCommit(commitId string) error
+	UpdateHeadCommit(commitId string, committer object.Author, message string) error
 	UpdateHeadRef(branchName types.BranchName) error
-	CreateBranch(branchName types.BranchName, oid string) error
+	CreateBranch(branchName types.BranchName, oid string, committer object.Author, message string) error
 	Ref(branchName string) (object.Commit, error)
+	HeadRef() (string, error)
+	ExpandRef(name string) (string, error)
+	ReadRef(name string) (string, error)
+	List() ([]string, error)
+	Reflog(fullname string) ([]repository.ReflogEntry, error)
 }
 
 type Objects interface {
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const gitfilePrefix = "gitdir: "
+
+var ErrRepositoryNotFound = errors.New("not a git repository (or any of the parent directories): .git")
+
+// Discover startから上に向かって.gitディレクトリかgitfile、ベアリポジトリを探す。ceilingsのディレクトリより上には行かない
+func Discover(start string, ceilings []string) (worktree, gitdir string, err error) {
+
+	dir := filepath.Clean(start)
+	limit := ceilingLimit(dir, ceilings)
+
+	for {
+
+		gitdir, err := gitdirOf(dir)
+		if err != nil {
+			return "", "", err
+		}
+
+		if gitdir != "" {
+			return dir, gitdir, nil
+		}
+
+		// ベアリポジトリにはワークスペースがない
+		if IsGitDir(dir) {
+			return "", dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || len(parent) <= limit {
+			return "", "", ErrRepositoryNotFound
+		}
+
+		dir = parent
+	}
+
+}
+
+// ResolveGitfile pathがgitfileなら参照先を、ディレクトリならそのまま返す
+func ResolveGitfile(path string) (string, error) {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, gitfilePrefix) {
+		return "", fmt.Errorf("invalid gitfile format: %s", path)
+	}
+
+	gitdir := strings.TrimPrefix(line, gitfilePrefix)
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(filepath.Dir(path), gitdir)
+	}
+
+	return filepath.Clean(gitdir), nil
+}
+
+func gitdirOf(dir string) (string, error) {
+
+	dotgit := filepath.Join(dir, ".git")
+
+	if _, err := os.Stat(dotgit); err != nil {
+		return "", nil
+	}
+
+	gitdir, err := ResolveGitfile(dotgit)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsGitDir(gitdir) {
+		return "", nil
+	}
+
+	return gitdir, nil
+}
+
+// IsGitDir HEADとobjects、refsがあればリポジトリとみなす
+func IsGitDir(path string) bool {
+
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ceilingLimit dirより上にあるceilingのうち最も深いもののパスの長さ
+func ceilingLimit(dir string, ceilings []string) int {
+
+	limit := -1
+
+	for _, c := range ceilings {
+
+		if c == "" || !filepath.IsAbs(c) {
+			continue
+		}
+
+		c = filepath.Clean(c)
+		if c == dir {
+			continue
+		}
+
+		if c == "/" || strings.HasPrefix(dir, c+"/") {
+			if len(c) > limit {
+				limit = len(c)
+			}
+		}
+	}
+
+	return limit
+}
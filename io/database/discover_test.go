@@ -0,0 +1,94 @@
+package database_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/types"
+)
+
+func TestDiscover(t *testing.T) {
+
+	root := t.TempDir()
+
+	branch, _ := types.NewBranchName("main")
+	if _, err := database.NewFSDB(root, filepath.Join(root, ".git")).Init(database.InitOptions{InitialBranch: branch}); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// gitfileで別の場所のリポジトリを指すワークスペース
+	linked := filepath.Join(t.TempDir(), "linked")
+	if err := os.MkdirAll(filepath.Join(linked, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: "+filepath.Join(root, ".git")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testt := []struct {
+		description string
+		start       string
+		ceilings    []string
+		worktree    string
+		gitdir      string
+		err         error
+	}{
+		{
+			description: "workspace root",
+			start:       root,
+			worktree:    root,
+			gitdir:      filepath.Join(root, ".git"),
+		},
+		{
+			description: "subdirectory",
+			start:       sub,
+			worktree:    root,
+			gitdir:      filepath.Join(root, ".git"),
+		},
+		{
+			description: "gitfile",
+			start:       filepath.Join(linked, "dir"),
+			worktree:    linked,
+			gitdir:      filepath.Join(root, ".git"),
+		},
+		{
+			description: "stops at ceiling directories",
+			start:       sub,
+			ceilings:    []string{filepath.Join(root, "src")},
+			err:         database.ErrRepositoryNotFound,
+		},
+		{
+			description: "ceiling above the workspace root",
+			start:       sub,
+			ceilings:    []string{filepath.Dir(root)},
+			worktree:    root,
+			gitdir:      filepath.Join(root, ".git"),
+		},
+	}
+
+	for _, tc := range testt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			worktree, gitdir, err := database.Discover(tc.start, tc.ceilings)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expect error %v, got %v", tc.err, err)
+			}
+
+			if worktree != tc.worktree || gitdir != tc.gitdir {
+				t.Fatalf("expect %s %s, got %s %s", tc.worktree, tc.gitdir, worktree, gitdir)
+			}
+
+		})
+
+	}
+
+}
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"fmt"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/mizuho-u/got/io/database/internal/fs"
 	"github.com/mizuho-u/got/types"
@@ -14,32 +17,121 @@ type fsdb struct {
 	refs    *fs.Refs
 	objects *fs.Objects
 	index   *fs.Index
+	config  *fs.Config
 }
 
 func NewFSDB(wsroot, gotroot string) *fsdb {
-	return &fsdb{wsroot: wsroot, gotroot: gotroot, refs: fs.NewRefs(gotroot), objects: fs.NewObjects(gotroot), index: fs.NewIndex(gotroot)}
+	return &fsdb{wsroot: wsroot, gotroot: gotroot, refs: fs.NewRefs(gotroot), objects: fs.NewObjects(gotroot), index: fs.NewIndex(gotroot), config: fs.NewConfig(gotroot)}
 }
 
-func (f *fsdb) Init() error {
+type InitOptions struct {
+	InitialBranch types.BranchName
+	Bare          bool
+	TemplateDir   string
+}
+
+// Init リポジトリを作成する。既にリポジトリがあればHEADやrefsはそのままにしてtemplateだけコピーする
+func (f *fsdb) Init(opts InitOptions) (reinitialized bool, err error) {
+
+	reinitialized = IsGitDir(f.gotroot)
 
-	if err := os.MkdirAll(filepath.Join(f.gotroot, "objects"), os.ModeDir|0755); err != nil {
-		return err
+	if err := os.MkdirAll(f.gotroot, os.ModeDir|0755); err != nil {
+		return false, err
 	}
 
-	if err := os.MkdirAll(filepath.Join(f.gotroot, "refs/heads"), os.ModeDir|0755); err != nil {
-		return err
+	if opts.TemplateDir != "" {
+		if err := copyTemplate(opts.TemplateDir, f.gotroot); err != nil {
+			return false, err
+		}
 	}
 
-	if err := f.refs.UpdateRef("main", ""); err != nil {
-		return err
+	if reinitialized {
+		return true, nil
 	}
 
-	ref, _ := types.NewBranchName("main")
-	if err := f.refs.UpdateHeadRef(ref); err != nil {
-		return err
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(f.gotroot, dir), os.ModeDir|0755); err != nil {
+			return false, err
+		}
 	}
 
-	return nil
+	core := [][2]string{
+		{"core.repositoryformatversion", "0"},
+		{"core.filemode", "true"},
+		{"core.bare", strconv.FormatBool(opts.Bare)},
+	}
+	if !opts.Bare {
+		core = append(core, [2]string{"core.logallrefupdates", "true"})
+	}
+
+	for _, kv := range core {
+		if err := f.config.Set(kv[0], kv[1]); err != nil {
+			return false, err
+		}
+	}
+
+	if err := f.refs.UpdateRef(opts.InitialBranch.String(), "", nil, ""); err != nil {
+		return false, err
+	}
+
+	if err := f.refs.UpdateHeadRef(opts.InitialBranch); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// copyTemplate templateのファイルをgotrootにコピーする。既にあるファイルは上書きしない
+func copyTemplate(template, gotroot string) error {
+
+	if _, err := os.Stat(template); err != nil {
+		return fmt.Errorf("templates not found in %s", template)
+	}
+
+	return filepath.WalkDir(template, func(path string, d iofs.DirEntry, err error) error {
+
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(template, path)
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(gotroot, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(dest, info.Mode().Perm()|0700)
+		}
+
+		if _, err := os.Lstat(dest); err == nil {
+			return nil
+		}
+
+		if info.Mode()&iofs.ModeSymlink != 0 {
+
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, dest)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(dest, data, info.Mode().Perm())
+	})
+
 }
 
 func (fs *fsdb) Refs() Refs {
@@ -54,6 +146,15 @@ func (fs *fsdb) Index() index {
 	return fs.index
 }
 
+func (fs *fsdb) Config() Config {
+	return fs.config
+}
+
+// GlobalConfig リポジトリの外ではユーザーごとの設定だけを読む
+func GlobalConfig() Config {
+	return fs.NewGlobalConfig()
+}
+
 func (fs *fsdb) Close() error {
 	return fs.index.Close()
 }
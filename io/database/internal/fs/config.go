@@ -0,0 +1,453 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type Config struct {
+	path    string
+	loaded  bool
+	entries []*configEntry
+}
+
+type configEntry struct {
+	key   string
+	value string
+}
+
+func NewConfig(gotpath string) *Config {
+	return &Config{path: filepath.Join(gotpath, "config")}
+}
+
+// NewGlobalConfig リポジトリの外で使う、ユーザーごとの設定だけを読む設定
+func NewGlobalConfig() *Config {
+	return &Config{}
+}
+
+// GlobalConfigPaths ユーザーごとの設定ファイル。後ろほど優先される
+func GlobalConfigPaths() []string {
+
+	if p := os.Getenv("GIT_CONFIG_GLOBAL"); p != "" {
+		return []string{p}
+	}
+
+	paths := []string{}
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	home, _ := os.UserHomeDir()
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+
+	if home != "" {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	return paths
+}
+
+func (c *Config) Load() error {
+
+	if c.loaded {
+		return nil
+	}
+
+	c.entries = []*configEntry{}
+
+	paths := GlobalConfigPaths()
+	if c.path != "" {
+		paths = append(paths, c.path)
+	}
+
+	for _, path := range paths {
+		if err := c.loadFile(path); err != nil {
+			return err
+		}
+	}
+
+	c.loaded = true
+
+	return nil
+}
+
+func (c *Config) loadFile(path string) error {
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+	case errors.Is(err, syscall.ENOENT):
+		return nil
+	default:
+		return err
+	}
+
+	entries, err := parseConfig(data)
+	if err != nil {
+		return fmt.Errorf("bad config file %s: %w", path, err)
+	}
+
+	c.entries = append(c.entries, entries...)
+
+	return nil
+}
+
+// Get keyの最後の値を返す。keyは section.name か section.subsection.name
+func (c *Config) Get(key string) (string, bool) {
+
+	values := c.GetAll(key)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[len(values)-1], true
+}
+
+func (c *Config) GetAll(key string) []string {
+
+	if err := c.Load(); err != nil {
+		return []string{}
+	}
+
+	key = normalizeConfigKey(key)
+
+	values := []string{}
+	for _, e := range c.entries {
+		if e.key == key {
+			values = append(values, e.value)
+		}
+	}
+
+	return values
+}
+
+func (c *Config) Bool(key string) (value bool, ok bool) {
+
+	v, ok := c.Get(key)
+	if !ok {
+		return false, false
+	}
+
+	switch strings.ToLower(v) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "", "false", "no", "off", "0":
+		return false, true
+	}
+
+	return false, false
+}
+
+func (c *Config) Int(key string) (value int, ok bool) {
+
+	v, ok := c.Get(key)
+	if !ok {
+		return 0, false
+	}
+
+	unit := 1
+	switch {
+	case strings.HasSuffix(v, "k"):
+		unit, v = 1024, strings.TrimSuffix(v, "k")
+	case strings.HasSuffix(v, "m"):
+		unit, v = 1024*1024, strings.TrimSuffix(v, "m")
+	case strings.HasSuffix(v, "g"):
+		unit, v = 1024*1024*1024, strings.TrimSuffix(v, "g")
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+
+	return n * unit, true
+}
+
+// Set リポジトリの設定ファイルに値を書き込む
+func (c *Config) Set(key, value string) error {
+
+	section, subsection, name, err := splitConfigKey(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil && !errors.Is(err, syscall.ENOENT) {
+		return err
+	}
+
+	lines := []string{}
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	entry := fmt.Sprintf("\t%s = %s", name, quoteConfigValue(value))
+
+	current, last := "", -1
+	for i, l := range lines {
+
+		trimmed := strings.TrimSpace(l)
+
+		if strings.HasPrefix(trimmed, "[") {
+			if s, sub, err := parseSectionHeader(trimmed); err == nil {
+				current = s + "." + sub
+			}
+			continue
+		}
+
+		if current != section+"."+subsection {
+			continue
+		}
+
+		last = i
+
+		k, _, _ := strings.Cut(trimmed, "=")
+		if strings.EqualFold(strings.TrimSpace(k), name) {
+			lines[i] = entry
+			return c.write(lines)
+		}
+	}
+
+	if last == -1 {
+		for i, l := range lines {
+			if s, sub, err := parseSectionHeader(strings.TrimSpace(l)); err == nil && s+"."+sub == section+"."+subsection {
+				last = i
+			}
+		}
+	}
+
+	if last == -1 {
+		header := fmt.Sprintf("[%s]", section)
+		if subsection != "" {
+			header = fmt.Sprintf("[%s %q]", section, subsection)
+		}
+		lines = append(lines, header, entry)
+	} else {
+		lines = append(lines[:last+1], append([]string{entry}, lines[last+1:]...)...)
+	}
+
+	return c.write(lines)
+}
+
+func (c *Config) write(lines []string) error {
+
+	lock, err := NewLockfile(c.path)
+	if err != nil {
+		return err
+	}
+
+	if err := lock.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		lock.Release()
+		return err
+	}
+
+	c.loaded = false
+
+	return lock.Commit()
+}
+
+func parseConfig(data []byte) ([]*configEntry, error) {
+
+	entries := []*configEntry{}
+
+	section, subsection := "", ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+
+		l := strings.TrimSpace(scanner.Text())
+
+		// 行末のバックスラッシュは次の行に続く
+		for strings.HasSuffix(l, `\`) && !strings.HasSuffix(l, `\\`) && scanner.Scan() {
+			l = strings.TrimSuffix(l, `\`) + scanner.Text()
+			n++
+		}
+
+		if l == "" || l[0] == '#' || l[0] == ';' {
+			continue
+		}
+
+		if l[0] == '[' {
+
+			end := strings.LastIndex(l, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated section header", n)
+			}
+
+			s, sub, err := parseSectionHeader(l[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			section, subsection = s, sub
+
+			if rest := strings.TrimSpace(l[end+1:]); rest == "" || rest[0] == '#' || rest[0] == ';' {
+				continue
+			} else {
+				l = rest
+			}
+		}
+
+		if section == "" {
+			return nil, fmt.Errorf("line %d: key outside of section", n)
+		}
+
+		name, value, hasValue := strings.Cut(l, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("line %d: empty key", n)
+		}
+
+		v := "true"
+		if hasValue {
+			var err error
+			if v, err = parseConfigValue(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+
+		key := section + "." + name
+		if subsection != "" {
+			key = section + "." + subsection + "." + name
+		}
+
+		entries = append(entries, &configEntry{key, v})
+	}
+
+	return entries, scanner.Err()
+}
+
+func parseSectionHeader(s string) (section, subsection string, err error) {
+
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return "", "", errors.New("invalid section header")
+	}
+
+	s = strings.TrimSpace(s[1 : len(s)-1])
+
+	if name, sub, ok := strings.Cut(s, " "); ok {
+
+		sub = strings.TrimSpace(sub)
+		if len(sub) < 2 || sub[0] != '"' || sub[len(sub)-1] != '"' {
+			return "", "", errors.New("invalid subsection")
+		}
+
+		unquoted, err := parseConfigValue(sub)
+		if err != nil {
+			return "", "", err
+		}
+
+		return strings.ToLower(name), unquoted, nil
+	}
+
+	// 古い形式 [section.subsection]
+	if name, sub, ok := strings.Cut(s, "."); ok {
+		return strings.ToLower(name), strings.ToLower(sub), nil
+	}
+
+	return strings.ToLower(s), "", nil
+}
+
+func parseConfigValue(s string) (string, error) {
+
+	var b strings.Builder
+	quoted := false
+	pending := ""
+
+	s = strings.TrimSpace(s)
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		switch {
+		case c == '"':
+			quoted = !quoted
+			b.WriteString(pending)
+			pending = ""
+		case c == '\\':
+			if i+1 >= len(s) {
+				return "", errors.New("bad escape at end of value")
+			}
+			i++
+			b.WriteString(pending)
+			pending = ""
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case '\\', '"':
+				b.WriteByte(s[i])
+			default:
+				return "", fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		case !quoted && (c == '#' || c == ';'):
+			return b.String(), nil
+		case !quoted && (c == ' ' || c == '\t'):
+			// 値の途中の空白は残し、末尾の空白は捨てる
+			pending += string(c)
+		default:
+			b.WriteString(pending)
+			pending = ""
+			b.WriteByte(c)
+		}
+	}
+
+	if quoted {
+		return "", errors.New("unterminated quote")
+	}
+
+	return b.String(), nil
+}
+
+func quoteConfigValue(v string) string {
+
+	if v == "" || strings.ContainsAny(v, "#;\"\\\n\t") || strings.TrimSpace(v) != v {
+		return strconv.Quote(v)
+	}
+
+	return v
+}
+
+func normalizeConfigKey(key string) string {
+
+	section, subsection, name, err := splitConfigKey(key)
+	if err != nil {
+		return strings.ToLower(key)
+	}
+
+	if subsection == "" {
+		return section + "." + name
+	}
+
+	return section + "." + subsection + "." + name
+}
+
+// splitConfigKey section名とkey名は大文字小文字を区別しない。subsectionは区別する
+func splitConfigKey(key string) (section, subsection, name string, err error) {
+
+	first := strings.Index(key, ".")
+	last := strings.LastIndex(key, ".")
+	if first == -1 || first == len(key)-1 || last == len(key)-1 {
+		return "", "", "", fmt.Errorf("key does not contain a section: %s", key)
+	}
+
+	section = strings.ToLower(key[:first])
+	name = strings.ToLower(key[last+1:])
+	if first != last {
+		subsection = key[first+1 : last]
+	}
+
+	return section, subsection, name, nil
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+
+	dir := t.TempDir()
+
+	content := `# comment
+[core]
+	excludesFile = ~/.gitignore_global ; trailing comment
+	bare
+[Diff "go"]
+	xfuncname = "^func \"(.*)\"$"
+[remote "origin"]
+	url = a
+	url = b
+`
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewConfig(dir)
+
+	testt := []struct {
+		key    string
+		expect string
+	}{
+		{key: "core.excludesfile", expect: "~/.gitignore_global"},
+		{key: "CORE.excludesFile", expect: "~/.gitignore_global"},
+		{key: "core.bare", expect: "true"},
+		{key: "diff.go.xfuncname", expect: `^func "(.*)"$`},
+		{key: "remote.origin.url", expect: "b"},
+	}
+
+	for _, tc := range testt {
+
+		got, ok := config.Get(tc.key)
+		if !ok || got != tc.expect {
+			t.Errorf("%s: expect %q, got %q", tc.key, tc.expect, got)
+		}
+
+	}
+
+	if all := config.GetAll("remote.origin.url"); len(all) != 2 {
+		t.Errorf("expect 2 values, got %v", all)
+	}
+
+	if err := config.Set("core.bare", "false"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.Set("init.defaultBranch", "trunk"); err != nil {
+		t.Fatal(err)
+	}
+
+	if bare, ok := config.Bool("core.bare"); !ok || bare {
+		t.Errorf("expect core.bare false, got %t", bare)
+	}
+
+	if branch, ok := config.Get("init.defaultbranch"); !ok || branch != "trunk" {
+		t.Errorf("expect init.defaultBranch trunk, got %s", branch)
+	}
+
+	if url, _ := config.Get("remote.origin.url"); url != "b" {
+		t.Errorf("expect remote.origin.url to be kept, got %s", url)
+	}
+
+}
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -73,7 +74,7 @@ func (i *Index) Update(index repository.Index) error {
 func (i *Index) Read(p []byte) (n int, err error) {
 
 	if i.file == nil {
-		return 0, nil
+		return 0, io.EOF
 	}
 
 	return i.file.Read(p)
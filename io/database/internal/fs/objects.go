@@ -80,6 +80,11 @@ func isExist(path string) bool {
 
 func loadPrefix(gotroot, prefix string) ([]object.Object, error) {
 
+	// オブジェクトのディレクトリは先頭の2文字
+	if len(prefix) < 2 {
+		return nil, fmt.Errorf("object prefix '%s' is too short", prefix)
+	}
+
 	objects := []object.Object{}
 	err := filepath.Walk(filepath.Join(gotroot, "objects", prefix[0:2]), func(path string, info fs.FileInfo, err error) error {
 
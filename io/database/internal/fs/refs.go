@@ -1,16 +1,23 @@
 package fs
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/mizuho-u/got/repository"
 	"github.com/mizuho-u/got/repository/object"
 	"github.com/mizuho-u/got/types"
 )
 
+var ErrRefNotFound = errors.New("ref not found")
+
 type Refs struct {
 	gotpath string
 }
@@ -23,34 +30,49 @@ func (r *Refs) heads(branch string) string {
 	return filepath.Join(r.gotpath, "refs", "heads", branch)
 }
 
+// Head ブランチにまだコミットがなければ空のコミット
 func (r *Refs) Head() (object.Commit, error) {
 
-	ref, err := r.resolveHead()
+	oid, err := r.read("HEAD")
+	if errors.Is(err, ErrRefNotFound) || oid == "" {
+		return object.EmptyCommit(), nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return r.Ref(filepath.Base(ref))
+	return r.commit(oid)
 }
 
 const head string = `ref: (.+)`
 
 func (r *Refs) resolveHead() (string, error) {
 
-	f, err := os.Open(filepath.Join(r.gotpath, "HEAD"))
+	read, err := os.ReadFile(filepath.Join(r.gotpath, "HEAD"))
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
 
-	read, err := io.ReadAll(f)
+	match := regexp.MustCompile(head).FindStringSubmatch(string(read))
+	if match == nil {
+		return "", errors.New("HEAD is not a symbolic ref")
+	}
+
+	return strings.TrimSpace(match[1]), nil
+}
+
+// HeadRef HEADが指すブランチの参照名。HEADが切り離されていれば空
+func (r *Refs) HeadRef() (string, error) {
+
+	ref, err := r.resolveHead()
 	if err != nil {
+		if _, statErr := os.Stat(filepath.Join(r.gotpath, "HEAD")); statErr == nil {
+			return "", nil
+		}
 		return "", err
 	}
 
-	match := regexp.MustCompile(head).FindStringSubmatch(string(read))
-
-	return match[1], nil
+	return ref, nil
 }
 
 func (r *Refs) UpdateHeadRef(branchName types.BranchName) error {
@@ -70,31 +92,40 @@ func (r *Refs) UpdateHeadRef(branchName types.BranchName) error {
 
 }
 
-func (r *Refs) UpdateHeadCommit(commitId string) error {
+// UpdateHeadCommit HEADが指すブランチをcommitIdにして、ブランチとHEADの記録に残す
+func (r *Refs) UpdateHeadCommit(commitId string, committer object.Author, message string) error {
 
 	ref, err := r.resolveHead()
 	if err != nil {
 		return err
 	}
 
-	head, err := NewLockfile(r.heads(filepath.Base(ref)))
+	old, err := r.current(ref)
 	if err != nil {
 		return err
 	}
-	defer head.Commit()
 
-	err = head.Write([]byte(commitId))
+	head, err := NewLockfile(filepath.Join(r.gotpath, ref))
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err := head.Write([]byte(commitId)); err != nil {
+		head.Release()
+		return err
+	}
+
+	if err := head.Commit(); err != nil {
+		return err
+	}
+
+	return r.appendReflog(repository.ReflogEntry{Old: old, New: commitId, Committer: committer, Message: message}, ref, "HEAD")
 }
 
-func (r *Refs) CreateBranch(branchName types.BranchName, oid string) error {
+func (r *Refs) CreateBranch(branchName types.BranchName, oid string, committer object.Author, message string) error {
 
 	if oid != "" {
-		return r.UpdateRef(branchName.String(), oid)
+		return r.UpdateRef(branchName.String(), oid, committer, message)
 	}
 
 	head, err := r.Head()
@@ -102,10 +133,10 @@ func (r *Refs) CreateBranch(branchName types.BranchName, oid string) error {
 		return err
 	}
 
-	return r.UpdateRef(branchName.String(), head.OID())
+	return r.UpdateRef(branchName.String(), head.OID(), committer, message)
 }
 
-func (r *Refs) UpdateRef(name, oid string) error {
+func (r *Refs) UpdateRef(name, oid string, committer object.Author, message string) error {
 
 	path := r.heads(name)
 	if isExist(path) {
@@ -121,40 +152,253 @@ func (r *Refs) UpdateRef(name, oid string) error {
 		return ref.Release()
 	}
 
-	return ref.Commit()
+	if err := ref.Commit(); err != nil {
+		return err
+	}
+
+	// initで作る、まだコミットのないブランチは記録しない
+	if oid == "" {
+		return nil
+	}
+
+	return r.appendReflog(repository.ReflogEntry{Old: nullOID, New: oid, Committer: committer, Message: message}, "refs/heads/"+name)
+}
+
+// nullOID まだない参照の前の値
+const nullOID = "0000000000000000000000000000000000000000"
+
+// current 参照が今指しているもの。まだないか、initで作った空のブランチならnullOID
+func (r *Refs) current(full string) (string, error) {
+
+	oid, err := r.read(full)
+	if errors.Is(err, ErrRefNotFound) || (err == nil && oid == "") {
+		return nullOID, nil
+	}
+
+	return oid, err
+}
+
+// appendReflog gitと同じく、core.logallrefupdatesが有効か、すでに記録があれば、logs/以下のrefsの記録にentryを足す
+func (r *Refs) appendReflog(entry repository.ReflogEntry, refs ...string) error {
+
+	enabled, _ := NewConfig(r.gotpath).Bool("core.logallrefupdates")
+
+	for _, ref := range refs {
+
+		path := filepath.Join(r.gotpath, "logs", ref)
+		if !enabled && !isExist(path) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.WriteString(entry.String()); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *Refs) Ref(branchName string) (object.Commit, error) {
 
-	if branchName == "HEAD" {
-		return r.Head()
+	oid, err := r.ReadRef(branchName)
+	if err != nil {
+		return nil, err
 	}
 
-	f, err := os.Open(r.heads(branchName))
+	// mainが空なのはいいけど他はどうしようかなー
+	if oid == "" {
+		return object.EmptyCommit(), nil
+	}
+
+	return r.commit(oid)
+}
+
+func (r *Refs) commit(oid string) (object.Commit, error) {
+
+	obj, err := load(r.gotpath, oid)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
-	commitId, err := io.ReadAll(f)
+	return object.ParseCommit(obj)
+}
+
+// gitと同じ順に省略された参照名を探す
+var refRules = []string{"%s", "refs/%s", "refs/tags/%s", "refs/heads/%s", "refs/remotes/%s", "refs/remotes/%s/HEAD"}
+
+var rootRef = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+
+// ExpandRef mainをrefs/heads/mainのように、存在する参照の完全な名前にする
+func (r *Refs) ExpandRef(name string) (string, error) {
+
+	packed, err := r.packedRefs()
+	if err != nil {
+		return "", err
+	}
+
+	for i, rule := range refRules {
+
+		// .git直下はHEADなどの大文字の名前だけ
+		if i == 0 && !rootRef.MatchString(name) && !strings.HasPrefix(name, "refs/") {
+			continue
+		}
+
+		full := fmt.Sprintf(rule, name)
+		if info, err := os.Stat(filepath.Join(r.gotpath, full)); err == nil && !info.IsDir() {
+			return full, nil
+		}
+
+		if _, ok := packed[full]; ok {
+			return full, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrRefNotFound, name)
+}
+
+// ReadRef 省略された参照名のoid。まだコミットのないブランチなら空
+func (r *Refs) ReadRef(name string) (string, error) {
+
+	full, err := r.ExpandRef(name)
+	if err != nil {
+		return "", err
+	}
+
+	return r.read(full)
+}
+
+// read シンボリック参照をたどって、ファイルかpacked-refsからoidを読む
+func (r *Refs) read(full string) (string, error) {
+
+	for depth := 0; depth < 5; depth++ {
+
+		data, err := os.ReadFile(filepath.Join(r.gotpath, full))
+		if err == nil {
+
+			content := strings.TrimSpace(string(data))
+			if target, ok := strings.CutPrefix(content, "ref: "); ok {
+				full = target
+				continue
+			}
+
+			return content, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		packed, err := r.packedRefs()
+		if err != nil {
+			return "", err
+		}
+
+		if oid, ok := packed[full]; ok {
+			return oid, nil
+		}
+
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, full)
+	}
+
+	return "", fmt.Errorf("symbolic ref %s is too deep", full)
+}
+
+// packedRefs git gcでまとめられた参照
+func (r *Refs) packedRefs() (map[string]string, error) {
+
+	refs := map[string]string{}
+
+	f, err := os.Open(filepath.Join(r.gotpath, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return refs, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
-	// mainが空なのはいいけど他はどうしようかなー
-	if len(commitId) == 0 {
-		return object.EmptyCommit(), nil
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+
+		line := scanner.Text()
+
+		// #はヘッダ、^は直前のタグが指すオブジェクト
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+
+		if oid, name, ok := strings.Cut(line, " "); ok {
+			refs[name] = oid
+		}
 	}
 
-	obj, err := load(r.gotpath, string(commitId))
+	return refs, scanner.Err()
+}
+
+// List refs/以下のすべての参照名
+func (r *Refs) List() ([]string, error) {
+
+	packed, err := r.packedRefs()
 	if err != nil {
 		return nil, err
 	}
 
-	commit, err := object.ParseCommit(obj)
+	names := map[string]struct{}{}
+	for name := range packed {
+		names[name] = struct{}{}
+	}
+
+	err = filepath.WalkDir(filepath.Join(r.gotpath, "refs"), func(path string, d fs.DirEntry, err error) error {
+
+		if err != nil || d.IsDir() || strings.HasSuffix(path, ".lock") {
+			return err
+		}
+
+		rel, err := filepath.Rel(r.gotpath, path)
+		if err != nil {
+			return err
+		}
+
+		names[filepath.ToSlash(rel)] = struct{}{}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+
+	return list, nil
+}
+
+// Reflog logs/以下の参照の記録。古い順
+func (r *Refs) Reflog(full string) ([]repository.ReflogEntry, error) {
+
+	data, err := os.ReadFile(filepath.Join(r.gotpath, "logs", full))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []repository.ReflogEntry{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return commit, nil
+	return repository.ParseReflog(string(data))
 }
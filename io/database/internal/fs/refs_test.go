@@ -0,0 +1,77 @@
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadRef(t *testing.T) {
+
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"HEAD":                     "ref: refs/heads/main\n",
+		"refs/heads/main":          "1111111111111111111111111111111111111111\n",
+		"refs/heads/v1.0":          "2222222222222222222222222222222222222222",
+		"refs/remotes/origin/HEAD": "ref: refs/remotes/origin/main\n",
+		"packed-refs": `# pack-refs with: peeled fully-peeled sorted
+3333333333333333333333333333333333333333 refs/remotes/origin/main
+4444444444444444444444444444444444444444 refs/tags/v1.0
+^5555555555555555555555555555555555555555
+`,
+	}
+
+	for name, content := range files {
+
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs := NewRefs(dir)
+
+	testt := []struct {
+		name, full, oid string
+	}{
+		{"HEAD", "HEAD", "1111111111111111111111111111111111111111"},
+		{"main", "refs/heads/main", "1111111111111111111111111111111111111111"},
+		// タグがブランチより先
+		{"v1.0", "refs/tags/v1.0", "4444444444444444444444444444444444444444"},
+		{"origin/main", "refs/remotes/origin/main", "3333333333333333333333333333333333333333"},
+		{"origin", "refs/remotes/origin/HEAD", "3333333333333333333333333333333333333333"},
+	}
+
+	for _, tc := range testt {
+
+		full, err := refs.ExpandRef(tc.name)
+		if err != nil || full != tc.full {
+			t.Errorf("%s: expect %s, got %s %v", tc.name, tc.full, full, err)
+		}
+
+		oid, err := refs.ReadRef(tc.name)
+		if err != nil || oid != tc.oid {
+			t.Errorf("%s: expect %s, got %s %v", tc.name, tc.oid, oid, err)
+		}
+	}
+
+	if _, err := refs.ReadRef("config"); !errors.Is(err, ErrRefNotFound) {
+		t.Errorf("expect not found, got %v", err)
+	}
+
+	list, err := refs.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"refs/heads/main", "refs/heads/v1.0", "refs/remotes/origin/HEAD", "refs/remotes/origin/main", "refs/tags/v1.0"}
+	if !reflect.DeepEqual(list, expect) {
+		t.Errorf("expect %v, got %v", expect, list)
+	}
+
+}
@@ -15,25 +15,83 @@ import (
 
 	"github.com/mizuho-u/got/repository"
 	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
 )
 
 type fileScanner struct {
-	root   string
-	ignore string
-	files  internal.Queue[*file]  // rootからのrelpath
-	dirs   internal.Queue[string] // fullpath
+	root     string
+	gotroot  string
+	ignore   repository.Ignore
+	tracked  func(path string) bool
+	pathspec types.Pathspec
+	files    internal.Queue[*file]  // rootからのrelpath
+	dirs     internal.Queue[string] // fullpath
+}
+
+type ScanOption func(*fileScanner) error
+
+// WithIgnore .gitignoreなどのルールにマッチするファイルをスキャンしない
+func WithIgnore(ignore repository.Ignore) ScanOption {
+
+	return func(fs *fileScanner) error {
+		fs.ignore = ignore
+		return nil
+	}
+
+}
+
+// WithTracked 追跡されているファイルは除外ルールにマッチしてもスキャンする
+func WithTracked(tracked func(path string) bool) ScanOption {
+
+	return func(fs *fileScanner) error {
+		fs.tracked = tracked
+		return nil
+	}
+
+}
+
+// WithPathspec pathspecにマッチしないディレクトリとファイルをスキャンしない
+func WithPathspec(ps types.Pathspec) ScanOption {
+
+	return func(fs *fileScanner) error {
+		fs.pathspec = ps
+		return nil
+	}
+
 }
 
 // Scan nameをスキャンしてrootDirからの相対パスを取得するfileScannerを生成する
-func Scan(rootDir, name, ignore string) (*fileScanner, error) {
+func Scan(rootDir, name, gotroot string, options ...ScanOption) (*fileScanner, error) {
 
-	scanner := &fileScanner{root: rootDir, ignore: ignore, files: internal.Queue[*file]{}, dirs: internal.Queue[string]{}}
+	scanner := &fileScanner{root: rootDir, gotroot: gotroot, files: internal.Queue[*file]{}, dirs: internal.Queue[string]{}}
+
+	for _, opt := range options {
+		if err := opt(scanner); err != nil {
+			return nil, err
+		}
+	}
 
 	info, err := os.Stat(name)
 	if err != nil {
 		return nil, err
 	}
 
+	if scanner.ignore != nil {
+
+		rel, err := filepath.Rel(rootDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := LoadIgnoreRules(scanner.ignore, rootDir, filepath.Dir(rel)); err != nil {
+			return nil, err
+		}
+
+		if rel != "." && scanner.ignored(name, info.IsDir()) {
+			return nil, fmt.Errorf("the following paths are ignored by one of your .gitignore files:\n%s", rel)
+		}
+	}
+
 	if info.IsDir() {
 		scanner.dirs = append(scanner.dirs, name)
 	} else if err := scanner.enqueueFile(filepath.Dir(name), info); err != nil {
@@ -55,10 +113,16 @@ func (fs *fileScanner) Next() (repository.WorkspaceEntry, error) {
 		return nil, nil
 	}
 
-	if fs.ignore != "" && strings.HasPrefix(dir, fs.ignore) {
+	if fs.gotroot != "" && (dir == fs.gotroot || strings.HasPrefix(dir, fs.gotroot+"/")) {
 		return fs.Next()
 	}
 
+	if fs.ignore != nil {
+		if err := fs.loadIgnoreRules(dir); err != nil {
+			return nil, err
+		}
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
@@ -66,6 +130,14 @@ func (fs *fileScanner) Next() (repository.WorkspaceEntry, error) {
 
 	for _, entry := range entries {
 
+		if fs.ignored(filepath.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+
+		if !fs.inPathspec(filepath.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+
 		if entry.IsDir() {
 			fs.dirs.Enqueue(filepath.Join(dir, entry.Name()))
 			continue
@@ -82,6 +154,210 @@ func (fs *fileScanner) Next() (repository.WorkspaceEntry, error) {
 	return fs.Next()
 }
 
+func (fs *fileScanner) loadIgnoreRules(dir string) error {
+
+	rel, err := filepath.Rel(fs.root, dir)
+	if err != nil {
+		return err
+	}
+
+	return loadIgnoreFile(fs.ignore, fs.root, rel)
+}
+
+func (fs *fileScanner) inPathspec(path string, isDir bool) bool {
+
+	if fs.pathspec == nil {
+		return true
+	}
+
+	rel, err := filepath.Rel(fs.root, path)
+	if err != nil {
+		return false
+	}
+
+	if isDir {
+		return fs.pathspec.MatchDir(rel)
+	}
+
+	return fs.pathspec.Match(rel)
+}
+
+func (fs *fileScanner) ignored(path string, isDir bool) bool {
+
+	if fs.ignore == nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(fs.root, path)
+	if err != nil {
+		return false
+	}
+
+	if fs.tracked != nil && fs.tracked(rel) {
+		return false
+	}
+
+	_, ignored := fs.ignore.Match(rel, isDir)
+
+	return ignored
+}
+
+// LoadIgnore excludesのファイルを順に読み込む。存在しないファイルは無視する
+func LoadIgnore(root string, excludes ...string) (repository.Ignore, error) {
+
+	ignore := repository.NewIgnore()
+
+	for _, path := range excludes {
+
+		f, err := os.Open(path)
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		source := path
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			source = rel
+		}
+
+		err = ignore.AddExcludes(source, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ignore, nil
+}
+
+// LoadIgnoreRules rootからdirまでの各ディレクトリの.gitignoreを読み込む
+func LoadIgnoreRules(ignore repository.Ignore, root, dir string) error {
+
+	dirs := []string{"."}
+	if dir != "." {
+		dirs = append(dirs, append(internal.ParentDirs(dir), dir)...)
+	}
+
+	for _, d := range dirs {
+		if err := loadIgnoreFile(ignore, root, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadIgnoreFile(ignore repository.Ignore, root, dir string) error {
+
+	source := filepath.Join(dir, ".gitignore")
+
+	f, err := os.Open(filepath.Join(root, source))
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return ignore.AddRules(dir, source, f)
+}
+
+// LoadFiles --no-indexで比べるpathのファイル。ファイルなら空のパスで、ディレクトリなら中のファイルをpathからの相対パスで読む。
+// シンボリックリンクはリンク先を読む
+func LoadFiles(path string) (map[string]*repository.NoIndexFile, error) {
+
+	files := map[string]*repository.NoIndexFile{}
+
+	load := func(name, p string) error {
+
+		info, err := os.Stat(p)
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		files[name] = &repository.NoIndexFile{Mode: statToPermission(info), Data: data}
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return files, load("", path)
+	}
+
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+
+		return load(filepath.ToSlash(rel), p)
+	})
+
+	return files, err
+}
+
+// LoadAttributes pathsの各ディレクトリの.gitattributesとinfoのファイルを読み込む。存在しないファイルは無視する
+func LoadAttributes(root, info string, paths ...string) (repository.Attributes, error) {
+
+	attrs := repository.NewAttributes()
+
+	dirs := map[string]struct{}{".": {}}
+	for _, p := range paths {
+		for _, d := range internal.ParentDirs(p) {
+			dirs[d] = struct{}{}
+		}
+	}
+
+	for d := range dirs {
+
+		f, err := os.Open(filepath.Join(root, d, ".gitattributes"))
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = attrs.AddRules(d, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(info)
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR) {
+		return attrs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := attrs.AddInfoRules(f); err != nil {
+		return nil, err
+	}
+
+	return attrs, nil
+}
+
 func (fs *fileScanner) enqueueFile(dir string, info fs.FileInfo) error {
 
 	path, err := filepath.Rel(fs.root, filepath.Join(dir, info.Name()))
@@ -30,7 +30,80 @@ func (repo *repository) Add(scanner WorkspaceScanner) (objects []object.Object,
 		objects = append(objects, blob)
 
 		repo.index.Add(NewIndexEntry(f.Name(), blob.OID(), f.Stats()))
+		repo.workspace[f.Name()] = f
 
 	}
 
 }
+
+// RemoveDeleted pathspecにマッチする追跡済みファイルのうち、Addで見つからなかったものをインデックスから削除する
+func (repo *repository) RemoveDeleted() {
+
+	for name := range repo.index.entries {
+
+		if _, ok := repo.workspace[name]; ok || !repo.matches(name) {
+			continue
+		}
+
+		repo.index.Delete(name)
+	}
+
+}
+
+// UpdateTracked Scanで検出した追跡済みファイルの変更と削除をインデックスに反映する
+func (repo *repository) UpdateTracked() (objects []object.Object, err error) {
+
+	files, changes := repo.WorkspaceChanges()
+	for _, name := range files {
+
+		st := changes[name]
+		delete(repo.workspaceChanges, name)
+
+		if st == statusFileDeleted {
+			repo.index.Delete(name)
+			repo.updateIndexChange(name, "")
+			continue
+		}
+
+		f := repo.workspace[name]
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+
+		blob, err := object.NewBlob(name, data)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, blob)
+
+		repo.index.Add(NewIndexEntry(name, blob.OID(), f.Stats()))
+		repo.updateIndexChange(name, blob.OID())
+	}
+
+	return objects, nil
+}
+
+// updateIndexChange ステージした内容とHEADを比べ直す。oidが空なら削除
+func (repo *repository) updateIndexChange(name, oid string) {
+
+	h, inHead := repo.head[name]
+
+	switch {
+	case !inHead && oid == "":
+		delete(repo.indexChanges, name)
+	case !inHead:
+		repo.indexChanges[name] = statusIndexAdded
+	case oid == "":
+		repo.indexChanges[name] = statusFileDeleted
+	case h.OID() != oid || repo.index.entries[name].permission() != h.Permission():
+		repo.indexChanges[name] = statusFileModified
+	default:
+		delete(repo.indexChanges, name)
+	}
+
+}
@@ -0,0 +1,301 @@
+package repository
+
+import "fmt"
+
+// DiffAlgorithm 行の差分を求めるアルゴリズム。空ならmyers
+type DiffAlgorithm string
+
+const (
+	DiffMyers     DiffAlgorithm = "myers"
+	DiffMinimal   DiffAlgorithm = "minimal"
+	DiffPatience  DiffAlgorithm = "patience"
+	DiffHistogram DiffAlgorithm = "histogram"
+)
+
+// ParseDiffAlgorithm --diff-algorithmとdiff.algorithmの値。defaultはmyers
+func ParseDiffAlgorithm(s string) (DiffAlgorithm, error) {
+
+	switch s {
+	case "default", string(DiffMyers):
+		return DiffMyers, nil
+	case string(DiffMinimal), string(DiffPatience), string(DiffHistogram):
+		return DiffAlgorithm(s), nil
+	}
+
+	return "", fmt.Errorf("diff algorithm accepts \"myers\", \"minimal\", \"patience\" and \"histogram\", but got \"%s\"", s)
+}
+
+// differ aをbにする編集を求める
+type differ interface {
+	diff() edits
+}
+
+func newDiffer(algorithm DiffAlgorithm, a, b []*line) differ {
+
+	switch algorithm {
+	case DiffMinimal:
+		return &myers{a: a, b: b, minimal: true}
+	case DiffPatience:
+		return &patience{a, b}
+	case DiffHistogram:
+		return &histogram{a, b}
+	default:
+		return newMyers(a, b)
+	}
+}
+
+// replace aをすべて削除してbをすべて追加する
+func replace(a, b []*line) edits {
+
+	es := edits{}
+	for _, l := range a {
+		es = append(es, newEdit(Deletion, l, nil))
+	}
+	for _, l := range b {
+		es = append(es, newEdit(Insertion, nil, l))
+	}
+
+	return es
+}
+
+// patience 両方に一度ずつしか出てこない行を、順番が変わらない範囲で最も多く対応させて、その間を再帰的に比べる。
+// そのような行がなければmyersで比べる
+type patience struct {
+	a, b []*line
+}
+
+func (p *patience) diff() edits {
+	return patienceDiff(p.a, p.b)
+}
+
+func patienceDiff(a, b []*line) edits {
+
+	if len(a) == 0 || len(b) == 0 {
+		return replace(a, b)
+	}
+
+	anchors := uniqueAnchors(a, b)
+	if len(anchors) == 0 {
+		return newMyers(a, b).diff()
+	}
+
+	es := edits{}
+	ai, bi := 0, 0
+	for i := 0; i <= len(anchors); i++ {
+
+		// 対応させた行の前に同じ行が続いていれば広げる。最後はファイルの終わりまで
+		nextA, nextB := len(a), len(b)
+		if i < len(anchors) {
+
+			nextA, nextB = anchors[i].x, anchors[i].y
+			for nextA > ai && nextB > bi && a[nextA-1].text == b[nextB-1].text {
+				nextA, nextB = nextA-1, nextB-1
+			}
+		}
+
+		for ai < nextA && bi < nextB && a[ai].text == b[bi].text {
+			es = append(es, newEdit(Nochange, a[ai], b[bi]))
+			ai, bi = ai+1, bi+1
+		}
+
+		if ai < nextA || bi < nextB {
+			es = append(es, patienceDiff(a[ai:nextA], b[bi:nextB])...)
+		}
+
+		if i == len(anchors) {
+			break
+		}
+
+		for ; nextA <= anchors[i].x; nextA, nextB = nextA+1, nextB+1 {
+			es = append(es, newEdit(Nochange, a[nextA], b[nextB]))
+		}
+		ai, bi = nextA, nextB
+	}
+
+	return es
+}
+
+// uniqueAnchors aとbに一度ずつ出てくる行の組のうち、aとbの順番がどちらも増えていく最長の並び
+func uniqueAnchors(a, b []*line) []vector2 {
+
+	type occurrence struct {
+		a, b   int
+		ai, bi int
+	}
+
+	occurrences := map[string]*occurrence{}
+	for i, l := range a {
+
+		o, ok := occurrences[l.text]
+		if !ok {
+			o = &occurrence{}
+			occurrences[l.text] = o
+		}
+		o.a, o.ai = o.a+1, i
+	}
+
+	for i, l := range b {
+
+		if o, ok := occurrences[l.text]; ok {
+			o.b, o.bi = o.b+1, i
+		}
+	}
+
+	// aの順に並べて、bの位置の最長増加部分列を求める
+	pairs := []vector2{}
+	for i, l := range a {
+
+		if o := occurrences[l.text]; o.a == 1 && o.b == 1 {
+			pairs = append(pairs, vector2{i, o.bi})
+		}
+	}
+
+	// tops 長さごとの増加部分列の最後の要素。prevはひとつ前の要素
+	tops := []int{}
+	prev := make([]int, len(pairs))
+	for i, p := range pairs {
+
+		lo, hi := 0, len(tops)
+		for lo < hi {
+
+			mid := (lo + hi) / 2
+			if pairs[tops[mid]].y < p.y {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tops[lo-1]
+		}
+
+		if lo == len(tops) {
+			tops = append(tops, i)
+		} else {
+			tops[lo] = i
+		}
+	}
+
+	if len(tops) == 0 {
+		return nil
+	}
+
+	anchors := make([]vector2, len(tops))
+	for i, j := len(tops)-1, tops[len(tops)-1]; i >= 0; i, j = i-1, prev[j] {
+		anchors[i] = pairs[j]
+	}
+
+	return anchors
+}
+
+// maxChainLength histogramでこれより多く出てくる行は対応させる候補にしない
+const maxChainLength = 64
+
+// histogram aで出てくる回数が少ない行から一致する範囲を広げて、最も出てくる回数が少なく長い範囲で分けて再帰的に比べる。
+// 候補になる行が多すぎればmyersで比べる
+type histogram struct {
+	a, b []*line
+}
+
+func (h *histogram) diff() edits {
+	return histogramDiff(h.a, h.b)
+}
+
+func histogramDiff(a, b []*line) edits {
+
+	if len(a) == 0 || len(b) == 0 {
+		return replace(a, b)
+	}
+
+	lcs, found, common := longestCommon(a, b)
+	if !found {
+
+		if common {
+			return newMyers(a, b).diff()
+		}
+
+		return replace(a, b)
+	}
+
+	es := histogramDiff(a[:lcs.aStart], b[:lcs.bStart])
+	for i := 0; i < lcs.aEnd-lcs.aStart; i++ {
+		es = append(es, newEdit(Nochange, a[lcs.aStart+i], b[lcs.bStart+i]))
+	}
+
+	return append(es, histogramDiff(a[lcs.aEnd:], b[lcs.bEnd:])...)
+}
+
+type region struct {
+	aStart, aEnd, bStart, bEnd int
+}
+
+// longestCommon aの中での出現回数が一番少ない行を含む、一致する範囲のうち一番長いもの。
+// foundがfalseでcommonなら、共通の行はあるが出現回数が多すぎる
+func longestCommon(a, b []*line) (lcs region, found, common bool) {
+
+	positions := map[string][]int{}
+	for i, l := range a {
+		positions[l.text] = append(positions[l.text], i)
+	}
+
+	count := func(l *line) int {
+		return len(positions[l.text])
+	}
+
+	lowest := maxChainLength + 1
+	for bi := 0; bi < len(b); {
+
+		next := bi + 1
+
+		occurrences, ok := positions[b[bi].text]
+		if !ok {
+			bi = next
+			continue
+		}
+
+		common = true
+		if len(occurrences) > lowest {
+			bi = next
+			continue
+		}
+
+		for i := 0; i < len(occurrences); i++ {
+
+			as, bs := occurrences[i], bi
+			ae, be := as+1, bs+1
+			rc := len(occurrences)
+
+			for as > 0 && bs > 0 && a[as-1].text == b[bs-1].text {
+				as, bs = as-1, bs-1
+				rc = min(rc, count(a[as]))
+			}
+
+			for ae < len(a) && be < len(b) && a[ae].text == b[be].text {
+				rc = min(rc, count(a[ae]))
+				ae, be = ae+1, be+1
+			}
+
+			next = max(next, be)
+
+			if !found || lcs.aEnd-lcs.aStart < ae-as || rc < lowest {
+				lcs, lowest, found = region{as, ae, bs, be}, rc, true
+			}
+
+			// この範囲の中にある同じ行は飛ばす
+			for i+1 < len(occurrences) && occurrences[i+1] < ae {
+				i++
+			}
+		}
+
+		bi = next
+	}
+
+	// gitと同じく、一番少ない出現回数が上限を超えていれば対応させない
+	if lowest > maxChainLength {
+		found = false
+	}
+
+	return lcs, found, common
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// PatchFailedError ハンクを当てる場所が見つからなかった
+type PatchFailedError struct {
+	Path string
+	// Line ハンクの変更前の開始行
+	Line int
+	// Preimage 探した行
+	Preimage string
+}
+
+func (e *PatchFailedError) Error() string {
+	return fmt.Sprintf("patch failed: %s:%d", e.Path, e.Line)
+}
+
+// HunkPlacement ハンクを当てた位置
+type HunkPlacement struct {
+	// Hunk 1から数えたハンクの番号
+	Hunk int
+	// At 当てた行。1から数える
+	At int
+	// Offset ハンクのヘッダーの位置からのずれ
+	Offset int
+	// Leading、Trailing 減らした後の前後の変更のない行数。減らしていなければReducedはfalse
+	Leading, Trailing int
+	Reduced           bool
+}
+
+// Moved ヘッダーと違う位置に当てたか
+func (p *HunkPlacement) Moved() bool {
+	return p.Offset != 0
+}
+
+type applyOptions struct {
+	// minContext 当てる場所が見つからなければ、前後の変更のない行をこの数まで減らして探す。負なら減らさない
+	minContext int
+}
+
+type ApplyOption func(*applyOptions)
+
+// ApplyMinContext -C。前後の変更のない行をn行まで減らしてもよい
+func ApplyMinContext(n int) ApplyOption {
+	return func(o *applyOptions) {
+		o.minContext = n
+	}
+}
+
+// Apply dataにハンクを順に当てる。gitと同じく、ヘッダーの位置から前後に交互に探して、最初に一致した場所に当てる
+func (patch *Patch) Apply(data []byte, options ...ApplyOption) ([]byte, []*HunkPlacement, error) {
+
+	opts := &applyOptions{minContext: -1}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	if patch.binary != nil {
+		result, err := patch.applyBinary(data)
+		return result, nil, err
+	}
+
+	image := splitLines(data)
+	placements := []*HunkPlacement{}
+
+	for n, h := range patch.hunks {
+
+		pre, post := []string{}, []string{}
+		for _, l := range h.lines {
+
+			if l.diff != Insertion {
+				pre = append(pre, l.text)
+			}
+			if l.diff != Deletion {
+				post = append(post, l.text)
+			}
+		}
+
+		pos := max(h.newStart-1, 0)
+		leading, trailing := h.leading, h.trailing
+
+		// 先頭の変更のハンクは先頭に、後ろに変更のない行がないハンクは末尾に当てる
+		matchBeginning := h.oldStart <= 1
+		matchEnd := trailing == 0
+
+		at := -1
+		for {
+
+			if at = findPosition(image, pre, pos, matchBeginning, matchEnd); at >= 0 {
+				break
+			}
+
+			if opts.minContext < 0 || (leading <= opts.minContext && trailing <= opts.minContext) {
+				break
+			}
+
+			if matchBeginning || matchEnd {
+				matchBeginning, matchEnd = false, false
+				continue
+			}
+
+			// gitと同じく、多いほうの変更のない行を減らす。同じなら両方を減らす
+			if leading >= trailing {
+				pre, post = pre[1:], post[1:]
+				pos--
+				leading--
+			}
+			if trailing > leading {
+				pre, post = pre[:len(pre)-1], post[:len(post)-1]
+				trailing--
+			}
+		}
+
+		if at < 0 {
+			return nil, nil, &PatchFailedError{Path: patch.OldPath, Line: h.oldStart, Preimage: strings.Join(pre, "")}
+		}
+
+		image = append(image[:at], append(append([]string{}, post...), image[at+len(pre):]...)...)
+
+		placements = append(placements, &HunkPlacement{
+			Hunk:     n + 1,
+			At:       at + 1,
+			Offset:   at - pos,
+			Leading:  leading,
+			Trailing: trailing,
+			Reduced:  leading != h.leading || trailing != h.trailing,
+		})
+	}
+
+	return []byte(strings.Join(image, "")), placements, nil
+}
+
+// findPosition imageでpreと一致する位置。posから後ろ、前の順に交互に探す
+func findPosition(image, pre []string, pos int, matchBeginning, matchEnd bool) int {
+
+	if len(pre) > len(image) {
+		return -1
+	}
+
+	switch {
+	case matchBeginning:
+		pos = 0
+	case matchEnd:
+		pos = len(image) - len(pre)
+	}
+	pos = min(max(pos, 0), len(image))
+
+	matches := func(at int) bool {
+
+		if matchBeginning && at != 0 {
+			return false
+		}
+		if matchEnd && at+len(pre) != len(image) {
+			return false
+		}
+		if at+len(pre) > len(image) {
+			return false
+		}
+
+		for i, l := range pre {
+			if image[at+i] != l {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	backward, forward := pos, pos
+	for i := 0; ; i++ {
+
+		if matches(pos) {
+			return pos
+		}
+
+		for {
+
+			if backward == 0 && forward == len(image) {
+				return -1
+			}
+
+			if i%2 == 1 {
+
+				if backward == 0 {
+					i++
+					continue
+				}
+				backward--
+				pos = backward
+			} else {
+
+				if forward == len(image) {
+					i++
+					continue
+				}
+				forward++
+				pos = forward
+			}
+
+			break
+		}
+	}
+}
+
+// applyBinary GIT binary patchを当てる。deltaは今の内容からの差分
+func (patch *Patch) applyBinary(data []byte) ([]byte, error) {
+
+	hunk := patch.binary.forward
+	if hunk == nil {
+		return nil, fmt.Errorf("cannot apply binary patch to '%s' without full index line", patch.path())
+	}
+
+	if oid := patch.OldOID; len(oid) == len(nullOID) && oid != nullOID {
+
+		if blob, err := object.NewBlob(patch.OldPath, data); err != nil {
+			return nil, err
+		} else if blob.OID() != oid {
+			return nil, fmt.Errorf("the patch applies to '%s' (%s), which does not match the current contents.", patch.OldPath, oid)
+		}
+	}
+
+	result := hunk.data
+	if hunk.delta {
+
+		var err error
+		if result, err = applyDelta(data, hunk.data); err != nil {
+			return nil, fmt.Errorf("binary patch does not apply to '%s'", patch.path())
+		}
+	}
+
+	if oid := patch.NewOID; len(oid) == len(nullOID) && oid != nullOID {
+
+		if blob, err := object.NewBlob(patch.NewPath, result); err != nil {
+			return nil, err
+		} else if blob.OID() != oid {
+			return nil, fmt.Errorf("binary patch to '%s' creates incorrect result (expecting %s, got %s)", patch.path(), oid, blob.OID())
+		}
+	}
+
+	return bytes.Clone(result), nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mizuho-u/got/internal"
+)
+
+// AttributeState パスに設定された属性の状態
+type AttributeState int
+
+const (
+	AttributeUnspecified AttributeState = iota
+	// AttributeSet attrのように名前だけ
+	AttributeSet
+	// AttributeUnset -attr
+	AttributeUnset
+	// AttributeValue attr=value
+	AttributeValue
+)
+
+type Attributes interface {
+	AddRules(dir string, r io.Reader) error
+	AddInfoRules(r io.Reader) error
+	Get(path, name string) (AttributeState, string)
+}
+
+type attribute struct {
+	name  string
+	state AttributeState
+	value string
+}
+
+type attributeRule struct {
+	base     string
+	anchored bool
+	re       *regexp.Regexp
+	attrs    []attribute
+}
+
+func (r *attributeRule) match(p string) bool {
+
+	if r.base != "" {
+		if !strings.HasPrefix(p, r.base+"/") {
+			return false
+		}
+		p = strings.TrimPrefix(p, r.base+"/")
+	}
+
+	if !r.anchored {
+		p = path.Base(p)
+	}
+
+	return r.re.MatchString(p)
+}
+
+// macros 組み込みのマクロ。binaryは差分もマージもしないテキストでないファイル
+var macros = map[string][]attribute{
+	"binary": {{"diff", AttributeUnset, ""}, {"merge", AttributeUnset, ""}, {"text", AttributeUnset, ""}},
+}
+
+type attributes struct {
+	dirs map[string][]*attributeRule
+	info []*attributeRule
+}
+
+func NewAttributes() *attributes {
+	return &attributes{dirs: map[string][]*attributeRule{}}
+}
+
+// AddRules dirにある.gitattributesのルールを設定する
+func (a *attributes) AddRules(dir string, r io.Reader) error {
+
+	if dir == "." {
+		dir = ""
+	}
+
+	rules, err := parseAttributeRules(dir, r)
+	if err != nil {
+		return err
+	}
+
+	a.dirs[dir] = rules
+
+	return nil
+}
+
+// AddInfoRules info/attributesのルールを設定する。どの.gitattributesよりも優先される
+func (a *attributes) AddInfoRules(r io.Reader) error {
+
+	rules, err := parseAttributeRules("", r)
+	if err != nil {
+		return err
+	}
+
+	a.info = rules
+
+	return nil
+}
+
+// Get pathのnameの属性。後の行ほど、深いディレクトリの.gitattributesほど優先される
+func (a *attributes) Get(p, name string) (AttributeState, string) {
+
+	if state, value, ok := lastAttribute(a.info, p, name); ok {
+		return state, value
+	}
+
+	dirs := append([]string{""}, internal.ParentDirs(p)...)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if state, value, ok := lastAttribute(a.dirs[dirs[i]], p, name); ok {
+			return state, value
+		}
+	}
+
+	return AttributeUnspecified, ""
+}
+
+func lastAttribute(rules []*attributeRule, p, name string) (AttributeState, string, bool) {
+
+	for i := len(rules) - 1; i >= 0; i-- {
+
+		if !rules[i].match(p) {
+			continue
+		}
+
+		attrs := rules[i].attrs
+		for j := len(attrs) - 1; j >= 0; j-- {
+
+			if attrs[j].name != name {
+				continue
+			}
+
+			// !attrはそれより前の設定を取り消す
+			if attrs[j].state == AttributeUnspecified {
+				return AttributeUnspecified, "", true
+			}
+
+			return attrs[j].state, attrs[j].value, true
+		}
+	}
+
+	return AttributeUnspecified, "", false
+}
+
+func parseAttributeRules(base string, r io.Reader) ([]*attributeRule, error) {
+
+	rules := []*attributeRule{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+
+		rule, ok := parseAttributeRule(strings.TrimSuffix(scanner.Text(), "\r"))
+		if !ok {
+			continue
+		}
+
+		rule.base = base
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// parseAttributeRule "pattern attr -attr !attr attr=value" の形式。マクロは展開する
+func parseAttributeRule(l string) (*attributeRule, bool) {
+
+	fields := strings.Fields(l)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil, false
+	}
+
+	p := fields[0]
+	rule := &attributeRule{}
+
+	// ディレクトリだけのパターンはファイルにマッチしない
+	if strings.HasSuffix(p, "/") {
+		return nil, false
+	}
+
+	if strings.Contains(p, "/") {
+		rule.anchored = true
+		p = strings.TrimPrefix(p, "/")
+	}
+
+	re, err := internal.CompileWildmatch(p, true, false)
+	if err != nil {
+		return nil, false
+	}
+	rule.re = re
+
+	for _, f := range fields[1:] {
+
+		attr := attribute{name: f, state: AttributeSet}
+		switch {
+		case strings.HasPrefix(f, "-"):
+			attr = attribute{name: f[1:], state: AttributeUnset}
+		case strings.HasPrefix(f, "!"):
+			attr = attribute{name: f[1:], state: AttributeUnspecified}
+		case strings.Contains(f, "="):
+			name, value, _ := strings.Cut(f, "=")
+			attr = attribute{name: name, state: AttributeValue, value: value}
+		}
+
+		if expanded, ok := macros[attr.name]; ok && attr.state == AttributeSet {
+			rule.attrs = append(rule.attrs, attr)
+			rule.attrs = append(rule.attrs, expanded...)
+			continue
+		}
+
+		rule.attrs = append(rule.attrs, attr)
+	}
+
+	return rule, true
+}
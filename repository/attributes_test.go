@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributesGet(t *testing.T) {
+
+	testt := []struct {
+		description string
+		rules       map[string]string
+		info        string
+		path        string
+		state       AttributeState
+		value       string
+	}{
+		{
+			description: "basename pattern matches at any level",
+			rules:       map[string]string{"": "*.go diff=golang\n"},
+			path:        "cmd/main.go",
+			state:       AttributeValue,
+			value:       "golang",
+		},
+		{
+			description: "later lines win",
+			rules:       map[string]string{"": "*.go diff=golang\nmain.go -diff\n"},
+			path:        "main.go",
+			state:       AttributeUnset,
+		},
+		{
+			description: "deeper .gitattributes wins",
+			rules:       map[string]string{"": "*.c diff=cpp\n", "lib": "*.c diff\n"},
+			path:        "lib/a.c",
+			state:       AttributeSet,
+		},
+		{
+			description: "anchored pattern only matches relative to the .gitattributes",
+			rules:       map[string]string{"": "/a.py diff=python\n"},
+			path:        "lib/a.py",
+			state:       AttributeUnspecified,
+		},
+		{
+			description: "unspecify resets earlier rules",
+			rules:       map[string]string{"": "*.py diff=python\n", "lib": "*.py !diff\n"},
+			path:        "lib/a.py",
+			state:       AttributeUnspecified,
+		},
+		{
+			description: "binary macro unsets diff",
+			rules:       map[string]string{"": "*.png binary\n"},
+			path:        "a.png",
+			state:       AttributeUnset,
+		},
+		{
+			description: "info/attributes wins over .gitattributes",
+			rules:       map[string]string{"": "*.go diff=golang\n"},
+			info:        "*.go diff=mine\n",
+			path:        "main.go",
+			state:       AttributeValue,
+			value:       "mine",
+		},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			attrs := NewAttributes()
+			for dir, rules := range tc.rules {
+				if err := attrs.AddRules(dir, strings.NewReader(rules)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := attrs.AddInfoRules(strings.NewReader(tc.info)); err != nil {
+				t.Fatal(err)
+			}
+
+			state, value := attrs.Get(tc.path, "diff")
+			if state != tc.state || value != tc.value {
+				t.Errorf("expect %d %s, got %d %s", tc.state, tc.value, state, value)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// firstFewBytes バイナリか調べる先頭のバイト数。gitと同じ
+const firstFewBytes = 8000
+
+// isBinary gitと同じく先頭にNULがあればバイナリとみなす
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), firstFewBytes)], 0) != -1
+}
+
+// binaryPatch GIT binary patch。aからbと、bからaに戻す内容をそれぞれliteralかdeltaで書く
+func binaryPatch(a, b []byte) string {
+	return "GIT binary patch\n" + binaryHunk(a, b) + binaryHunk(b, a)
+}
+
+// binaryHunk srcをdstにする内容。gitと同じく圧縮したdeltaが圧縮したdstより小さければdeltaにする
+func binaryHunk(src, dst []byte) string {
+
+	header, data := fmt.Sprintf("literal %d\n", len(dst)), deflate(dst)
+
+	if len(src) > 0 && len(dst) > 0 {
+
+		delta := createDelta(src, dst)
+		if deflated := deflate(delta); len(deflated) < len(data) {
+			header, data = fmt.Sprintf("delta %d\n", len(delta)), deflated
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+
+	// 1行に52バイトまで。先頭の文字は行のバイト数で、1から26はA-Z、27から52はa-z
+	for len(data) > 0 {
+
+		n := min(len(data), 52)
+		if n <= 26 {
+			b.WriteByte(byte('A' + n - 1))
+		} else {
+			b.WriteByte(byte('a' + n - 27))
+		}
+
+		b.WriteString(encode85(data[:n]))
+		b.WriteByte('\n')
+		data = data[n:]
+	}
+
+	b.WriteByte('\n')
+
+	return b.String()
+}
+
+// deflate gitと同じく速さを優先して圧縮する
+func deflate(data []byte) []byte {
+
+	var b bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&b, zlib.BestSpeed)
+	w.Write(data)
+	w.Close()
+
+	return b.Bytes()
+}
+
+const base85 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+// encode85 4バイトずつ5文字にする。足りない分は0で埋める
+func encode85(data []byte) string {
+
+	var b strings.Builder
+	for len(data) > 0 {
+
+		var acc uint32
+		for i := 0; i < 4; i++ {
+
+			acc <<= 8
+			if i < len(data) {
+				acc |= uint32(data[i])
+			}
+		}
+
+		var chunk [5]byte
+		for i := 4; i >= 0; i-- {
+			chunk[i] = base85[acc%85]
+			acc /= 85
+		}
+		b.Write(chunk[:])
+
+		data = data[min(len(data), 4):]
+	}
+
+	return b.String()
+}
+
+const (
+	// deltaWindow この長さの一致からコピーにする
+	deltaWindow = 16
+	// maxCopySize ひとつのコピーの長さの上限。gitと同じ
+	maxCopySize = 0x10000
+	// maxInsertSize ひとつの追加の長さの上限
+	maxInsertSize = 0x7f
+)
+
+// createDelta gitのパックと同じ形式のdelta。srcとdstのサイズの後に、srcからのコピーか追加するバイトを並べる
+func createDelta(src, dst []byte) []byte {
+
+	delta := appendSize(appendSize(nil, len(src)), len(dst))
+
+	// srcをdeltaWindowごとに区切って位置を覚える
+	index := map[string]int{}
+	for i := 0; i+deltaWindow <= len(src); i += deltaWindow {
+		if _, ok := index[string(src[i:i+deltaWindow])]; !ok {
+			index[string(src[i:i+deltaWindow])] = i
+		}
+	}
+
+	insert := []byte{}
+	flush := func() {
+
+		for len(insert) > 0 {
+
+			n := min(len(insert), maxInsertSize)
+			delta = append(delta, byte(n))
+			delta = append(delta, insert[:n]...)
+			insert = insert[n:]
+		}
+	}
+
+	for i := 0; i < len(dst); {
+
+		offset, ok := -1, false
+		if i+deltaWindow <= len(dst) {
+			offset, ok = index[string(dst[i:i+deltaWindow])]
+		}
+
+		if !ok {
+			insert = append(insert, dst[i])
+			i++
+			continue
+		}
+
+		size := deltaWindow
+		for offset+size < len(src) && i+size < len(dst) && src[offset+size] == dst[i+size] {
+			size++
+		}
+
+		flush()
+		for size > 0 {
+
+			n := min(size, maxCopySize)
+			delta = appendCopy(delta, offset, n)
+			offset, i, size = offset+n, i+n, size-n
+		}
+	}
+
+	flush()
+
+	return delta
+}
+
+// appendSize 下位から7ビットずつ、続きがあれば最上位ビットを立てる
+func appendSize(b []byte, n int) []byte {
+
+	for n >= 0x80 {
+		b = append(b, byte(n)|0x80)
+		n >>= 7
+	}
+
+	return append(b, byte(n))
+}
+
+// appendCopy 先頭のバイトのビットで、続くオフセット4バイトとサイズ3バイトのうち0でないバイトを示す
+func appendCopy(b []byte, offset, size int) []byte {
+
+	// サイズ0は0x10000を表す
+	if size == maxCopySize {
+		size = 0
+	}
+
+	op := byte(0x80)
+	args := []byte{}
+	for i := 0; i < 4; i++ {
+		if v := byte(offset >> (8 * i)); v != 0 {
+			op |= 1 << i
+			args = append(args, v)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if v := byte(size >> (8 * i)); v != 0 {
+			op |= 1 << (4 + i)
+			args = append(args, v)
+		}
+	}
+
+	return append(append(b, op), args...)
+}
+
+// decodeBinaryLine GIT binary patchの1行。先頭の文字のバイト数だけ85進数から戻す
+func decodeBinaryLine(line string) ([]byte, error) {
+
+	if len(line) < 6 || (len(line)-1)%5 != 0 {
+		return nil, fmt.Errorf("invalid binary patch line")
+	}
+
+	var n int
+	switch c := line[0]; {
+	case 'A' <= c && c <= 'Z':
+		n = int(c-'A') + 1
+	case 'a' <= c && c <= 'z':
+		n = int(c-'a') + 27
+	default:
+		return nil, fmt.Errorf("invalid binary patch line")
+	}
+
+	data, err := decode85(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	if n > len(data) {
+		return nil, fmt.Errorf("invalid binary patch line")
+	}
+
+	return data[:n], nil
+}
+
+// decode85 5文字ずつ4バイトに戻す
+func decode85(s string) ([]byte, error) {
+
+	data := []byte{}
+	for ; len(s) >= 5; s = s[5:] {
+
+		var acc uint64
+		for i := 0; i < 5; i++ {
+
+			v := strings.IndexByte(base85, s[i])
+			if v == -1 {
+				return nil, fmt.Errorf("invalid base85 character %q", s[i])
+			}
+			acc = acc*85 + uint64(v)
+		}
+
+		if acc > 0xffffffff {
+			return nil, fmt.Errorf("invalid base85 sequence")
+		}
+
+		data = append(data, byte(acc>>24), byte(acc>>16), byte(acc>>8), byte(acc))
+	}
+
+	return data, nil
+}
+
+// inflate zlibで戻して、sizeバイトになることを確かめる
+func inflate(data []byte, size int) ([]byte, error) {
+
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	inflated, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inflated) != size {
+		return nil, fmt.Errorf("inflated size %d does not match %d", len(inflated), size)
+	}
+
+	return inflated, nil
+}
+
+// applyDelta createDeltaと同じ形式のdeltaをsrcに当てる
+func applyDelta(src, delta []byte) ([]byte, error) {
+
+	errCorrupt := fmt.Errorf("corrupt binary delta")
+
+	readSize := func() (int, bool) {
+
+		n, shift := 0, 0
+		for len(delta) > 0 {
+
+			c := delta[0]
+			delta = delta[1:]
+			n |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return n, true
+			}
+		}
+
+		return 0, false
+	}
+
+	srcSize, ok := readSize()
+	if !ok || srcSize != len(src) {
+		return nil, errCorrupt
+	}
+
+	dstSize, ok := readSize()
+	if !ok {
+		return nil, errCorrupt
+	}
+
+	dst := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+
+			n := int(op)
+			if n == 0 || n > len(delta) {
+				return nil, errCorrupt
+			}
+			dst = append(dst, delta[:n]...)
+			delta = delta[n:]
+			continue
+		}
+
+		offset, size := 0, 0
+		for i := 0; i < 7; i++ {
+
+			if op&(1<<i) == 0 {
+				continue
+			}
+
+			if len(delta) == 0 {
+				return nil, errCorrupt
+			}
+
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				size |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+
+		if size == 0 {
+			size = maxCopySize
+		}
+
+		if offset+size > len(src) {
+			return nil, errCorrupt
+		}
+		dst = append(dst, src[offset:offset+size]...)
+	}
+
+	if len(dst) != dstSize {
+		return nil, errCorrupt
+	}
+
+	return dst, nil
+}
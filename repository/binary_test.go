@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIsBinary(t *testing.T) {
+
+	testt := []struct {
+		data   []byte
+		binary bool
+	}{
+		{[]byte("text\n"), false},
+		{[]byte("a\x00b"), true},
+		{append(bytes.Repeat([]byte("a"), firstFewBytes), 0), false},
+		{[]byte{}, false},
+	}
+
+	for i, tc := range testt {
+		if isBinary(tc.data) != tc.binary {
+			t.Errorf("%d: expect %v", i, tc.binary)
+		}
+	}
+
+}
+
+func TestEncode85(t *testing.T) {
+
+	// gitがGIT binary patchに出す、2バイトのNULをzlibで圧縮したもの
+	data := []byte{0x78, 0x01, 0x63, 0x60, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01}
+
+	if got := encode85(data); got != "cmZQz0000200961" {
+		t.Errorf("expect cmZQz0000200961, got %s", got)
+	}
+
+}
+
+func TestCreateDelta(t *testing.T) {
+
+	src := []byte("abcdefghijklmnopqrstuvwxyz0123456789")
+	dst := []byte("XYabcdefghijklmnopqrstuvwxyz")
+
+	expect := []byte{
+		36, 28,
+		// XYを追加
+		2, 'X', 'Y',
+		// オフセット0から26バイトをコピー
+		0x90, 26,
+	}
+
+	if got := createDelta(src, dst); !bytes.Equal(got, expect) {
+		t.Errorf("expect % x, got % x", expect, got)
+	}
+
+}
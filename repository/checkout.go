@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mizuho-u/got/repository/internal"
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// CheckoutFiles entriesの内容でワークスペースのファイルを上書きし、インデックスを更新する。
+// ブランチの切り替えと違い、ローカルの変更は確認せずに捨てる
+func CheckoutFiles(ws Workspace, ol ObjectLoader, index IndexWriter, entries map[string]object.TreeEntry) error {
+
+	names := internal.Keys(entries)
+	sort.Strings(names)
+
+	for _, name := range names {
+
+		entry := entries[name]
+
+		o, err := ol.Load(entry.OID())
+		if err != nil {
+			return err
+		}
+
+		if stat, err := ws.Stat(name); err == nil {
+
+			if stat.IsDir() {
+				return fmt.Errorf("cannot checkout %s: a directory is in the way", name)
+			}
+
+			if err := ws.RemoveFile(name); err != nil {
+				return err
+			}
+		}
+
+		f, err := ws.Open(name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.Write(o.Data()); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := f.Chmod(entry.Permission()); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		stat, err := ws.Stat(name)
+		if err != nil {
+			return err
+		}
+
+		index.Add(NewIndexEntry(name, entry.OID(), stat.Stats()))
+	}
+
+	return nil
+}
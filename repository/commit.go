@@ -1,22 +1,30 @@
 package repository
 
 import (
-	"time"
-
 	"github.com/mizuho-u/got/repository/object"
 )
 
-func (repo *repository) Commit(parent, author, email, message string, now time.Time) (commitId string, objects []object.Object, err error) {
+// WriteTree インデックスからコミットするツリーを作る
+func (repo *repository) WriteTree() (treeId string, objects []object.Object, err error) {
 
 	entries := []object.TreeEntry{}
 
 	for _, entry := range repo.index.entries {
-		entries = append(entries, object.NewTreeEntry(entry.filename, entry.permission(), entry.oid))
+		if repo.matches(entry.filename) {
+			entries = append(entries, object.NewTreeEntry(entry.filename, entry.permission(), entry.oid))
+		}
+	}
+
+	// pathspecを指定した場合、マッチしないファイルはHEADのまま
+	for name, entry := range repo.head {
+		if !repo.matches(name) {
+			entries = append(entries, object.NewTreeEntry(name, entry.Permission(), entry.OID()))
+		}
 	}
 
 	root, err := object.BuildTree(entries)
 	if err != nil {
-		return commitId, objects, err
+		return treeId, objects, err
 	}
 
 	root.Walk(func(tree object.Object) error {
@@ -26,11 +34,46 @@ func (repo *repository) Commit(parent, author, email, message string, now time.T
 
 	})
 
-	a := object.NewAuthor(author, email, now)
-	commit, err := object.NewCommit(parent, root.OID(), a, message)
+	return root.OID(), objects, nil
+}
+
+type commitOptions struct {
+	sign func(payload []byte) (string, error)
+}
+
+type CommitOption func(*commitOptions)
+
+// WithSigner コミットに署名してgpgsigヘッダに入れる
+func WithSigner(sign func(payload []byte) (string, error)) CommitOption {
+
+	return func(o *commitOptions) {
+		o.sign = sign
+	}
+
+}
+
+func (repo *repository) Commit(parents []string, author, committer object.Author, message string, options ...CommitOption) (commitId string, objects []object.Object, err error) {
+
+	opts := &commitOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	treeId, objects, err := repo.WriteTree()
 	if err != nil {
 		return commitId, objects, err
 	}
+
+	commit, err := object.NewCommit(parents, treeId, author, committer, message)
+	if err != nil {
+		return commitId, objects, err
+	}
+
+	if opts.sign != nil {
+		if commit, err = object.SignCommit(commit, opts.sign); err != nil {
+			return commitId, objects, err
+		}
+	}
 	objects = append(objects, commit)
 
 	commitId = commit.OID()
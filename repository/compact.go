@@ -0,0 +1,384 @@
+package repository
+
+// diffLines optsのアルゴリズムで求めた編集を、gitと同じく読みやすい位置にずらす。空白を無視するなら無視した行で比べる
+func diffLines(opts *diffOptions, a, b []*line) edits {
+
+	ka, kb := opts.keys(a), opts.keys(b)
+
+	return compact(a, b, ka, kb, newDiffer(opts.algorithm, ka, kb).diff())
+}
+
+// changes 変更された行の印。前後にひとつずつ番兵を置く。keysは比べるための行
+type changes struct {
+	lines   []*line
+	keys    []*line
+	changed []bool
+}
+
+func newChanges(lines, keys []*line) *changes {
+	return &changes{lines, keys, make([]bool, len(lines)+2)}
+}
+
+func (c *changes) at(i int) bool {
+	return c.changed[i+1]
+}
+
+func (c *changes) set(i int, v bool) {
+	c.changed[i+1] = v
+}
+
+// group 続けて変更された行の範囲[start, end)。両方のファイルのグループは順番に対応する
+type group struct {
+	start, end int
+}
+
+func (c *changes) first() *group {
+
+	g := &group{}
+	for c.at(g.end) {
+		g.end++
+	}
+
+	return g
+}
+
+func (c *changes) next(g *group) bool {
+
+	if g.end == len(c.lines) {
+		return false
+	}
+
+	g.start = g.end + 1
+	for g.end = g.start; c.at(g.end); g.end++ {
+	}
+
+	return true
+}
+
+func (c *changes) previous(g *group) bool {
+
+	if g.start == 0 {
+		return false
+	}
+
+	g.end = g.start - 1
+	for g.start = g.end; c.at(g.start - 1); g.start-- {
+	}
+
+	return true
+}
+
+// slideDown グループの最初の行と直後の行が同じなら、グループをひとつ下にずらす
+func (c *changes) slideDown(g *group) bool {
+
+	if g.end >= len(c.lines) || c.keys[g.start].text != c.keys[g.end].text {
+		return false
+	}
+
+	c.set(g.start, false)
+	c.set(g.end, true)
+	g.start, g.end = g.start+1, g.end+1
+	for c.at(g.end) {
+		g.end++
+	}
+
+	return true
+}
+
+// slideUp グループの最後の行と直前の行が同じなら、グループをひとつ上にずらす
+func (c *changes) slideUp(g *group) bool {
+
+	if g.start <= 0 || c.keys[g.start-1].text != c.keys[g.end-1].text {
+		return false
+	}
+
+	c.set(g.start-1, true)
+	c.set(g.end-1, false)
+	g.start, g.end = g.start-1, g.end-1
+	for c.at(g.start - 1) {
+		g.start--
+	}
+
+	return true
+}
+
+// compact 変更のグループをずらせるだけずらしてつなげ、もう片方の変更と並ぶ位置か、インデントから読みやすい位置に置く
+func compact(a, b, ka, kb []*line, es edits) edits {
+
+	ca, cb := newChanges(a, ka), newChanges(b, kb)
+	i, j := 0, 0
+	for _, e := range es {
+
+		switch e.diff {
+		case Deletion:
+			ca.set(i, true)
+			i++
+		case Insertion:
+			cb.set(j, true)
+			j++
+		default:
+			i, j = i+1, j+1
+		}
+	}
+
+	ca.compact(cb)
+	cb.compact(ca)
+
+	compacted := edits{}
+	for i, j = 0, 0; i < len(a) || j < len(b); {
+
+		if i < len(a) && j < len(b) && !ca.at(i) && !cb.at(j) {
+			compacted = append(compacted, newEdit(Nochange, a[i], b[j]))
+			i, j = i+1, j+1
+			continue
+		}
+
+		for ; i < len(a) && ca.at(i); i++ {
+			compacted = append(compacted, newEdit(Deletion, a[i], nil))
+		}
+		for ; j < len(b) && cb.at(j); j++ {
+			compacted = append(compacted, newEdit(Insertion, nil, b[j]))
+		}
+	}
+
+	return compacted
+}
+
+// maxSliding インデントで位置を決めるときにずらす行数の上限
+const maxSliding = 100
+
+func (c *changes) compact(other *changes) {
+
+	g, og := c.first(), other.first()
+
+	for {
+
+		if g.end != g.start {
+
+			var earliestEnd, size int
+			endMatchingOther := -1
+
+			// 上下にずらしてほかの変更とつながれば、もう一度やり直す
+			for {
+
+				size = g.end - g.start
+				endMatchingOther = -1
+
+				for c.slideUp(g) {
+					other.previous(og)
+				}
+
+				earliestEnd = g.end
+				if og.end > og.start {
+					endMatchingOther = g.end
+				}
+
+				for c.slideDown(g) {
+
+					other.next(og)
+					if og.end > og.start {
+						endMatchingOther = g.end
+					}
+				}
+
+				if size == g.end-g.start {
+					break
+				}
+			}
+
+			switch {
+			case g.end == earliestEnd:
+			case endMatchingOther != -1:
+
+				for og.end == og.start {
+					c.slideUp(g)
+					other.previous(og)
+				}
+
+			default:
+
+				shift := max(earliestEnd, g.end-size-1, g.end-maxSliding)
+				best, bestScore := -1, splitScore{}
+				for ; shift <= g.end; shift++ {
+
+					score := splitScore{}
+					score.add(c.measure(shift))
+					score.add(c.measure(shift - size))
+
+					if best == -1 || score.compare(bestScore) <= 0 {
+						best, bestScore = shift, score
+					}
+				}
+
+				for g.end > best {
+					c.slideUp(g)
+					other.previous(og)
+				}
+			}
+		}
+
+		if !c.next(g) {
+			break
+		}
+		other.next(og)
+	}
+}
+
+const (
+	maxIndent = 200
+	maxBlanks = 20
+)
+
+// indent 行頭の空白の幅。タブは8の倍数まで進める。空白だけの行は-1
+func indent(text string) int {
+
+	n := 0
+	for _, c := range text {
+
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 8 - n%8
+		case '\r', '\f', '\v':
+		default:
+			return n
+		}
+
+		if n >= maxIndent {
+			return maxIndent
+		}
+	}
+
+	return -1
+}
+
+// splitMeasurement 変更の境目の前後の空行とインデント
+type splitMeasurement struct {
+	endOfFile  bool
+	indent     int
+	preBlank   int
+	preIndent  int
+	postBlank  int
+	postIndent int
+}
+
+// measure split行目の前で区切ったときの前後の様子
+func (c *changes) measure(split int) splitMeasurement {
+
+	m := splitMeasurement{indent: -1, preIndent: -1, postIndent: -1}
+	if split >= len(c.lines) {
+		m.endOfFile = true
+	} else {
+		m.indent = indent(c.lines[split].text)
+	}
+
+	for i := split - 1; i >= 0; i-- {
+
+		m.preIndent = indent(c.lines[i].text)
+		if m.preIndent != -1 {
+			break
+		}
+
+		m.preBlank++
+		if m.preBlank == maxBlanks {
+			m.preIndent = 0
+			break
+		}
+	}
+
+	for i := split + 1; i < len(c.lines); i++ {
+
+		m.postIndent = indent(c.lines[i].text)
+		if m.postIndent != -1 {
+			break
+		}
+
+		m.postBlank++
+		if m.postBlank == maxBlanks {
+			m.postIndent = 0
+			break
+		}
+	}
+
+	return m
+}
+
+// 境目の読みにくさの重み。gitのindent heuristicと同じ値
+const (
+	startOfFilePenalty              = 1
+	endOfFilePenalty                = 21
+	totalBlankWeight                = -30
+	postBlankWeight                 = 6
+	relativeIndentPenalty           = -4
+	relativeIndentWithBlankPenalty  = 10
+	relativeOutdentPenalty          = 24
+	relativeOutdentWithBlankPenalty = 17
+	relativeDedentPenalty           = 23
+	relativeDedentWithBlankPenalty  = 17
+	indentWeight                    = 60
+)
+
+type splitScore struct {
+	effectiveIndent int
+	penalty         int
+}
+
+func (s *splitScore) add(m splitMeasurement) {
+
+	if m.preIndent == -1 && m.preBlank == 0 {
+		s.penalty += startOfFilePenalty
+	}
+
+	if m.endOfFile {
+		s.penalty += endOfFilePenalty
+	}
+
+	postBlank := 0
+	if m.indent == -1 {
+		postBlank = 1 + m.postBlank
+	}
+	totalBlank := m.preBlank + postBlank
+
+	s.penalty += totalBlankWeight * totalBlank
+	s.penalty += postBlankWeight * postBlank
+
+	indent := m.postIndent
+	if m.indent != -1 {
+		indent = m.indent
+	}
+
+	anyBlanks := totalBlank != 0
+	s.effectiveIndent += indent
+
+	penalty := func(blank, noBlank int) int {
+		if anyBlanks {
+			return blank
+		}
+		return noBlank
+	}
+
+	switch {
+	case indent == -1, m.preIndent == -1, indent == m.preIndent:
+	case indent > m.preIndent:
+		s.penalty += penalty(relativeIndentWithBlankPenalty, relativeIndentPenalty)
+	case m.postIndent != -1 && m.postIndent > indent:
+		s.penalty += penalty(relativeOutdentWithBlankPenalty, relativeOutdentPenalty)
+	default:
+		s.penalty += penalty(relativeDedentWithBlankPenalty, relativeDedentPenalty)
+	}
+}
+
+// compare 小さいほうが読みやすい
+func (s splitScore) compare(other splitScore) int {
+
+	cmp := 0
+	switch {
+	case s.effectiveIndent > other.effectiveIndent:
+		cmp = 1
+	case s.effectiveIndent < other.effectiveIndent:
+		cmp = -1
+	}
+
+	return indentWeight*cmp + s.penalty - other.penalty
+}
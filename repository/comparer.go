@@ -2,8 +2,11 @@ package repository
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"math"
+	"regexp"
 	"sort"
 )
 
@@ -15,6 +18,7 @@ type line struct {
 func lines(r io.Reader) ([]*line, error) {
 
 	scan := bufio.NewScanner(r)
+	scan.Split(scanLines)
 	lines := []*line{}
 
 	for i := 1; scan.Scan(); i++ {
@@ -28,12 +32,28 @@ func lines(r io.Reader) ([]*line, error) {
 	return lines, nil
 }
 
+// scanLines bufio.ScanLinesと違い、gitと同じく行末の\rを行の内容に残す
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
 type myers struct {
 	a, b []*line
+	// minimal 長い入力でも最短の編集を探しきる
+	minimal bool
 }
 
 func newMyers(a, b []*line) *myers {
-	return &myers{a, b}
+	return &myers{a: a, b: b}
 }
 
 type symbol string
@@ -53,18 +73,19 @@ type edit struct {
 	aline *line
 	bline *line
 	line  *line
+	// moved --color-movedで見つけた移動した行
+	moved movedFlag
 }
 
 func newEdit(diff symbol, aline, bline *line) *edit {
 
-	var line *line
-	if aline != nil {
+	// 変更のない行は、空白を無視したときにgitと同じくbの行を出す
+	line := bline
+	if line == nil {
 		line = aline
-	} else {
-		line = bline
 	}
 
-	return &edit{diff, aline, bline, line}
+	return &edit{diff: diff, aline: aline, bline: bline, line: line}
 
 }
 
@@ -104,42 +125,106 @@ func (es edits) String() string {
 const hunkContext int = 3
 
 func (es edits) hunks() []*hunk {
+	return es.split(hunkContext, 0, nil)
+}
 
-	hunks := []*hunk{}
-	offset := 0
+// changeGroup 続けて削除か追加された編集の範囲[start, end)。ignoreなら無視できる変更だけ
+type changeGroup struct {
+	start, end int
+	ignore     bool
+}
+
+func (es edits) groups(ignorable func(*edit) bool) []*changeGroup {
+
+	groups := []*changeGroup{}
+	for i := 0; i < len(es); i++ {
+
+		if es[i].diff == Nochange {
+			continue
+		}
+
+		g := &changeGroup{start: i, ignore: ignorable != nil}
+		for ; i < len(es) && es[i].diff != Nochange; i++ {
+			g.ignore = g.ignore && ignorable(es[i])
+		}
+		g.end = i
+
+		groups = append(groups, g)
+	}
 
+	return groups
+}
+
+// split 変更の前後にcontext行ずつ変更のない行をつけてハンクにする。間の変更のない行が2*context+interHunk行以下ならつなげる。
+// ignorableな変更だけのグループは、gitと同じくほかの変更とcontext行より離れていればハンクにしない
+func (es edits) split(context, interHunk int, ignorable func(*edit) bool) []*hunk {
+
+	maxCommon := 2*context + interHunk
+
+	hunks := []*hunk{}
+	groups := es.groups(ignorable)
 	for {
 
-		for offset < len(es) && es[offset].diff == Nochange {
-			offset++
+		first := 0
+		for i := 0; i < len(groups) && groups[i].ignore; i++ {
+			if i+1 == len(groups) || groups[i+1].start-groups[i].end >= context {
+				first = i + 1
+			}
 		}
 
-		if offset >= len(es) {
+		groups = groups[first:]
+		if len(groups) == 0 {
 			return hunks
 		}
 
-		offset -= hunkContext + 1
+		last := 0
+		for i := 1; i < len(groups); i++ {
 
-		aStart := 0
-		if offset >= 0 {
-			aStart = es[offset].aline.number
-		}
+			distance := groups[i].start - groups[i-1].end
+			if distance > maxCommon {
+				break
+			}
 
-		bStart := 0
-		if offset >= 0 {
-			bStart = es[offset].bline.number
+			if distance < context && (!groups[i].ignore || last == i-1) {
+				last = i
+			} else if distance >= context && last != i-1 && groups[i].start-groups[last].end > maxCommon {
+				break
+			} else if distance >= context && !groups[i].ignore {
+				last = i
+			}
 		}
 
-		hunk := &hunk{aStart, bStart, []*edit{}}
-		offset = hunk.build(es, offset)
-		hunks = append(hunks, hunk)
+		start, end := max(groups[0].start-context, 0), min(groups[last].end+context, len(es))
+		hunks = append(hunks, newHunk(es, start, end))
 
+		groups = groups[last+1:]
 	}
 }
 
+// newHunk es[start:end]のハンク。片方の行がなければ直前の行番号から始める
+func newHunk(es edits, start, end int) *hunk {
+
+	h := &hunk{edits: es[start:end]}
+	for i := start - 1; i >= 0 && (h.aStart == 0 || h.bStart == 0); i-- {
+
+		if es[i].aline != nil && h.aStart == 0 {
+			h.aStart = es[i].aline.number
+		}
+		if es[i].bline != nil && h.bStart == 0 {
+			h.bStart = es[i].bline.number
+		}
+	}
+
+	return h
+}
+
 type hunk struct {
 	aStart, bStart int
 	edits          edits
+	// funcname ハンクより前で一番近い関数の行
+	funcname string
+	// wordRegex 単語で比べるときの単語の正規表現
+	wordRegex *regexp.Regexp
 }
 
 func (h *hunk) String() string {
@@ -162,7 +247,12 @@ func (h *hunk) Header() string {
 	aStart, aSize := h.offset(func(e *edit) bool { return e.aline != nil }, func(e *edit) int { return e.aline.number }, h.aStart)
 	bStart, bSize := h.offset(func(e *edit) bool { return e.bline != nil }, func(e *edit) int { return e.bline.number }, h.bStart)
 
-	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aSize, bStart, bSize)
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aSize, bStart, bSize)
+	if h.funcname != "" {
+		header += " " + h.funcname
+	}
+
+	return header
 }
 
 func (h *hunk) offset(filterFunc func(*edit) bool, startFunc func(*edit) int, defaultStart int) (int, int) {
@@ -177,42 +267,14 @@ func (h *hunk) offset(filterFunc func(*edit) bool, startFunc func(*edit) int, de
 	return start, len(lines)
 }
 
-func (h *hunk) build(edits edits, offset int) int {
-
-	counter := -1
-	for counter != 0 {
-
-		if offset >= 0 && counter > 0 {
-			h.edits = append(h.edits, edits[offset])
-		}
-
-		offset++
-		if offset >= len(edits) {
-			break
-		}
-
-		if offset+hunkContext < len(edits) {
-
-			switch edits[offset+hunkContext].diff {
-			case Insertion, Deletion:
-				counter = 2*hunkContext + 1
-			default:
-				counter -= 1
-			}
-
-		}
-
-	}
-
-	return offset
-}
-
 func (my *myers) diff() edits {
 
 	diff := edits{}
 
+	trace, x, y := my.shortestEdit()
+
 	moves := make(chan *move)
-	go my.backtrack(moves)
+	go my.backtrack(moves, trace, x, y)
 
 	for {
 
@@ -241,6 +303,12 @@ func (my *myers) diff() edits {
 		return i > j
 	})
 
+	// 途中で打ち切ったら残りをあらためて比べる
+	if x < len(my.a) || y < len(my.b) {
+		rest := &myers{a: my.a[x:], b: my.b[y:], minimal: my.minimal}
+		diff = append(diff, rest.diff()...)
+	}
+
 	return diff
 
 }
@@ -280,23 +348,38 @@ func (ns *intarray) index(i int) uint {
 // 3  |  3  2  4  2  5  3  5  0
 // 4  |  3  4  4  5  5  7  5  7
 // 4  |  3  4  5  5  7  7  5  7
-func (my *myers) shortestEdit() []intarray {
+//
+// minimalでなければgitと同じく移動回数をmaxCostで打ち切り、そこまでで一番進んだ位置を返す
+func (my *myers) shortestEdit() (trace []intarray, x, y int) {
 	n, m := len(my.a), len(my.b)
 	max := n + m
 
 	if max == 0 {
-		return []intarray{}
+		return []intarray{}, 0, 0
+	}
+
+	limit := max
+	if !my.minimal {
+		limit = maxCost(n + m)
 	}
 
 	xs := make(intarray, 2*max+1)
 	// (0, 0)の前は(0, -1)とする
 	xs.set(1, 0)
 
-	trace := []intarray{}
+	trace = []intarray{}
 
 	// d -> 移動回数
 	for d := 0; d <= max; d++ {
 
+		if d > limit {
+
+			if x, y, ok := my.furthest(xs, d-1); ok {
+				return trace, x, y
+			}
+			limit = max
+		}
+
 		// min(src, dist)の長さだけしかcopyされないので、
 		// srcの長さだけdistの配列を作って全部コピる
 		t := make(intarray, len(xs))
@@ -328,13 +411,35 @@ func (my *myers) shortestEdit() []intarray {
 
 			// 右下に到達したら終わり
 			if x >= n && y >= m {
-				return trace
+				return trace, n, m
 			}
 
 		}
 	}
 
-	return trace
+	return trace, n, m
+}
+
+// maxCost 最短の編集を探す移動回数の上限。gitと同じく入力の長さの平方根で、最低でも256
+func maxCost(size int) int {
+	return max(int(math.Sqrt(float64(size+3))), 256)
+}
+
+// furthest d回の移動で一番右下まで進んだ位置
+func (my *myers) furthest(xs intarray, d int) (x, y int, ok bool) {
+
+	for k := -d; k <= d; k += 2 {
+
+		kx := xs.get(k)
+		ky := kx - k
+		if kx > len(my.a) || ky < 0 || ky > len(my.b) || kx+ky <= x+y {
+			continue
+		}
+
+		x, y, ok = kx, ky, true
+	}
+
+	return x, y, ok
 }
 
 type vector2 struct {
@@ -359,9 +464,7 @@ type move struct {
 // 3  |  3  2  4  2  X  3  5  0
 // 4  |  3  4  4  5  5  X  5  7
 // 4  |  3  4  5  5  S  7  5  7
-func (my *myers) backtrack(moves chan<- *move) {
-	x, y := len(my.a), len(my.b)
-	trace := my.shortestEdit()
+func (my *myers) backtrack(moves chan<- *move, trace []intarray, x, y int) {
 
 	// d -> 移動回数
 	for d := len(trace) - 1; 0 <= d; d-- {
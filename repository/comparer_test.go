@@ -248,3 +248,142 @@ func TestHunks(t *testing.T) {
 	}
 
 }
+
+func TestDiffAlgorithms(t *testing.T) {
+
+	a := []string{"void func1() {", "    x += 1", "}", "", "void func2() {", "    x += 2", "}"}
+	b := []string{"void func1() {", "    x += 1", "}", "", "void functhreehalves() {", "    x += 1.5", "}", "", "void func2() {", "    x += 2", "}"}
+
+	expect := `+void functhreehalves() {
++    x += 1.5
++}
++
+`
+
+	for _, algorithm := range []DiffAlgorithm{DiffMyers, DiffMinimal, DiffPatience, DiffHistogram} {
+
+		t.Run(string(algorithm), func(t *testing.T) {
+
+			al, _ := lines(bytes.NewBufferString(strings.Join(a, "\n")))
+			bl, _ := lines(bytes.NewBufferString(strings.Join(b, "\n")))
+
+			changed := diffLines(&diffOptions{algorithm: algorithm}, al, bl).filter(func(e *edit) bool { return e.diff != Nochange })
+			if changed.String() != expect {
+				t.Errorf("expect \n%s, got \n%s", expect, changed)
+			}
+		})
+	}
+
+}
+
+func TestPatienceUniqueLines(t *testing.T) {
+
+	// 一度ずつしか出てこない行のうち順番が保たれる最長の並びを対応させる。gitと同じくcだけになる
+	a := []string{"a", "}", "b", "}", "c"}
+	b := []string{"c", "}", "b", "}", "a"}
+
+	al, _ := lines(bytes.NewBufferString(strings.Join(a, "\n")))
+	bl, _ := lines(bytes.NewBufferString(strings.Join(b, "\n")))
+
+	expect := `-a
+-}
+-b
+-}
+ c
++}
++b
++}
++a
+`
+
+	if diff := (&patience{al, bl}).diff(); diff.String() != expect {
+		t.Errorf("expect \n%s, got \n%s", expect, diff)
+	}
+
+}
+
+func TestMyersMaxCost(t *testing.T) {
+
+	// 上限を超えて打ち切っても、すべての行を順に削除か追加か変更なしにする
+	a, b := []*line{}, []*line{}
+	for i := 0; i < 2000; i++ {
+		a = append(a, &line{i + 1, strings.Repeat("a", i%7)})
+		b = append(b, &line{i + 1, strings.Repeat("a", i%5)})
+	}
+
+	diff := newMyers(a, b).diff()
+
+	i, j := 0, 0
+	for _, e := range diff {
+
+		switch e.diff {
+		case Deletion:
+			if e.aline != a[i] {
+				t.Fatalf("expect a line %d", i+1)
+			}
+			i++
+		case Insertion:
+			if e.bline != b[j] {
+				t.Fatalf("expect b line %d", j+1)
+			}
+			j++
+		default:
+			if e.aline != a[i] || e.bline != b[j] || a[i].text != b[j].text {
+				t.Fatalf("expect a line %d and b line %d", i+1, j+1)
+			}
+			i, j = i+1, j+1
+		}
+	}
+
+	if i != len(a) || j != len(b) {
+		t.Errorf("expect all lines, got a %d b %d", i, j)
+	}
+
+}
+
+func TestSplitHunks(t *testing.T) {
+
+	a := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	b := []string{"a", "B", "c", "d", "e", "", "f", "g", "h", "I", "j"}
+	near := []string{"a", "B", "c", "", "d", "e", "f", "g", "h", "i", "j"}
+
+	testt := []struct {
+		description      string
+		b                []string
+		context          int
+		interHunk        int
+		ignoreBlankLines bool
+		expect           []string
+	}{
+		{"context 1 splits", b, 1, 0, false, []string{"@@ -1,3 +1,3 @@", "@@ -5,2 +5,3 @@", "@@ -8,3 +9,3 @@"}},
+		{"inter hunk context joins", b, 1, 1, false, []string{"@@ -1,10 +1,11 @@"}},
+		{"blank lines far from other changes are ignored", b, 1, 0, true, []string{"@@ -1,3 +1,3 @@", "@@ -8,3 +9,3 @@"}},
+		{"blank lines far from other changes are not context", b, 3, 0, true, []string{"@@ -1,5 +1,5 @@", "@@ -6,5 +7,5 @@"}},
+		{"blank lines near other changes are kept", near, 2, 0, true, []string{"@@ -1,5 +1,6 @@"}},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			al, _ := lines(bytes.NewBufferString(strings.Join(a, "\n")))
+			bl, _ := lines(bytes.NewBufferString(strings.Join(tc.b, "\n")))
+
+			o := &diffOptions{algorithm: DiffMyers, ignoreBlankLines: tc.ignoreBlankLines}
+
+			var ignorable func(*edit) bool
+			if tc.ignoreBlankLines {
+				ignorable = func(e *edit) bool { return o.key(e.line.text) == "" }
+			}
+
+			got := []string{}
+			for _, h := range diffLines(o, al, bl).split(tc.context, tc.interHunk, ignorable) {
+				got = append(got, h.Header())
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("expect %v, got %v", tc.expect, got)
+			}
+		})
+	}
+
+}
@@ -1,27 +1,29 @@
 package repository
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/mizuho-u/got/internal"
 	"github.com/mizuho-u/got/repository/object"
 )
 
-func (repo *repository) Diff(staged bool) ([]diff, error) {
+func (repo *repository) Diff(staged bool) ([]FileDiff, error) {
 
 	if staged {
 		return repo.diffStaged()
 	}
 
-	diffs := []diff{}
+	diffs := []FileDiff{}
 
 	files, types := repo.WorkspaceChanges()
 
 	for _, path := range files {
 
-		var d diff
+		var d FileDiff
 
 		switch types[path] {
 		case statusFileDeleted:
@@ -30,7 +32,7 @@ func (repo *repository) Diff(staged bool) ([]diff, error) {
 
 			deleted.AOID = repo.index.entries[path].oid
 			deleted.AMode = string(repo.index.entries[path].permission())
-			deleted.APath = filepath.Join("a", path)
+			deleted.APath = path
 			obj, err := repo.object.Load(deleted.AOID)
 			if err != nil {
 				return nil, err
@@ -38,7 +40,7 @@ func (repo *repository) Diff(staged bool) ([]diff, error) {
 			deleted.AData = obj.Data()
 
 			deleted.BOID = nullOID
-			deleted.BPath = filepath.Join("b", path)
+			deleted.BPath = path
 			deleted.BData = []byte(nullContents)
 
 			d = deleted
@@ -73,6 +75,7 @@ func (repo *repository) Diff(staged bool) ([]diff, error) {
 			modified.BMode = string(f.Stats().Permission())
 			modified.BPath = path
 			modified.BData = data
+			modified.workspace = true
 
 			d = modified
 
@@ -85,15 +88,15 @@ func (repo *repository) Diff(staged bool) ([]diff, error) {
 	return diffs, nil
 }
 
-func (repo *repository) diffStaged() ([]diff, error) {
+func (repo *repository) diffStaged() ([]FileDiff, error) {
 
-	diffs := []diff{}
+	diffs := []FileDiff{}
 
 	files, types := repo.IndexChanges()
 
 	for _, path := range files {
 
-		var d diff
+		var d FileDiff
 
 		switch types[path] {
 		case statusFileModified:
@@ -129,7 +132,7 @@ func (repo *repository) diffStaged() ([]diff, error) {
 
 			deleted.AOID = repo.head[path].OID()
 			deleted.AMode = string(repo.head[path].Permission())
-			deleted.APath = filepath.Join("a", path)
+			deleted.APath = path
 
 			obj, err := repo.object.Load(deleted.AOID)
 			if err != nil {
@@ -138,7 +141,7 @@ func (repo *repository) diffStaged() ([]diff, error) {
 			deleted.AData = obj.Data()
 
 			deleted.BOID = nullOID
-			deleted.BPath = filepath.Join("b", path)
+			deleted.BPath = path
 			deleted.BData = []byte(nullContents)
 
 			d = deleted
@@ -148,12 +151,12 @@ func (repo *repository) diffStaged() ([]diff, error) {
 			added := &diffAdded{}
 
 			added.AOID = nullOID
-			added.APath = filepath.Join("a", path)
+			added.APath = path
 			added.AData = []byte(nullContents)
 
 			added.BOID = repo.index.entries[path].oid
 			added.BMode = string(repo.index.entries[path].permission())
-			added.BPath = filepath.Join("b", path)
+			added.BPath = path
 			obj, err := repo.object.Load(added.BOID)
 			if err != nil {
 				return nil, err
@@ -171,11 +174,83 @@ func (repo *repository) diffStaged() ([]diff, error) {
 	return diffs, nil
 }
 
+// DiffTree Scanで読んだツリーとワークスペースの差分。ワークスペースのファイルはインデックスにあるものだけを比べる
+func (repo *repository) DiffTree() ([]FileDiff, error) {
+
+	paths := map[string]struct{}{}
+	for name := range repo.head {
+		paths[name] = struct{}{}
+	}
+	for name := range repo.index.entries {
+		paths[name] = struct{}{}
+	}
+
+	names := internal.Keys(paths)
+	sort.Strings(names)
+
+	diffs := []FileDiff{}
+	for _, path := range names {
+
+		if !repo.matches(path) {
+			continue
+		}
+
+		aOID, aMode, aData := nullOID, "", []byte(nullContents)
+		if h, ok := repo.head[path]; ok {
+
+			data, err := io.ReadAll(h)
+			if err != nil {
+				return nil, err
+			}
+			aOID, aMode, aData = h.OID(), string(h.Permission()), data
+		}
+
+		bOID, bMode, bData := nullOID, "", []byte(nullContents)
+		if f, ok := repo.workspace[path]; ok && repo.index.tracked(path) {
+
+			f.Seek(0, io.SeekStart)
+			data, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+
+			blob, err := object.NewBlob(path, data)
+			if err != nil {
+				return nil, err
+			}
+			bOID, bMode, bData = blob.OID(), string(f.Stats().Permission()), data
+		}
+
+		if aOID == bOID && aMode == bMode {
+			continue
+		}
+
+		diffs = append(diffs, newFileDiff(path, aOID, aMode, aData, bOID, bMode, bData, true))
+	}
+
+	return diffs, nil
+}
+
+// newFileDiff 片方がnullOIDなら追加か削除の差分にする。workspaceならBはワークスペースのファイル
+func newFileDiff(path, aOID, aMode string, aData []byte, bOID, bMode string, bData []byte, workspace bool) FileDiff {
+
+	switch {
+	case aOID == nullOID:
+		return &diffAdded{AOID: aOID, APath: path, AData: aData, BOID: bOID, BMode: bMode, BPath: path, BData: bData, workspace: workspace}
+	case bOID == nullOID:
+		return &diffDeleted{AOID: aOID, AMode: aMode, APath: path, AData: aData, BOID: bOID, BPath: path, BData: bData}
+	default:
+		return &diffModified{AOID: aOID, AMode: aMode, APath: path, AData: aData, BOID: bOID, BMode: bMode, BPath: path, BData: bData, workspace: workspace}
+	}
+}
+
 const (
 	statusNone          status = " "
 	statusIndexAdded    status = "A"
 	statusFileDeleted   status = "D"
 	statusFileModified  status = "M"
+	statusRenamed       status = "R"
+	statusCopied        status = "C"
 	statusFileUntracked status = ""
 	statusUnchanged     status = statusNone + statusNone
 )
@@ -194,6 +269,10 @@ func (s status) LongFormat() string {
 		return "deleted"
 	case statusFileModified:
 		return "modified"
+	case statusRenamed:
+		return "renamed"
+	case statusCopied:
+		return "copied"
 	default:
 		return ""
 	}
@@ -203,14 +282,68 @@ const (
 	nullPath     string = "/dev/null"
 	nullOID      string = "0000000000000000000000000000000000000000"
 	nullContents string = ""
+	nullMode     string = "000000"
 )
 
-type diff interface {
+// raw :100644 100644 abc1234 def5678 M の形式。ないほうのモードは000000
+func raw(aMode, bMode, aOID, bOID string, s status) string {
+
+	if aMode == "" {
+		aMode = nullMode
+	}
+	if bMode == "" {
+		bMode = nullMode
+	}
+
+	return fmt.Sprintf(":%s %s %s %s %s", aMode, bMode, object.ShortOID(aOID), object.ShortOID(bOID), s)
+}
+
+// FileDiff ファイルひとつ分の差分
+type FileDiff interface {
 	PathLine() string
 	ModeLine() string
 	IndexLine() string
 	FileLine() string
 	Hunks() []*hunk
+	Path() string
+	// OldPath 名前の変更とコピーの元のパス。それ以外はPathと同じ
+	OldPath() string
+	Status() status
+	// NameStatus --name-statusの状態。名前の変更とコピーはR051のように類似度をつける
+	NameStatus() string
+	// Raw --rawのパスより前の部分
+	Raw() string
+	// Stat 追加と削除の行数。バイナリなら0
+	Stat() (added, deleted int)
+	// Binary 内容が変わったバイナリの差分か。ハンクのかわりにBinaryPatchを出す
+	Binary() bool
+	BinaryPatch() string
+	// BinarySize --statに出す前後のバイト数
+	BinarySize() (before, after int)
+	strip(prefix string)
+	setOptions(opts *diffOptions)
+	contents() (a, b []byte)
+}
+
+// Relative prefix以下の差分だけを残し、パスをprefixからの相対パスにする
+func Relative(diffs []FileDiff, prefix string) []FileDiff {
+
+	if prefix == "" || prefix == "." {
+		return diffs
+	}
+
+	relative := []FileDiff{}
+	for _, d := range diffs {
+
+		if !strings.HasPrefix(d.Path(), prefix+"/") {
+			continue
+		}
+
+		d.strip(prefix + "/")
+		relative = append(relative, d)
+	}
+
+	return relative
 }
 
 type diffModified struct {
@@ -222,6 +355,11 @@ type diffModified struct {
 	BMode string
 	BPath string
 	BData []byte
+	// workspace Bがワークスペースのファイル。--rawではgitと同じくBのIDを出さない
+	workspace bool
+	opts      *diffOptions
+	// hunks 求めたハンク。移動した行の印を残すため、一度だけ求める
+	hunks []*hunk
 }
 
 func (diff *diffModified) PathLine() string {
@@ -246,11 +384,13 @@ func (diff *diffModified) IndexLine() string {
 		return ""
 	}
 
+	o, binary := diff.opts.orDefault(), diff.Binary()
+	aOID, bOID := o.indexOID(diff.AOID, binary), o.indexOID(diff.BOID, binary)
 	if diff.AMode != diff.BMode {
-		return fmt.Sprintf("index %s..%s\n", object.ShortOID(diff.AOID), object.ShortOID(diff.BOID))
+		return fmt.Sprintf("index %s..%s\n", aOID, bOID)
 	}
 
-	return fmt.Sprintf("index %s..%s %s\n", object.ShortOID(diff.AOID), object.ShortOID(diff.BOID), diff.AMode)
+	return fmt.Sprintf("index %s..%s %s\n", aOID, bOID, diff.AMode)
 }
 
 func (diff *diffModified) FileLine() string {
@@ -266,15 +406,68 @@ func (diff *diffModified) FileLine() string {
 
 }
 
+func (diff *diffModified) Path() string {
+	return diff.APath
+}
+
+func (diff *diffModified) OldPath() string {
+	return diff.APath
+}
+
+func (diff *diffModified) NameStatus() string {
+	return diff.Status().ShortFormat()
+}
+
+func (diff *diffModified) strip(prefix string) {
+	diff.APath = strings.TrimPrefix(diff.APath, prefix)
+	diff.BPath = strings.TrimPrefix(diff.BPath, prefix)
+}
+
+func (diff *diffModified) setOptions(opts *diffOptions) {
+	diff.opts = opts
+	diff.hunks = nil
+}
+
+func (diff *diffModified) contents() (a, b []byte) {
+	return diff.AData, diff.BData
+}
+
+func (diff *diffModified) Status() status {
+	return statusFileModified
+}
+
+func (diff *diffModified) Raw() string {
+
+	if diff.workspace {
+		return raw(diff.AMode, diff.BMode, diff.AOID, nullOID, diff.Status())
+	}
+
+	return raw(diff.AMode, diff.BMode, diff.AOID, diff.BOID, diff.Status())
+}
+
+func (diff *diffModified) Stat() (added, deleted int) {
+	return stat(diff.Hunks())
+}
+
 func (diff *diffModified) Hunks() []*hunk {
 
-	al, _ := lines(bytes.NewBuffer(diff.AData))
-	bl, _ := lines(bytes.NewBuffer(diff.BData))
+	if diff.hunks == nil {
+		diff.hunks = diff.opts.orDefault().hunks(diff.AData, diff.BData)
+	}
+
+	return diff.hunks
+}
 
-	m := newMyers(al, bl)
+func (diff *diffModified) Binary() bool {
+	return diff.opts.orDefault().binary(diff.AData, diff.BData)
+}
 
-	return m.diff().hunks()
+func (diff *diffModified) BinaryPatch() string {
+	return diff.opts.orDefault().binaryDiff(filepath.Join("a", diff.APath), filepath.Join("b", diff.BPath), diff.AData, diff.BData)
+}
 
+func (diff *diffModified) BinarySize() (before, after int) {
+	return len(diff.AData), len(diff.BData)
 }
 
 type diffDeleted struct {
@@ -286,10 +479,15 @@ type diffDeleted struct {
 	BMode string
 	BPath string
 	BData []byte
+	opts  *diffOptions
+	// hunks 求めたハンク。移動した行の印を残すため、一度だけ求める
+	hunks []*hunk
+	// noIndex --no-indexの差分。gitと同じく要約では新しいパスを/dev/nullにする
+	noIndex bool
 }
 
 func (diff *diffDeleted) PathLine() string {
-	return fmt.Sprintf("diff --git %s %s\n", diff.APath, diff.BPath)
+	return fmt.Sprintf("diff --git %s %s\n", filepath.Join("a", diff.APath), filepath.Join("b", diff.BPath))
 }
 
 func (diff *diffDeleted) ModeLine() string {
@@ -297,27 +495,81 @@ func (diff *diffDeleted) ModeLine() string {
 }
 
 func (diff *diffDeleted) IndexLine() string {
-	return fmt.Sprintf("index %s..%s\n", object.ShortOID(diff.AOID), object.ShortOID(diff.BOID))
+	o, binary := diff.opts.orDefault(), diff.Binary()
+	return fmt.Sprintf("index %s..%s\n", o.indexOID(diff.AOID, binary), o.indexOID(diff.BOID, binary))
 }
 
 func (diff *diffDeleted) FileLine() string {
 
-	l := fmt.Sprintf("--- %s\n", diff.APath)
+	l := fmt.Sprintf("--- %s\n", filepath.Join("a", diff.APath))
 	l += fmt.Sprintf("+++ %s\n", nullPath)
 
 	return l
 
 }
 
+func (diff *diffDeleted) Path() string {
+
+	if diff.noIndex {
+		return nullPath
+	}
+
+	return diff.APath
+}
+
+func (diff *diffDeleted) OldPath() string {
+	return diff.APath
+}
+
+func (diff *diffDeleted) NameStatus() string {
+	return diff.Status().ShortFormat()
+}
+
+func (diff *diffDeleted) strip(prefix string) {
+	diff.APath = strings.TrimPrefix(diff.APath, prefix)
+	diff.BPath = strings.TrimPrefix(diff.BPath, prefix)
+}
+
+func (diff *diffDeleted) setOptions(opts *diffOptions) {
+	diff.opts = opts
+	diff.hunks = nil
+}
+
+func (diff *diffDeleted) contents() (a, b []byte) {
+	return diff.AData, diff.BData
+}
+
+func (diff *diffDeleted) Status() status {
+	return statusFileDeleted
+}
+
+func (diff *diffDeleted) Raw() string {
+	return raw(diff.AMode, diff.BMode, diff.AOID, diff.BOID, diff.Status())
+}
+
+func (diff *diffDeleted) Stat() (added, deleted int) {
+	return stat(diff.Hunks())
+}
+
 func (diff *diffDeleted) Hunks() []*hunk {
 
-	al, _ := lines(bytes.NewBuffer(diff.AData))
-	bl, _ := lines(bytes.NewBuffer(diff.BData))
+	if diff.hunks == nil {
+		diff.hunks = diff.opts.orDefault().hunks(diff.AData, diff.BData)
+	}
+
+	return diff.hunks
+}
 
-	m := newMyers(al, bl)
+func (diff *diffDeleted) Binary() bool {
+	return diff.opts.orDefault().binary(diff.AData, diff.BData)
+}
 
-	return m.diff().hunks()
+func (diff *diffDeleted) BinaryPatch() string {
+	return diff.opts.orDefault().binaryDiff(filepath.Join("a", diff.APath), nullPath, diff.AData, diff.BData)
+}
 
+func (diff *diffDeleted) BinarySize() (before, after int) {
+	return len(diff.AData), len(diff.BData)
 }
 
 type diffAdded struct {
@@ -329,10 +581,17 @@ type diffAdded struct {
 	BMode string
 	BPath string
 	BData []byte
+	// workspace Bがワークスペースのファイル。--rawではgitと同じくBのIDを出さない
+	workspace bool
+	opts      *diffOptions
+	// hunks 求めたハンク。移動した行の印を残すため、一度だけ求める
+	hunks []*hunk
+	// noIndex --no-indexの差分。gitと同じく要約では元のパスを/dev/nullにする
+	noIndex bool
 }
 
 func (diff *diffAdded) PathLine() string {
-	return fmt.Sprintf("diff --git %s %s\n", diff.APath, diff.BPath)
+	return fmt.Sprintf("diff --git %s %s\n", filepath.Join("a", diff.APath), filepath.Join("b", diff.BPath))
 }
 
 func (diff *diffAdded) ModeLine() string {
@@ -340,24 +599,83 @@ func (diff *diffAdded) ModeLine() string {
 }
 
 func (diff *diffAdded) IndexLine() string {
-	return fmt.Sprintf("index %s..%s\n", object.ShortOID(diff.AOID), object.ShortOID(diff.BOID))
+	o, binary := diff.opts.orDefault(), diff.Binary()
+	return fmt.Sprintf("index %s..%s\n", o.indexOID(diff.AOID, binary), o.indexOID(diff.BOID, binary))
 }
 
 func (diff *diffAdded) FileLine() string {
 
 	l := fmt.Sprintf("--- %s\n", nullPath)
-	l += fmt.Sprintf("+++ %s\n", diff.BPath)
+	l += fmt.Sprintf("+++ %s\n", filepath.Join("b", diff.BPath))
 
 	return l
 }
 
+func (diff *diffAdded) Path() string {
+	return diff.BPath
+}
+
+func (diff *diffAdded) OldPath() string {
+
+	if diff.noIndex {
+		return nullPath
+	}
+
+	return diff.BPath
+}
+
+func (diff *diffAdded) NameStatus() string {
+	return diff.Status().ShortFormat()
+}
+
+func (diff *diffAdded) strip(prefix string) {
+	diff.APath = strings.TrimPrefix(diff.APath, prefix)
+	diff.BPath = strings.TrimPrefix(diff.BPath, prefix)
+}
+
+func (diff *diffAdded) setOptions(opts *diffOptions) {
+	diff.opts = opts
+	diff.hunks = nil
+}
+
+func (diff *diffAdded) contents() (a, b []byte) {
+	return diff.AData, diff.BData
+}
+
+func (diff *diffAdded) Status() status {
+	return statusIndexAdded
+}
+
+func (diff *diffAdded) Raw() string {
+
+	if diff.workspace {
+		return raw(diff.AMode, diff.BMode, diff.AOID, nullOID, diff.Status())
+	}
+
+	return raw(diff.AMode, diff.BMode, diff.AOID, diff.BOID, diff.Status())
+}
+
+func (diff *diffAdded) Stat() (added, deleted int) {
+	return stat(diff.Hunks())
+}
+
 func (diff *diffAdded) Hunks() []*hunk {
 
-	al, _ := lines(bytes.NewBuffer(diff.AData))
-	bl, _ := lines(bytes.NewBuffer(diff.BData))
+	if diff.hunks == nil {
+		diff.hunks = diff.opts.orDefault().hunks(diff.AData, diff.BData)
+	}
+
+	return diff.hunks
+}
 
-	m := newMyers(al, bl)
+func (diff *diffAdded) Binary() bool {
+	return diff.opts.orDefault().binary(diff.AData, diff.BData)
+}
 
-	return m.diff().hunks()
+func (diff *diffAdded) BinaryPatch() string {
+	return diff.opts.orDefault().binaryDiff(nullPath, filepath.Join("b", diff.BPath), diff.AData, diff.BData)
+}
 
+func (diff *diffAdded) BinarySize() (before, after int) {
+	return len(diff.AData), len(diff.BData)
 }
@@ -0,0 +1,351 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// Whitespace 行を比べるときに無視する空白
+type Whitespace int
+
+const (
+	// IgnoreAllSpace -w。空白をすべて無視する
+	IgnoreAllSpace Whitespace = 1 << iota
+	// IgnoreSpaceChange -b。空白の量の違いと行末の空白を無視する
+	IgnoreSpaceChange
+	// IgnoreSpaceAtEOL --ignore-space-at-eol。行末の空白を無視する
+	IgnoreSpaceAtEOL
+)
+
+type diffOptions struct {
+	algorithm DiffAlgorithm
+	// context ハンクの前後につける変更のない行数
+	context int
+	// interHunk 間の行数がこれ以下ならハンクをつなげる。前後のcontextの分は含まない
+	interHunk        int
+	whitespace       Whitespace
+	ignoreBlankLines bool
+	driver           *DiffDriver
+	// drivers パスごとのdiff属性のドライバー
+	drivers func(path string) *DiffDriver
+	// binaryPatch バイナリの差分をGIT binary patchで出す
+	binaryPatch bool
+	// wordRegex --word-diff-regex。ドライバーの設定より優先する
+	wordRegex *regexp.Regexp
+	// defaultWordRegex diff.wordRegex。ドライバーに設定がなければ使う
+	defaultWordRegex *regexp.Regexp
+}
+
+func defaultDiffOptions() *diffOptions {
+	return &diffOptions{algorithm: DiffMyers, context: hunkContext}
+}
+
+type DiffOption func(*diffOptions)
+
+// Algorithm 行の差分をalgorithmで求める
+func Algorithm(algorithm DiffAlgorithm) DiffOption {
+
+	return func(o *diffOptions) {
+		o.algorithm = algorithm
+	}
+
+}
+
+// Context ハンクの前後にn行ずつ変更のない行をつける
+func Context(n int) DiffOption {
+
+	return func(o *diffOptions) {
+		o.context = n
+	}
+
+}
+
+// InterHunkContext 間の行数がn行以下のハンクをつなげる
+func InterHunkContext(n int) DiffOption {
+
+	return func(o *diffOptions) {
+		o.interHunk = n
+	}
+
+}
+
+// IgnoreWhitespace wsの空白を無視して行を比べる
+func IgnoreWhitespace(ws Whitespace) DiffOption {
+
+	return func(o *diffOptions) {
+		o.whitespace |= ws
+	}
+
+}
+
+// IgnoreBlankLines 空行を追加か削除しただけの変更をハンクにしない
+func IgnoreBlankLines() DiffOption {
+
+	return func(o *diffOptions) {
+		o.ignoreBlankLines = true
+	}
+
+}
+
+// Drivers パスごとのドライバーで比べる。nilならバイナリかは内容から決めて、関数はgitと同じく英字で始まる行
+func Drivers(f func(path string) *DiffDriver) DiffOption {
+
+	return func(o *diffOptions) {
+		o.drivers = f
+	}
+
+}
+
+// BinaryPatch --binary。バイナリの差分を、パッチで戻せるGIT binary patchで出す
+func BinaryPatch() DiffOption {
+
+	return func(o *diffOptions) {
+		o.binaryPatch = true
+	}
+
+}
+
+// WordRegex --word-diff-regex。単語で比べるときにreにマッチする部分を単語にする
+func WordRegex(re *regexp.Regexp) DiffOption {
+
+	return func(o *diffOptions) {
+		o.wordRegex = re
+	}
+
+}
+
+// DefaultWordRegex diff.wordRegex。diff属性のドライバーに単語の正規表現がなければreを使う
+func DefaultWordRegex(re *regexp.Regexp) DiffOption {
+
+	return func(o *diffOptions) {
+		o.defaultWordRegex = re
+	}
+
+}
+
+// UseDiffOptions 差分のハンクと行数をoptsで求める
+func UseDiffOptions(diffs []FileDiff, opts ...DiffOption) []FileDiff {
+
+	o := defaultDiffOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	for _, d := range diffs {
+
+		do := *o
+		if o.drivers != nil {
+
+			// gitと同じく元のパスのドライバーを優先する
+			if do.driver = o.drivers(d.OldPath()); do.driver == nil {
+				do.driver = o.drivers(d.Path())
+			}
+		}
+
+		d.setOptions(&do)
+	}
+
+	return diffs
+}
+
+// OmitUnchanged 空白などを無視してハンクがなくなった差分を除く。モードや名前が変わっていれば残す
+func OmitUnchanged(diffs []FileDiff) []FileDiff {
+
+	result := []FileDiff{}
+	for _, d := range diffs {
+
+		m, ok := d.(*diffModified)
+		if n, noIndex := d.(*diffNoIndex); noIndex {
+			m, ok = &n.diffModified, true
+		}
+
+		if ok && m.AMode == m.BMode && !m.Binary() && len(m.Hunks()) == 0 {
+			continue
+		}
+
+		result = append(result, d)
+	}
+
+	return result
+}
+
+func (o *diffOptions) orDefault() *diffOptions {
+
+	if o == nil {
+		return defaultDiffOptions()
+	}
+
+	return o
+}
+
+// spaces gitと同じくisspaceの文字
+const spaces = " \t\n\v\f\r"
+
+func isSpace(c byte) bool {
+	return strings.IndexByte(spaces, c) != -1
+}
+
+// key 行を比べるときの文字列。無視する空白を取り除く
+func (o *diffOptions) key(text string) string {
+
+	switch {
+	case o.whitespace&IgnoreAllSpace != 0:
+
+		var b strings.Builder
+		for i := 0; i < len(text); i++ {
+			if !isSpace(text[i]) {
+				b.WriteByte(text[i])
+			}
+		}
+		return b.String()
+
+	case o.whitespace&IgnoreSpaceChange != 0:
+
+		var b strings.Builder
+		text = strings.TrimRight(text, spaces)
+		for i := 0; i < len(text); i++ {
+
+			if !isSpace(text[i]) {
+				b.WriteByte(text[i])
+				continue
+			}
+
+			// 続く空白はひとつにする
+			if i == 0 || !isSpace(text[i-1]) {
+				b.WriteByte(' ')
+			}
+		}
+		return b.String()
+
+	case o.whitespace&IgnoreSpaceAtEOL != 0:
+		return strings.TrimRight(text, spaces)
+	}
+
+	return text
+}
+
+// keys 比べるための行。空白を無視しなければそのまま
+func (o *diffOptions) keys(lines []*line) []*line {
+
+	if o.whitespace == 0 {
+		return lines
+	}
+
+	keys := make([]*line, len(lines))
+	for i, l := range lines {
+		keys[i] = &line{l.number, o.key(l.text)}
+	}
+
+	return keys
+}
+
+// diff aとbの内容を行ごとに比べる
+func (o *diffOptions) diff(a, b []byte) (al []*line, es edits) {
+
+	al, _ = lines(bytes.NewBuffer(a))
+	bl, _ := lines(bytes.NewBuffer(b))
+
+	return al, diffLines(o, al, bl)
+}
+
+// words 単語の正規表現。--word-diff-regex、ドライバー、diff.wordRegexの順に決める。nilなら空白で区切る
+func (o *diffOptions) words() *regexp.Regexp {
+
+	if o.wordRegex != nil {
+		return o.wordRegex
+	}
+
+	if re := o.driver.WordRegex(); re != nil {
+		return re
+	}
+
+	return o.defaultWordRegex
+}
+
+// binary 内容が変わっていて、ドライバーか内容でバイナリとみなすか
+func (o *diffOptions) binary(a, b []byte) bool {
+	return !bytes.Equal(a, b) && o.driver.isBinary(a, b)
+}
+
+// binaryDiff Binary files a/x and b/x differ。--binaryならGIT binary patch
+func (o *diffOptions) binaryDiff(aName, bName string, a, b []byte) string {
+
+	if o.binaryPatch {
+		return binaryPatch(a, b)
+	}
+
+	return fmt.Sprintf("Binary files %s and %s differ\n", aName, bName)
+}
+
+// indexOID indexの行のID。--binaryならバイナリの差分はgitと同じく省略しない
+func (o *diffOptions) indexOID(oid string, binary bool) string {
+
+	if o.binaryPatch && binary {
+		return oid
+	}
+
+	return object.ShortOID(oid)
+}
+
+// hunks aとbの差分のハンク。バイナリならハンクはない。空行だけの変更を無視するなら他の変更から離れたものはハンクにしない
+func (o *diffOptions) hunks(a, b []byte) []*hunk {
+
+	if o.binary(a, b) {
+		return []*hunk{}
+	}
+
+	al, es := o.diff(a, b)
+
+	var ignorable func(*edit) bool
+	if o.ignoreBlankLines {
+		ignorable = func(e *edit) bool { return o.key(e.line.text) == "" }
+	}
+
+	hunks := es.split(o.context, o.interHunk, ignorable)
+
+	// 前のハンクとの間に関数が見つからなければ、前のハンクの関数を使う
+	funcname, prev := "", -1
+	for _, h := range hunks {
+
+		start := h.aStart
+		if lines := h.edits.filter(func(e *edit) bool { return e.aline != nil }); len(lines) > 0 {
+			start = lines[0].aline.number - 1
+		}
+
+		for i := start - 1; i > prev && i >= 0; i-- {
+
+			if name, ok := o.driver.Funcname().match(al[i].text); ok {
+				funcname = name
+				break
+			}
+		}
+
+		prev = start - 1
+		h.funcname = funcname
+		h.wordRegex = o.words()
+	}
+
+	return hunks
+}
+
+// stat ハンクにした追加と削除の行数
+func stat(hunks []*hunk) (added, deleted int) {
+
+	for _, h := range hunks {
+		for _, e := range h.edits {
+
+			switch e.diff {
+			case Insertion:
+				added++
+			case Deletion:
+				deleted++
+			}
+		}
+	}
+
+	return added, deleted
+}
@@ -0,0 +1,79 @@
+package repository
+
+import "regexp"
+
+// DiffDriver diff属性で決まるファイルの比べ方
+type DiffDriver struct {
+	funcname *Funcname
+	// binary nilなら内容から決める
+	binary *bool
+	// wordRegex diff.<driver>.wordRegex
+	wordRegex *regexp.Regexp
+}
+
+type DiffDriverOption func(*DiffDriver)
+
+// DriverFuncname ハンクのヘッダーの関数をfuncnameで探す
+func DriverFuncname(funcname *Funcname) DiffDriverOption {
+
+	return func(d *DiffDriver) {
+		d.funcname = funcname
+	}
+
+}
+
+// DriverBinary 内容にかかわらずバイナリかテキストとして比べる。-diffならバイナリ、diffならテキスト
+func DriverBinary(binary bool) DiffDriverOption {
+
+	return func(d *DiffDriver) {
+		d.binary = &binary
+	}
+
+}
+
+// DriverWordRegex 単語で比べるときにreにマッチする部分を単語にする
+func DriverWordRegex(re *regexp.Regexp) DiffDriverOption {
+
+	return func(d *DiffDriver) {
+		d.wordRegex = re
+	}
+
+}
+
+func NewDiffDriver(opts ...DiffDriverOption) *DiffDriver {
+
+	d := &DiffDriver{}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+func (d *DiffDriver) Funcname() *Funcname {
+
+	if d == nil {
+		return nil
+	}
+
+	return d.funcname
+}
+
+// isBinary ドライバーで決まっていなければ、どちらかの内容がバイナリならバイナリ
+func (d *DiffDriver) isBinary(a, b []byte) bool {
+
+	if d != nil && d.binary != nil {
+		return *d.binary
+	}
+
+	return isBinary(a) || isBinary(b)
+}
+
+func (d *DiffDriver) WordRegex() *regexp.Regexp {
+
+	if d == nil {
+		return nil
+	}
+
+	return d.wordRegex
+}
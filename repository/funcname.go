@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxFuncnameLength ハンクのヘッダーに出す関数の行の長さの上限。gitと同じ
+const maxFuncnameLength = 80
+
+// builtinFuncnames diff=<driver>の組み込みのパターン。gitのuserdiffと同じ
+var builtinFuncnames = map[string]string{
+	"golang": "^[ \t]*(func[ \t]*.*(\\{[ \t]*)?)\n" +
+		"^[ \t]*(type[ \t].*(struct|interface)[ \t]*(\\{[ \t]*)?)",
+	"python": "^[ \t]*((class|(async[ \t]+)?def)[ \t].*)$",
+	"cpp": "!^[ \t]*[A-Za-z_][A-Za-z_0-9]*:[[:space:]]*($|/[/*])\n" +
+		"^((::[[:space:]]*)?[A-Za-z_].*)$",
+}
+
+// Funcname ハンクのヘッダーに出す関数の行を探すパターン。nilならgitと同じく英字、_、$で始まる行
+type Funcname struct {
+	patterns []*funcnamePattern
+}
+
+type funcnamePattern struct {
+	re *regexp.Regexp
+	// negate マッチした行は関数の行にしない
+	negate bool
+}
+
+// NewFuncname diff.<driver>.xfuncnameのような拡張正規表現。改行で区切ったものを順に試し、!で始まるものにマッチすれば関数の行にしない
+func NewFuncname(xfuncname string) (*Funcname, error) {
+
+	f := &Funcname{}
+	for _, p := range strings.Split(xfuncname, "\n") {
+
+		negate := strings.HasPrefix(p, "!")
+		if negate {
+			p = p[1:]
+		}
+
+		re, err := regexp.CompilePOSIX(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp to look for hunk header: %s", p)
+		}
+
+		f.patterns = append(f.patterns, &funcnamePattern{re, negate})
+	}
+
+	return f, nil
+}
+
+// BuiltinFuncname 組み込みのドライバーgolang、python、cppのパターン
+func BuiltinFuncname(driver string) (*Funcname, bool) {
+
+	p, ok := builtinFuncnames[driver]
+	if !ok {
+		return nil, false
+	}
+
+	f, err := NewFuncname(p)
+	if err != nil {
+		return nil, false
+	}
+
+	return f, true
+}
+
+// match textが関数の行なら、ヘッダーに出す部分。最初のグループがあればその部分にする
+func (f *Funcname) match(text string) (string, bool) {
+
+	text = strings.TrimSuffix(text, "\r")
+
+	if f == nil {
+
+		if text == "" || !(isAlpha(text[0]) || text[0] == '_' || text[0] == '$') {
+			return "", false
+		}
+
+		return trimFuncname(text), true
+	}
+
+	for _, p := range f.patterns {
+
+		m := p.re.FindStringSubmatchIndex(text)
+		if m == nil {
+			continue
+		}
+
+		if p.negate {
+			return "", false
+		}
+
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+
+		return trimFuncname(text[start:end]), true
+	}
+
+	return "", false
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func trimFuncname(s string) string {
+
+	if len(s) > maxFuncnameLength {
+		s = s[:maxFuncnameLength]
+	}
+
+	return strings.TrimRight(s, spaces)
+}
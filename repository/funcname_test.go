@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFuncnameMatch(t *testing.T) {
+
+	builtin := func(driver string) *Funcname {
+
+		f, ok := BuiltinFuncname(driver)
+		if !ok {
+			t.Fatalf("%s is not builtin", driver)
+		}
+
+		return f
+	}
+
+	testt := []struct {
+		description string
+		funcname    *Funcname
+		text        string
+		expect      string
+		ok          bool
+	}{
+		{"default matches a line starting with a letter", nil, "int main(void)  ", "int main(void)", true},
+		{"default skips an indented line", nil, "\treturn 0;", "", false},
+		{"default truncates long lines", nil, strings.Repeat("a", 100), strings.Repeat("a", 80), true},
+		{"golang func", builtin("golang"), "func (r *repo) Diff() error {", "func (r *repo) Diff() error {", true},
+		{"golang type", builtin("golang"), "type repo struct {", "type repo struct {", true},
+		{"golang skips other lines", builtin("golang"), "var x = 1", "", false},
+		{"python def", builtin("python"), "    async def run(self):", "async def run(self):", true},
+		{"cpp function", builtin("cpp"), "static int g(void)", "static int g(void)", true},
+		{"cpp skips labels", builtin("cpp"), "label:", "", false},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			got, ok := tc.funcname.match(tc.text)
+			if got != tc.expect || ok != tc.ok {
+				t.Errorf("expect %q %v, got %q %v", tc.expect, tc.ok, got, ok)
+			}
+		})
+	}
+
+	if _, err := NewFuncname("(["); err == nil {
+		t.Error("expect an error for an invalid regexp")
+	}
+
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/mizuho-u/got/internal"
+)
+
+type Ignore interface {
+	AddExcludes(source string, r io.Reader) error
+	AddRules(dir, source string, r io.Reader) error
+	Match(path string, isDir bool) (rule *IgnoreRule, ignored bool)
+}
+
+type IgnoreRule struct {
+	source   string
+	line     int
+	pattern  string
+	base     string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	re       *regexp.Regexp
+}
+
+func (r *IgnoreRule) Source() string {
+	return r.source
+}
+
+func (r *IgnoreRule) Line() int {
+	return r.line
+}
+
+func (r *IgnoreRule) Pattern() string {
+	return r.pattern
+}
+
+func (r *IgnoreRule) Negated() bool {
+	return r.negate
+}
+
+func (r *IgnoreRule) match(p string, isDir bool) bool {
+
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	if r.base != "" {
+		if !strings.HasPrefix(p, r.base+"/") {
+			return false
+		}
+		p = strings.TrimPrefix(p, r.base+"/")
+	}
+
+	if !r.anchored {
+		p = path.Base(p)
+	}
+
+	return r.re.MatchString(p)
+}
+
+type ignore struct {
+	excludes []*IgnoreRule
+	dirs     map[string][]*IgnoreRule
+}
+
+func NewIgnore() *ignore {
+	return &ignore{excludes: []*IgnoreRule{}, dirs: map[string][]*IgnoreRule{}}
+}
+
+// AddExcludes info/excludeやcore.excludesFileのルールを追加する。後から追加したものほど優先される
+func (ig *ignore) AddExcludes(source string, r io.Reader) error {
+
+	rules, err := parseIgnoreRules("", source, r)
+	if err != nil {
+		return err
+	}
+
+	ig.excludes = append(ig.excludes, rules...)
+
+	return nil
+}
+
+// AddRules dirにある.gitignoreのルールを設定する。同じdirのルールは置き換える
+func (ig *ignore) AddRules(dir, source string, r io.Reader) error {
+
+	if dir == "." {
+		dir = ""
+	}
+
+	rules, err := parseIgnoreRules(dir, source, r)
+	if err != nil {
+		return err
+	}
+
+	ig.dirs[dir] = rules
+
+	return nil
+}
+
+// Match pathにマッチしたルールを返す。親ディレクトリが除外されていれば中のファイルは戻せない
+func (ig *ignore) Match(p string, isDir bool) (*IgnoreRule, bool) {
+
+	for _, parent := range internal.ParentDirs(p) {
+		if rule := ig.match(parent, true); rule != nil && !rule.negate {
+			return rule, true
+		}
+	}
+
+	rule := ig.match(p, isDir)
+
+	return rule, rule != nil && !rule.negate
+}
+
+func (ig *ignore) match(p string, isDir bool) *IgnoreRule {
+
+	// 深いディレクトリの.gitignoreほど優先される
+	dirs := append([]string{""}, internal.ParentDirs(p)...)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if rule := lastMatch(ig.dirs[dirs[i]], p, isDir); rule != nil {
+			return rule
+		}
+	}
+
+	return lastMatch(ig.excludes, p, isDir)
+}
+
+func lastMatch(rules []*IgnoreRule, p string, isDir bool) *IgnoreRule {
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(p, isDir) {
+			return rules[i]
+		}
+	}
+
+	return nil
+}
+
+func parseIgnoreRules(base, source string, r io.Reader) ([]*IgnoreRule, error) {
+
+	rules := []*IgnoreRule{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+
+		rule, ok := parseIgnoreRule(strings.TrimSuffix(scanner.Text(), "\r"))
+		if !ok {
+			continue
+		}
+
+		rule.base, rule.source, rule.line = base, source, n
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+func parseIgnoreRule(l string) (*IgnoreRule, bool) {
+
+	// エスケープされていない末尾の空白は無視する
+	for strings.HasSuffix(l, " ") && !strings.HasSuffix(l, `\ `) {
+		l = strings.TrimSuffix(l, " ")
+	}
+
+	if l == "" || strings.HasPrefix(l, "#") {
+		return nil, false
+	}
+
+	rule := &IgnoreRule{pattern: l}
+
+	p := l
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	}
+
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+
+	if strings.Contains(p, "/") {
+		rule.anchored = true
+		p = strings.TrimPrefix(p, "/")
+	}
+
+	if p == "" {
+		return nil, false
+	}
+
+	re, err := internal.CompileWildmatch(p, true, false)
+	if err != nil {
+		return nil, false
+	}
+	rule.re = re
+
+	return rule, true
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+
+	testt := []struct {
+		description string
+		excludes    string
+		rules       map[string]string
+		path        string
+		isDir       bool
+		ignored     bool
+		pattern     string
+	}{
+		{
+			description: "basename pattern matches at any level",
+			rules:       map[string]string{"": "*.log\n"},
+			path:        "a/b/debug.log",
+			ignored:     true,
+			pattern:     "*.log",
+		},
+		{
+			description: "no rules match",
+			rules:       map[string]string{"": "*.log\n"},
+			path:        "main.go",
+			ignored:     false,
+		},
+		{
+			description: "negation re-includes a file",
+			rules:       map[string]string{"": "*.log\n!keep.log\n"},
+			path:        "keep.log",
+			ignored:     false,
+			pattern:     "!keep.log",
+		},
+		{
+			description: "negation cannot re-include a file in an excluded directory",
+			rules:       map[string]string{"": "build/\n!build/keep.txt\n"},
+			path:        "build/keep.txt",
+			ignored:     true,
+			pattern:     "build/",
+		},
+		{
+			description: "anchored pattern only matches relative to the .gitignore",
+			rules:       map[string]string{"": "/bin\n"},
+			path:        "cmd/bin",
+			isDir:       true,
+			ignored:     false,
+		},
+		{
+			description: "anchored pattern matches at the root",
+			rules:       map[string]string{"": "/bin\n"},
+			path:        "bin",
+			isDir:       true,
+			ignored:     true,
+			pattern:     "/bin",
+		},
+		{
+			description: "directory only pattern does not match files",
+			rules:       map[string]string{"": "node_modules/\n"},
+			path:        "node_modules",
+			ignored:     false,
+		},
+		{
+			description: "leading double asterisk",
+			rules:       map[string]string{"": "**/testdata\n"},
+			path:        "a/b/testdata",
+			isDir:       true,
+			ignored:     true,
+			pattern:     "**/testdata",
+		},
+		{
+			description: "middle double asterisk matches zero directories",
+			rules:       map[string]string{"": "a/**/b.txt\n"},
+			path:        "a/b.txt",
+			ignored:     true,
+			pattern:     "a/**/b.txt",
+		},
+		{
+			description: "trailing double asterisk matches everything inside",
+			rules:       map[string]string{"": "vendor/**\n"},
+			path:        "vendor/x/y.go",
+			ignored:     true,
+			pattern:     "vendor/**",
+		},
+		{
+			description: "single asterisk does not cross directories",
+			rules:       map[string]string{"": "doc/*.txt\n"},
+			path:        "doc/server/arch.txt",
+			ignored:     false,
+		},
+		{
+			description: "nested .gitignore overrides the parent",
+			rules:       map[string]string{"": "*.txt\n", "docs": "!*.txt\n"},
+			path:        "docs/readme.txt",
+			ignored:     false,
+			pattern:     "!*.txt",
+		},
+		{
+			description: "nested .gitignore is relative to its directory",
+			rules:       map[string]string{"src": "/gen\n"},
+			path:        "src/gen",
+			isDir:       true,
+			ignored:     true,
+			pattern:     "/gen",
+		},
+		{
+			description: ".gitignore takes precedence over excludes",
+			excludes:    "*.tmp\n",
+			rules:       map[string]string{"": "!a.tmp\n"},
+			path:        "a.tmp",
+			ignored:     false,
+			pattern:     "!a.tmp",
+		},
+		{
+			description: "excludes apply when .gitignore does not match",
+			excludes:    "*.tmp\n",
+			path:        "b.tmp",
+			ignored:     true,
+			pattern:     "*.tmp",
+		},
+		{
+			description: "character class and escapes",
+			rules:       map[string]string{"": "\\#*\nfile[0-9].o\n"},
+			path:        "file7.o",
+			ignored:     true,
+			pattern:     "file[0-9].o",
+		},
+		{
+			description: "escaped hash",
+			rules:       map[string]string{"": "\\#notes\n"},
+			path:        "#notes",
+			ignored:     true,
+			pattern:     "\\#notes",
+		},
+	}
+
+	for _, tc := range testt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			ig := NewIgnore()
+
+			if tc.excludes != "" {
+				if err := ig.AddExcludes(".git/info/exclude", strings.NewReader(tc.excludes)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for dir, rules := range tc.rules {
+				if err := ig.AddRules(dir, dir+"/.gitignore", strings.NewReader(rules)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rule, ignored := ig.Match(tc.path, tc.isDir)
+			if ignored != tc.ignored {
+				t.Fatalf("expect ignored %t, got %t", tc.ignored, ignored)
+			}
+
+			pattern := ""
+			if rule != nil {
+				pattern = rule.Pattern()
+			}
+
+			if pattern != tc.pattern {
+				t.Fatalf("expect pattern %q, got %q", tc.pattern, pattern)
+			}
+
+		})
+
+	}
+
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"sort"
 
@@ -56,7 +57,11 @@ func IndexSource(data io.Reader) indexOption {
 
 		checksum := internal.NewChecksum()
 
+		// インデックスのファイルがなければ空のインデックスにする
 		rawHeader, _, _, count, err := parseHeader(data)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -70,21 +75,57 @@ func IndexSource(data io.Reader) indexOption {
 
 		index.Add(entries...)
 
-		digest, err := io.ReadAll(data)
+		rest, err := io.ReadAll(data)
 		if err != nil {
 			return err
 		}
 
+		if len(rest) < checksumSize {
+			return errors.New("index file smaller than expected")
+		}
+
+		extensions, digest := rest[:len(rest)-checksumSize], rest[len(rest)-checksumSize:]
+		checksum.Write(extensions)
+
 		if !checksum.Expect(internal.Unpack(digest)) {
 			return errors.New("index check sum not match")
 		}
 
-		return err
+		return skipExtensions(extensions)
 
 	}
 
 }
 
+// checksumSize インデックスの末尾のSHA-1の長さ
+const checksumSize = 20
+
+// skipExtensions エントリの後の拡張を読み飛ばす。gitと同じく、名前が大文字で始まる拡張は省略できるので無視するが、
+// それ以外の知らない拡張があれば読めないインデックスとする。
+// TREEのようにエントリから作る拡張はエントリを変えると古くなるので、書き戻すときには残さない
+func skipExtensions(data []byte) error {
+
+	for len(data) > 0 {
+
+		if len(data) < 8 {
+			return errors.New("index extension is truncated")
+		}
+
+		signature, size := data[0:4], binary.BigEndian.Uint32(data[4:8])
+		if uint64(len(data)-8) < uint64(size) {
+			return fmt.Errorf("index extension %s is truncated", signature)
+		}
+
+		if signature[0] < 'A' || 'Z' < signature[0] {
+			return fmt.Errorf("index uses %s extension, which we do not understand", signature)
+		}
+
+		data = data[8+size:]
+	}
+
+	return nil
+}
+
 func NewIndex(opts ...indexOption) (*index, error) {
 
 	i := &index{entries: map[string]*IndexEntry{}, parents: map[string]map[string]struct{}{}}
@@ -102,7 +143,7 @@ func read(reader io.Reader, count int) ([]byte, error) {
 
 	bs := make([]byte, count)
 
-	_, err := reader.Read(bs)
+	_, err := io.ReadFull(reader, bs)
 	if err != nil {
 		return nil, err
 	}
@@ -501,3 +542,7 @@ func (ie *IndexEntry) matchTimes(stat *FileStat) bool {
 func (ie *IndexEntry) Name() string {
 	return ie.filename
 }
+
+func (ie *IndexEntry) TreeEntry() object.TreeEntry {
+	return object.NewTreeEntry(ie.filename, ie.permission(), ie.oid)
+}
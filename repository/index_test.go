@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -59,3 +60,63 @@ func TestAddEntry(t *testing.T) {
 	}
 
 }
+
+func TestIndexSourceExtensions(t *testing.T) {
+
+	// extensionsをつけて、チェックサムを計算し直したインデックス
+	withExtensions := func(t *testing.T, extensions ...[]byte) []byte {
+
+		index, _ := NewIndex()
+		index.Add(NewIndexEntry("hello.txt", "5ab2f8a4323abafb10abb68657d9d39f1a775057", &FileStat{mode: 0100644}))
+
+		data, err := index.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data = append(data[:len(data)-checksumSize], bytes.Join(extensions, nil)...)
+		oid, err := internal.OID(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return append(data, internal.MustPack(oid)...)
+	}
+
+	extension := func(signature string, data string) []byte {
+		return append(append([]byte(signature), internal.UintToBytes(uint32(len(data)))...), data...)
+	}
+
+	tt := []struct {
+		description string
+		extensions  [][]byte
+		err         string
+	}{
+		{"no extensions", nil, ""},
+		{"optional extensions written by git", [][]byte{extension("TREE", "\x001 0\n"), extension("UNTR", "xyz")}, ""},
+		{"required extension", [][]byte{extension("link", "")}, "index uses link extension, which we do not understand"},
+		{"truncated extension", [][]byte{extension("TREE", "abc")[:9]}, "index extension TREE is truncated"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			index, err := NewIndex(IndexSource(bytes.NewReader(withExtensions(t, tc.extensions...))))
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("expect error %q, got %v", tc.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := index.Get("hello.txt"); !ok {
+				t.Error("expect hello.txt in the index")
+			}
+		})
+	}
+
+}
@@ -0,0 +1,206 @@
+package repository
+
+import "strings"
+
+// conflictMarkerSize gitと同じ、衝突の印の長さ
+const conflictMarkerSize = 7
+
+// mergeRegion マージした結果の一部。衝突していればoursとtheirsの両方を残す
+type mergeRegion struct {
+	lines     []string
+	conflict  bool
+	ours      []string
+	theirs    []string
+	unchanged bool
+}
+
+// Merge3 baseからoursとtheirsへの変更を行ごとにまとめる。両方が同じ場所を違うように変えていれば、
+// gitと同じく <<<<<<< oursLabel と >>>>>>> theirsLabel の間に両方を残してconflictedを返す
+func Merge3(base, ours, theirs []byte, oursLabel, theirsLabel string) (merged []byte, conflicted bool) {
+
+	regions := simplifyConflicts(refineConflicts(merge3(splitLines(base), splitLines(ours), splitLines(theirs))))
+
+	var b strings.Builder
+	for _, r := range regions {
+
+		if !r.conflict {
+			b.WriteString(strings.Join(r.lines, ""))
+			continue
+		}
+
+		conflicted = true
+		b.WriteString(strings.Repeat("<", conflictMarkerSize) + " " + oursLabel + "\n")
+		b.WriteString(withNewline(r.ours))
+		b.WriteString(strings.Repeat("=", conflictMarkerSize) + "\n")
+		b.WriteString(withNewline(r.theirs))
+		b.WriteString(strings.Repeat(">", conflictMarkerSize) + " " + theirsLabel + "\n")
+	}
+
+	return []byte(b.String()), conflicted
+}
+
+// withNewline 最後の行に改行がなければ足す
+func withNewline(lines []string) string {
+
+	s := strings.Join(lines, "")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	return s
+}
+
+// matchLines aとbを比べて、aの行ごとに一致するbの位置。一致しなければ-1
+func matchLines(a, b []string) []int {
+
+	toLines := func(ss []string) []*line {
+
+		lines := make([]*line, len(ss))
+		for i, s := range ss {
+			lines[i] = &line{i + 1, s}
+		}
+		return lines
+	}
+
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	for _, e := range diffLines(&diffOptions{algorithm: DiffMyers}, toLines(a), toLines(b)) {
+		if e.diff == Nochange {
+			matches[e.aline.number-1] = e.bline.number - 1
+		}
+	}
+
+	return matches
+}
+
+// merge3 diff3と同じく、baseの行がoursとtheirsの両方で一致する場所で区切り、その間をどちらかの変更か衝突にする
+func merge3(base, ours, theirs []string) []*mergeRegion {
+
+	mo, mt := matchLines(base, ours), matchLines(base, theirs)
+
+	regions := []*mergeRegion{}
+	i, j, k := 0, 0, 0
+	for {
+
+		// 次に両方で一致するbaseの行
+		b, o, t := i, len(ours), len(theirs)
+		for ; b < len(base); b++ {
+			if mo[b] != -1 && mt[b] != -1 {
+				o, t = mo[b], mt[b]
+				break
+			}
+		}
+
+		baseChunk, oursChunk, theirsChunk := base[i:b], ours[j:o], theirs[k:t]
+		switch {
+		case len(baseChunk) == 0 && len(oursChunk) == 0 && len(theirsChunk) == 0:
+		case equalLines(oursChunk, baseChunk):
+			regions = append(regions, &mergeRegion{lines: theirsChunk})
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			regions = append(regions, &mergeRegion{lines: oursChunk})
+		default:
+			regions = append(regions, &mergeRegion{conflict: true, ours: oursChunk, theirs: theirsChunk})
+		}
+
+		if b == len(base) {
+			break
+		}
+
+		regions = append(regions, &mergeRegion{lines: []string{base[b]}, unchanged: true})
+		i, j, k = b+1, o+1, t+1
+	}
+
+	return regions
+}
+
+func equalLines(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// refineConflicts gitのzealousと同じく、衝突したoursとtheirsを比べて、同じ行を衝突から外す
+func refineConflicts(regions []*mergeRegion) []*mergeRegion {
+
+	refined := []*mergeRegion{}
+	for _, r := range regions {
+
+		if !r.conflict || len(r.ours) == 0 || len(r.theirs) == 0 {
+			refined = append(refined, r)
+			continue
+		}
+
+		matches := matchLines(r.ours, r.theirs)
+
+		i, j := 0, 0
+		for {
+
+			o, t := i, len(r.theirs)
+			for ; o < len(r.ours); o++ {
+				if matches[o] != -1 {
+					t = matches[o]
+					break
+				}
+			}
+
+			if o > i || t > j {
+				refined = append(refined, &mergeRegion{conflict: true, ours: r.ours[i:o], theirs: r.theirs[j:t]})
+			}
+
+			if o == len(r.ours) {
+				break
+			}
+
+			refined = append(refined, &mergeRegion{lines: []string{r.ours[o]}, unchanged: true})
+			i, j = o+1, t+1
+		}
+	}
+
+	return refined
+}
+
+// simplifyConflicts gitと同じく、変更のない3行以下をはさんだ衝突をひとつにまとめる
+func simplifyConflicts(regions []*mergeRegion) []*mergeRegion {
+
+	// 続けて変更のない行をひとつにする
+	joined := []*mergeRegion{}
+	for _, r := range regions {
+
+		if n := len(joined); n > 0 && r.unchanged && joined[n-1].unchanged {
+			joined[n-1].lines = append(joined[n-1].lines, r.lines...)
+			continue
+		}
+
+		joined = append(joined, &mergeRegion{lines: append([]string{}, r.lines...), conflict: r.conflict, ours: r.ours, theirs: r.theirs, unchanged: r.unchanged})
+	}
+
+	simplified := []*mergeRegion{}
+	for n := 0; n < len(joined); n++ {
+
+		r := joined[n]
+		if last := len(simplified) - 1; last > 0 && r.conflict && simplified[last].unchanged && len(simplified[last].lines) <= 3 && simplified[last-1].conflict {
+
+			between, prev := simplified[last], simplified[last-1]
+			prev.ours = append(append(append([]string{}, prev.ours...), between.lines...), r.ours...)
+			prev.theirs = append(append(append([]string{}, prev.theirs...), between.lines...), r.theirs...)
+			simplified = simplified[:last]
+			continue
+		}
+
+		simplified = append(simplified, r)
+	}
+
+	return simplified
+}
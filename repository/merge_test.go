@@ -0,0 +1,30 @@
+package repository
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+
+	base := "1\n2\n3\n4\n5\n6\n7\n8\n9\n"
+
+	testt := []struct {
+		description  string
+		ours, theirs string
+		expect       string
+		conflicted   bool
+	}{
+		{"different places", "one\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\n5\n6\n7\n8\nnine\n", "one\n2\n3\n4\n5\n6\n7\n8\nnine\n", false},
+		{"same change", "1\n2\nthree\n4\n5\n6\n7\n8\n9\n", "1\n2\nthree\n4\n5\n6\n7\n8\n9\n", "1\n2\nthree\n4\n5\n6\n7\n8\n9\n", false},
+		{"conflict", "1\n2\nTHREE\n4\n5\n6\n7\n8\n9\n", "1\n2\nthree\n4\n5\n6\n7\n8\n9\n", "1\n2\n<<<<<<< ours\nTHREE\n=======\nthree\n>>>>>>> theirs\n4\n5\n6\n7\n8\n9\n", true},
+		{"common lines in conflict", "1\n2\nA\nx\nB\n4\n5\n6\n7\n8\n9\n", "1\n2\nC\nx\nD\n4\n5\n6\n7\n8\n9\n", "1\n2\n<<<<<<< ours\nA\nx\nB\n=======\nC\nx\nD\n>>>>>>> theirs\n4\n5\n6\n7\n8\n9\n", true},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			merged, conflicted := Merge3([]byte(base), []byte(tc.ours), []byte(tc.theirs), "ours", "theirs")
+			if string(merged) != tc.expect || conflicted != tc.conflicted {
+				t.Errorf("expect %v\n%s, got %v\n%s", tc.conflicted, tc.expect, conflicted, merged)
+			}
+		})
+	}
+}
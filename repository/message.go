@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+const commentChar = "#"
+
+// CleanupMessage gitと同じようにコミットメッセージを整える。
+// 行末の空白と前後の空行を取り除き、続く空行はひとつにまとめる。stripCommentsなら#で始まる行も取り除く
+func CleanupMessage(message string, stripComments bool) string {
+
+	lines := []string{}
+	blank := false
+
+	for _, l := range strings.Split(message, "\n") {
+
+		if stripComments && strings.HasPrefix(l, commentChar) {
+			continue
+		}
+
+		l = strings.TrimRight(l, " \t\r\v\f")
+		if l == "" {
+			blank = len(lines) != 0
+			continue
+		}
+
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// CommitTemplate エディタで開くコミットメッセージのテンプレート
+func (repo *repository) CommitTemplate(message string) string {
+
+	var b strings.Builder
+
+	b.WriteString(message)
+	if message != "" && !strings.HasSuffix(message, "\n") {
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(commentChar + " Please enter the commit message for your changes. Lines starting\n")
+	b.WriteString(commentChar + " with '" + commentChar + "' will be ignored, and an empty message aborts the commit.\n")
+	b.WriteString(commentChar + "\n")
+
+	if files, changes := repo.IndexChanges(); len(files) != 0 {
+
+		b.WriteString(commentChar + " Changes to be committed:\n")
+		for _, f := range files {
+			b.WriteString(fmt.Sprintf("%s\t%-12s%s\n", commentChar, changes[f].LongFormat()+":", f))
+		}
+		b.WriteString(commentChar + "\n")
+	}
+
+	return b.String()
+}
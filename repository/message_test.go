@@ -0,0 +1,29 @@
+package repository
+
+import "testing"
+
+func TestCleanupMessage(t *testing.T) {
+
+	testt := []struct {
+		description   string
+		message       string
+		stripComments bool
+		expect        string
+	}{
+		{"adds a trailing newline", "subject", false, "subject\n"},
+		{"strips trailing whitespace", "subject  \t\nbody \n", false, "subject\nbody\n"},
+		{"collapses blank lines", "\n\nsubject\n\n\n\nbody\n\n\n", false, "subject\n\nbody\n"},
+		{"keeps comments", "subject\n# comment\n", false, "subject\n# comment\n"},
+		{"strips comments", "subject\n# comment\n\n# comment\n", true, "subject\n"},
+		{"empty message", " \n\n# comment\n", true, ""},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+			if got := CleanupMessage(tc.message, tc.stripComments); got != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, got)
+			}
+		})
+	}
+
+}
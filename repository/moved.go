@@ -0,0 +1,269 @@
+package repository
+
+import "fmt"
+
+// ColorMoved --color-movedの、移動した行の見つけ方
+type ColorMoved string
+
+const (
+	MovedNo ColorMoved = "no"
+	// MovedPlain ほかの場所で追加か削除された行をすべて移動した行にする
+	MovedPlain ColorMoved = "plain"
+	// MovedBlocks 英数字が20文字以上の続いた行だけを移動した行にする
+	MovedBlocks ColorMoved = "blocks"
+	// MovedZebra blocksで、隣り合うブロックを交互に分ける
+	MovedZebra ColorMoved = "zebra"
+	// MovedDimmedZebra zebraで、ブロックの境目以外の行を目立たなくする
+	MovedDimmedZebra ColorMoved = "dimmed-zebra"
+)
+
+// ParseColorMoved --color-movedとdiff.colorMovedの値。defaultはzebra
+func ParseColorMoved(s string) (ColorMoved, error) {
+
+	switch s {
+	case "no", "false":
+		return MovedNo, nil
+	case "default", "true", "zebra":
+		return MovedZebra, nil
+	case "plain":
+		return MovedPlain, nil
+	case "blocks":
+		return MovedBlocks, nil
+	case "dimmed-zebra", "dimmed_zebra":
+		return MovedDimmedZebra, nil
+	}
+
+	return "", fmt.Errorf("color moved setting must be one of 'no', 'default', 'blocks', 'zebra', 'dimmed-zebra', 'plain': %s", s)
+}
+
+// movedBlockChars ブロックを移動した行にする英数字の数。gitと同じ
+const movedBlockChars = 20
+
+type movedFlag int
+
+const (
+	movedLine movedFlag = 1 << iota
+	// movedAlternative zebraで、前のブロックと隣り合うブロック
+	movedAlternative
+	// movedDimmed dimmed-zebraで、ブロックの境目でない行
+	movedDimmed
+)
+
+// Moved ほかの場所に移動した行か。alternativeは隣り合うブロックの片方、dimmedはブロックの境目でない行
+func (e *edit) Moved() (moved, alternative, dimmed bool) {
+	return e.moved&movedLine != 0, e.moved&movedAlternative != 0, e.moved&movedDimmed != 0
+}
+
+// MarkMoved diffsのハンクで、削除した行がほかの場所に追加されていれば、gitと同じく移動した行の印をつける
+func MarkMoved(diffs []FileDiff, mode ColorMoved) {
+
+	if mode == MovedNo {
+		return
+	}
+
+	// 追加と削除以外はnil。ハンクの境目も移動したブロックを区切る
+	lines := []*edit{}
+	for _, d := range diffs {
+		for _, h := range d.Hunks() {
+
+			lines = append(lines, nil)
+			for _, e := range h.edits {
+
+				if e.diff == Nochange {
+					lines = append(lines, nil)
+					continue
+				}
+
+				lines = append(lines, e)
+			}
+		}
+	}
+
+	m := &movedMarker{mode: mode, lines: lines}
+	m.mark()
+
+	if mode == MovedDimmedZebra {
+		m.dim()
+	}
+}
+
+type movedMarker struct {
+	mode  ColorMoved
+	lines []*edit
+	// next 同じ向きで続く次の行。なければ-1
+	next []int
+	// index 追加か削除ごとの、行の内容の位置
+	index map[symbol]map[string][]int
+}
+
+func (m *movedMarker) mark() {
+
+	m.next = make([]int, len(m.lines))
+	m.index = map[symbol]map[string][]int{Deletion: {}, Insertion: {}}
+	for n, l := range m.lines {
+
+		m.next[n] = -1
+		if l == nil {
+			continue
+		}
+
+		if n > 0 && m.lines[n-1] != nil && m.lines[n-1].diff == l.diff {
+			m.next[n-1] = n
+		}
+
+		m.index[l.diff][l.line.text] = append(m.index[l.diff][l.line.text], n)
+	}
+
+	// candidates 今のブロックの元になりうる反対側の行
+	candidates, blockLength, flipped, movedSymbol := []int{}, 0, false, symbol("")
+	for n := 0; n < len(m.lines); n++ {
+
+		l := m.lines[n]
+
+		var matches []int
+		if l != nil {
+			matches = m.index[opposite(l.diff)][l.line.text]
+		} else {
+			flipped = false
+		}
+		match := len(matches) > 0
+
+		if len(candidates) > 0 && (!match || l.diff != movedSymbol) {
+
+			// 短すぎるブロックだったら、ブロックの2行目から探しなおす
+			if !m.adjustLastBlock(n, blockLength) && blockLength > 1 {
+				match = false
+				n -= blockLength
+			}
+
+			candidates, blockLength, flipped = nil, 0, false
+		}
+
+		if !match {
+			movedSymbol = ""
+			continue
+		}
+
+		if m.mode == MovedPlain {
+			l.moved |= movedLine
+			continue
+		}
+
+		advanced := []int{}
+		for _, c := range candidates {
+			if next := m.next[c]; next != -1 && m.lines[next].line.text == l.line.text {
+				advanced = append(advanced, next)
+			}
+		}
+		candidates = advanced
+
+		if len(candidates) == 0 {
+
+			contiguous := m.adjustLastBlock(n, blockLength)
+			if !contiguous && blockLength > 1 {
+				n -= blockLength
+			} else {
+				candidates = append(candidates, matches...)
+			}
+
+			if contiguous && len(candidates) > 0 && movedSymbol == l.diff {
+				flipped = !flipped
+			} else {
+				flipped = false
+			}
+
+			movedSymbol = ""
+			if len(candidates) > 0 {
+				movedSymbol = l.diff
+			}
+
+			blockLength = 0
+		}
+
+		if len(candidates) > 0 {
+
+			blockLength++
+			l.moved |= movedLine
+			if flipped && m.mode != MovedBlocks {
+				l.moved |= movedAlternative
+			}
+		}
+	}
+
+	m.adjustLastBlock(len(m.lines), blockLength)
+}
+
+// adjustLastBlock nの直前のブロックの英数字が足りなければ印を消す。ブロックを残したか
+func (m *movedMarker) adjustLastBlock(n, blockLength int) bool {
+
+	if m.mode == MovedPlain {
+		return blockLength != 0
+	}
+
+	chars := 0
+	for i := 1; i <= blockLength; i++ {
+
+		text := m.lines[n-i].line.text
+		for j := 0; j < len(text); j++ {
+			if isAlpha(text[j]) || ('0' <= text[j] && text[j] <= '9') {
+				chars++
+			}
+		}
+
+		if chars >= movedBlockChars {
+			return true
+		}
+	}
+
+	for i := 1; i <= blockLength; i++ {
+		m.lines[n-i].moved = 0
+	}
+
+	return false
+}
+
+// dim gitと同じく、隣り合う別のブロックとの境目でない移動した行を目立たなくする
+func (m *movedMarker) dim() {
+
+	zebra := movedLine | movedAlternative
+	at := func(n int) *edit {
+		if n < 0 || n >= len(m.lines) {
+			return nil
+		}
+		return m.lines[n]
+	}
+
+	for n, l := range m.lines {
+
+		if l == nil || l.moved&movedLine == 0 {
+			continue
+		}
+
+		prev, next := at(n-1), at(n+1)
+
+		// ブロックの中
+		if prev != nil && prev.moved&zebra == l.moved&zebra && next != nil && next.moved&zebra == l.moved&zebra {
+			l.moved |= movedDimmed
+			continue
+		}
+
+		// 別のブロックとの境目
+		if prev != nil && prev.moved&movedLine != 0 && prev.moved&movedAlternative != l.moved&movedAlternative {
+			continue
+		}
+		if next != nil && next.moved&movedLine != 0 && next.moved&movedAlternative != l.moved&movedAlternative {
+			continue
+		}
+
+		l.moved |= movedDimmed
+	}
+}
+
+func opposite(s symbol) symbol {
+
+	if s == Deletion {
+		return Insertion
+	}
+
+	return Deletion
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMarkMoved(t *testing.T) {
+
+	block := func(name string, n int) []string {
+
+		lines := []string{}
+		for i := 0; i < n; i++ {
+			lines = append(lines, fmt.Sprintf("block %s line %d with enough characters", name, i))
+		}
+		return lines
+	}
+
+	anchors := []string{"anchor 0", "anchor 1", "anchor 2", "anchor 3", "anchor 4", "anchor 5", "anchor 6", "anchor 7"}
+	a, b := block("A", 3), block("B", 3)
+
+	old := strings.Join(append(append(append([]string{}, a...), b...), anchors...), "\n") + "\n"
+	new := strings.Join(append(append(append(append([]string{}, anchors...), b...), a...), "tail"), "\n") + "\n"
+
+	// gitと同じく、続けて移動したブロックを交互に分け、dimmed-zebraではブロックの境目以外を薄くする
+	testt := []struct {
+		mode   ColorMoved
+		expect string
+	}{
+		{MovedPlain, "-m -m -m -m -m -m +m +m +m +m +m +m +"},
+		{MovedBlocks, "-m -m -m -m -m -m +m +m +m +m +m +m +"},
+		{MovedZebra, "-m -m -m -ma -ma -ma +m +m +m +ma +ma +ma +"},
+		{MovedDimmedZebra, "-md -md -m -ma -mad -mad +md +md +m +ma +mad +mad +"},
+		{MovedNo, "- - - - - - + + + + + + +"},
+	}
+
+	for _, tc := range testt {
+		t.Run(string(tc.mode), func(t *testing.T) {
+
+			d := newFileDiff("file", "a", "100644", []byte(old), "b", "100644", []byte(new), false)
+			MarkMoved([]FileDiff{d}, tc.mode)
+
+			marks := []string{}
+			for _, h := range d.Hunks() {
+				for _, e := range h.edits.filter(func(e *edit) bool { return e.diff != Nochange }) {
+
+					moved, alternative, dimmed := e.Moved()
+					mark := e.diff.String()
+					if moved {
+						mark += "m"
+					}
+					if alternative {
+						mark += "a"
+					}
+					if dimmed {
+						mark += "d"
+					}
+					marks = append(marks, mark)
+				}
+			}
+
+			if got := strings.Join(marks, " "); got != tc.expect {
+				t.Errorf("expect %s, got %s", tc.expect, got)
+			}
+		})
+	}
+
+	if _, err := ParseColorMoved("stripes"); err == nil {
+		t.Error("expect an error for an unknown mode")
+	}
+
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"path"
+	"sort"
+
+	"github.com/mizuho-u/got/internal"
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// NoIndexFile --no-indexで比べるリポジトリの外のファイル
+type NoIndexFile struct {
+	Mode object.Permission
+	Data []byte
+}
+
+// DiffNoIndex aPathとbPathのファイルを比べる。ファイルは空のパス、ディレクトリの中のファイルはディレクトリからの相対パスで、同じパスのものを組にする
+func DiffNoIndex(aPath string, a map[string]*NoIndexFile, bPath string, b map[string]*NoIndexFile) ([]FileDiff, error) {
+
+	paths := map[string]struct{}{}
+	for p := range a {
+		paths[p] = struct{}{}
+	}
+	for p := range b {
+		paths[p] = struct{}{}
+	}
+
+	names := internal.Keys(paths)
+	sort.Strings(names)
+
+	diffs := []FileDiff{}
+	for _, name := range names {
+
+		aName, bName := path.Join(aPath, name), path.Join(bPath, name)
+
+		aOID, aMode, aData := nullOID, "", []byte(nullContents)
+		if f, ok := a[name]; ok {
+
+			blob, err := object.NewBlob(aName, f.Data)
+			if err != nil {
+				return nil, err
+			}
+			aOID, aMode, aData = blob.OID(), string(f.Mode), f.Data
+		}
+
+		bOID, bMode, bData := nullOID, "", []byte(nullContents)
+		if f, ok := b[name]; ok {
+
+			blob, err := object.NewBlob(bName, f.Data)
+			if err != nil {
+				return nil, err
+			}
+			bOID, bMode, bData = blob.OID(), string(f.Mode), f.Data
+		}
+
+		switch {
+		case aOID == bOID && aMode == bMode:
+			continue
+		case aOID == nullOID:
+			diffs = append(diffs, &diffAdded{AOID: aOID, APath: bName, AData: aData, BOID: bOID, BMode: bMode, BPath: bName, BData: bData, noIndex: true})
+		case bOID == nullOID:
+			diffs = append(diffs, &diffDeleted{AOID: aOID, AMode: aMode, APath: aName, AData: aData, BOID: bOID, BPath: aName, BData: bData, noIndex: true})
+		default:
+			diffs = append(diffs, &diffNoIndex{diffModified{AOID: aOID, AMode: aMode, APath: aName, AData: aData, BOID: bOID, BMode: bMode, BPath: bName, BData: bData, workspace: true}})
+		}
+	}
+
+	return diffs, nil
+}
+
+// diffNoIndex 別々のパスのファイル同士の変更
+type diffNoIndex struct {
+	diffModified
+}
+
+func (diff *diffNoIndex) Path() string {
+	return diff.BPath
+}
+
+func (diff *diffNoIndex) OldPath() string {
+	return diff.APath
+}
+
+// Raw gitと同じく、どちらのIDも出さない
+func (diff *diffNoIndex) Raw() string {
+	return raw(diff.AMode, diff.BMode, nullOID, nullOID, diff.Status())
+}
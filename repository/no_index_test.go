@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+func TestDiffNoIndex(t *testing.T) {
+
+	file := func(mode object.Permission, data string) *NoIndexFile {
+		return &NoIndexFile{Mode: mode, Data: []byte(data)}
+	}
+
+	a := map[string]*NoIndexFile{
+		"same":  file(object.RegularFile, "x\n"),
+		"sub/m": file(object.RegularFile, "1\n2\n"),
+		"old":   file(object.RegularFile, "gone\n"),
+		"x":     file(object.RegularFile, "p\n"),
+	}
+	b := map[string]*NoIndexFile{
+		"same":  file(object.RegularFile, "x\n"),
+		"sub/m": file(object.RegularFile, "1\n22\n"),
+		"new":   file(object.RegularFile, "new\n"),
+		"x":     file(object.ExecutableFile, "p\n"),
+	}
+
+	diffs, err := DiffNoIndex("d1", a, "d2", b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同じ相対パスを組にして、片方にしかないファイルは追加か削除にする
+	got := []string{}
+	for _, d := range diffs {
+		got = append(got, d.NameStatus()+" "+d.OldPath()+" "+d.Path())
+	}
+
+	expect := "A /dev/null d2/new,D d1/old /dev/null,M d1/sub/m d2/sub/m,M d1/x d2/x"
+	if strings.Join(got, ",") != expect {
+		t.Errorf("expect %s, got %s", expect, strings.Join(got, ","))
+	}
+
+	if l := diffs[2].PathLine(); l != "diff --git a/d1/sub/m b/d2/sub/m\n" {
+		t.Errorf("unexpected path line %q", l)
+	}
+
+	// ファイル同士は空のパスで組にする
+	diffs, err = DiffNoIndex("f1", map[string]*NoIndexFile{"": file(object.RegularFile, "a\n")}, "f2", map[string]*NoIndexFile{"": file(object.RegularFile, "b\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 || diffs[0].OldPath() != "f1" || diffs[0].Path() != "f2" {
+		t.Errorf("expect f1 and f2 to be compared, got %v", diffs)
+	}
+
+}
@@ -12,22 +12,33 @@ type author struct {
 	name  string
 	email string
 	now   time.Time
+	// raw パースした元の文字列。書き出すときはそのまま使う
+	raw string
 }
 
+// Author コミットのauthorとcommitterの行
 type Author interface {
 	String() string
+	Name() string
+	Email() string
+	When() time.Time
 }
 
 func NewAuthor(name, email string, now time.Time) *author {
-	return &author{name, email, now}
+	return &author{name: name, email: email, now: now}
 }
 
-func authorFromString(s string) (*author, error) {
+// ParseAuthor "Name <email> unixtime +0900" の形式
+func ParseAuthor(s string) (Author, error) {
+	return authorFromString(s)
+}
 
-	re := regexp.MustCompile(`^(.*) ?<(.*)> (\d+) (.+)$`)
-	match := re.FindStringSubmatch(s)
+var authorLine = regexp.MustCompile(`^(.*?) ?<(.*)> (\d+) ([+-])(\d{2})(\d{2})$`)
+
+func authorFromString(s string) (*author, error) {
 
-	if len(match) != 5 {
+	match := authorLine.FindStringSubmatch(s)
+	if match == nil {
 		return nil, errors.New("invalid author format")
 	}
 
@@ -36,24 +47,43 @@ func authorFromString(s string) (*author, error) {
 		return nil, err
 	}
 
-	now := time.Unix(unixtime, 0)
+	// time.Parseはオフセットがローカルのタイムゾーンと同じだとLocalを使い、夏時間でずれるので固定のゾーンにする
+	hours, _ := strconv.Atoi(match[5])
+	minutes, _ := strconv.Atoi(match[6])
 
-	offset, err := time.Parse("-0700", match[4])
-	if err != nil {
-		return nil, err
+	offset := hours*60*60 + minutes*60
+	if match[4] == "-" {
+		offset = -offset
 	}
 
 	return &author{
 		name:  match[1],
 		email: match[2],
-		now:   now.In(offset.Location()),
+		now:   time.Unix(unixtime, 0).In(time.FixedZone("", offset)),
+		raw:   s,
 	}, nil
 
 }
 
 func (a *author) String() string {
 
+	if a.raw != "" {
+		return a.raw
+	}
+
 	t := a.now.Format(time.RFC822Z)
 	offset := t[len(t)-5:]
 	return fmt.Sprintf("%s <%s> %d %s", a.name, a.email, a.now.Unix(), offset)
 }
+
+func (a *author) Name() string {
+	return a.name
+}
+
+func (a *author) Email() string {
+	return a.email
+}
+
+func (a *author) When() time.Time {
+	return a.now
+}
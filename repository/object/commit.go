@@ -2,8 +2,9 @@ package object
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-
+	"slices"
 	"strings"
 )
 
@@ -11,26 +12,52 @@ type Commit interface {
 	Object
 	Tree() string
 	Parent() string
+	Parents() []string
+	Message() string
+	Author() Author
+	Committer() Author
+	Headers() []Header
+	Header(key string) (string, bool)
+	Signature() (signature string, payload []byte, ok bool)
+	Trailers() []Trailer
 	TitleLine() string
 }
 
+// Header コミットのヘッダ。複数行の値は継続行を改行でつないだもの
+type Header struct {
+	Key   string
+	Value string
+}
+
 type commit struct {
-	parent, tree, message string
-	author                *author
+	tree, message     string
+	parents           []string
+	author, committer Author
+	headers           []Header
 	*object
 }
 
-func NewCommit(parent, tree string, author *author, message string) (*commit, error) {
+// NewCommit authorは変更を書いた人、committerはコミットを作った人。parentsは並び順のまま書き出す
+func NewCommit(parents []string, tree string, author, committer Author, message string) (*commit, error) {
 
-	content := []byte{}
+	headers := []Header{{"tree", tree}}
+	for _, parent := range parents {
+		headers = append(headers, Header{"parent", parent})
+	}
+	headers = append(headers, Header{"author", author.String()}, Header{"committer", committer.String()})
 
-	content = append(content, []byte("tree "+tree+"\n")...)
-	if parent != "" {
-		content = append(content, []byte("parent "+parent+"\n")...)
+	return NewCommitWithHeaders(headers, message)
+}
+
+// NewCommitWithHeaders ヘッダを並び順のまま書き出す。ParseCommitしたヘッダを渡せば同じコミットになる
+func NewCommitWithHeaders(headers []Header, message string) (*commit, error) {
+
+	content := []byte{}
+	for _, h := range headers {
+		content = append(content, []byte(h.Key+" "+strings.ReplaceAll(h.Value, "\n", "\n "))...)
+		content = append(content, '\n')
 	}
-	content = append(content, []byte("author "+author.String()+"\n")...)
-	content = append(content, []byte("committer "+author.String()+"\n")...)
-	content = append(content, []byte("\n")...)
+	content = append(content, '\n')
 	content = append(content, []byte(message)...)
 
 	object, err := newObject(content, ClassCommit)
@@ -38,7 +65,25 @@ func NewCommit(parent, tree string, author *author, message string) (*commit, er
 		return nil, err
 	}
 
-	return &commit{parent, tree, message, author, object}, nil
+	c := &commit{message: message, headers: headers, object: object}
+	if err := c.readHeaders(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// SignCommit コミットの内容にsignで署名して、gpgsigヘッダを足したコミットを作る
+func SignCommit(c *commit, sign func(payload []byte) (string, error)) (*commit, error) {
+
+	signature, err := sign(c.Data())
+	if err != nil {
+		return nil, err
+	}
+
+	headers := append(slices.Clone(c.headers), Header{"gpgsig", strings.TrimSuffix(signature, "\n")})
+
+	return NewCommitWithHeaders(headers, c.message)
 }
 
 func EmptyCommit() Commit {
@@ -53,66 +98,149 @@ func ParseCommit(obj Object) (Commit, error) {
 		return nil, fmt.Errorf("object is not commit: %s", obj.Class())
 	}
 
-	c := &commit{object: &object{id: obj.OID()}}
+	c := &commit{object: &object{id: obj.OID(), class: ClassCommit, raw: obj.Raw(), data: obj.Data()}}
 
 	buf := bytes.NewBuffer(obj.Data())
 
-	str, err := buf.ReadString(0x0A)
-	if err != nil {
-		return nil, err
-	}
-	c.tree = strings.TrimSuffix(strings.TrimPrefix(str, "tree "), "\n")
+	for {
 
-	str, err = buf.ReadString(0x0A)
-	if err != nil {
-		return nil, err
-	}
+		line, err := buf.ReadString('\n')
+		if err != nil {
+			return nil, errors.New("commit has no message separator")
+		}
+		line = strings.TrimSuffix(line, "\n")
 
-	if strings.HasPrefix(str, "parent") {
+		if line == "" {
+			break
+		}
 
-		c.parent = strings.TrimRight(strings.TrimLeft(str, "parent "), "\n")
+		// 空白で始まる行は前のヘッダの続き
+		if strings.HasPrefix(line, " ") {
 
-		str, err = buf.ReadString(0x0A)
-		if err != nil {
-			return nil, err
+			if len(c.headers) == 0 {
+				return nil, errors.New("commit starts with a continuation line")
+			}
+			c.headers[len(c.headers)-1].Value += "\n" + line[1:]
+			continue
 		}
-	}
 
-	author, err := authorFromString(strings.TrimRight(strings.TrimLeft(str, "author "), "\n"))
-	if err != nil {
-		return nil, err
+		key, value, _ := strings.Cut(line, " ")
+		c.headers = append(c.headers, Header{key, value})
 	}
-	c.author = author
 
-	str, err = buf.ReadString(0x0A)
-	if err != nil {
-		return nil, err
-	}
-	committer, err := authorFromString(strings.TrimSuffix(strings.TrimPrefix(str, "committer "), "\n"))
-	if err != nil {
-		return nil, err
-	}
-	c.author = committer
+	c.message = buf.String()
 
-	_, err = buf.ReadByte()
-	if err != nil {
+	if err := c.readHeaders(); err != nil {
 		return nil, err
 	}
 
-	c.message = buf.String()
-
 	return c, nil
 
 }
 
+// readHeaders よく使うヘッダを取り出しておく
+func (c *commit) readHeaders() (err error) {
+
+	for _, h := range c.headers {
+
+		switch h.Key {
+		case "tree":
+			c.tree = h.Value
+		case "parent":
+			c.parents = append(c.parents, h.Value)
+		case "author":
+			c.author, err = authorFromString(h.Value)
+		case "committer":
+			c.committer, err = authorFromString(h.Value)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *commit) Tree() string {
 	return c.tree
 }
 
+// Parent 最初の親
 func (c *commit) Parent() string {
-	return c.parent
+
+	if len(c.parents) == 0 {
+		return ""
+	}
+
+	return c.parents[0]
+}
+
+func (c *commit) Parents() []string {
+	return c.parents
+}
+
+func (c *commit) Message() string {
+	return c.message
+}
+
+func (c *commit) Author() Author {
+	return c.author
+}
+
+func (c *commit) Committer() Author {
+	return c.committer
+}
+
+func (c *commit) Headers() []Header {
+	return c.headers
+}
+
+// Header keyの最初のヘッダの値
+func (c *commit) Header(key string) (string, bool) {
+
+	for _, h := range c.headers {
+		if h.Key == key {
+			return h.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Signature gpgsigヘッダの署名と、署名したときの内容。署名のヘッダを除いて組み立て直す
+func (c *commit) Signature() (signature string, payload []byte, ok bool) {
+
+	headers := []Header{}
+	for _, h := range c.headers {
+
+		switch h.Key {
+		case "gpgsig", "gpgsig-sha256":
+			if !ok {
+				signature, ok = h.Value+"\n", true
+			}
+		default:
+			headers = append(headers, h)
+		}
+	}
+
+	if !ok {
+		return "", nil, false
+	}
+
+	unsigned, err := NewCommitWithHeaders(headers, c.message)
+	if err != nil {
+		return "", nil, false
+	}
+
+	return signature, unsigned.Data(), true
+}
+
+// Trailers メッセージの最後の段落のトレーラ
+func (c *commit) Trailers() []Trailer {
+	return ParseTrailers(c.message)
 }
 
 func (c *commit) TitleLine() string {
-	return fmt.Sprintf("%s - %s", c.author.now.Format("2006-01-02"), strings.Split(c.message, "\n")[0])
+	return fmt.Sprintf("%s - %s", c.author.When().Format("2006-01-02"), strings.Split(c.message, "\n")[0])
 }
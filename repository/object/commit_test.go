@@ -1,6 +1,7 @@
 package object_test
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -16,7 +17,7 @@ func TestCreateCommitObject(t *testing.T) {
 	now := time.Unix(1511204319, 0).UTC()
 
 	author := object.NewAuthor(name, email, now)
-	commit, err := object.NewCommit("", tree, author, "First commit.\n")
+	commit, err := object.NewCommit(nil, tree, author, author, "First commit.\n")
 	if err != nil {
 		t.Fatal("failed to create commit. ", err)
 	}
@@ -26,3 +27,118 @@ func TestCreateCommitObject(t *testing.T) {
 	}
 
 }
+
+func TestParseCommitAuthorAndCommitter(t *testing.T) {
+
+	author := object.NewAuthor("A U Thor", "author@example.com", time.Unix(1600000000, 0).In(time.FixedZone("", 2*60*60)))
+	committer := object.NewAuthor("Committer", "committer@example.com", time.Unix(1697247000, 0).In(time.FixedZone("", 9*60*60)))
+
+	c, err := object.NewCommit(nil, "88e38705fdbd3608cddbe904b67c731f3234c45b", author, committer, "message\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := object.ParseCommit(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Author().String() != author.String() {
+		t.Errorf("expect author %s, got %s", author, parsed.Author())
+	}
+
+	if parsed.Committer().String() != committer.String() {
+		t.Errorf("expect committer %s, got %s", committer, parsed.Committer())
+	}
+
+}
+
+func TestParseCommitRoundTrip(t *testing.T) {
+
+	data := `tree 88e38705fdbd3608cddbe904b67c731f3234c45b
+parent 2fb7e6b97a594fa7f9ccb927849e95c7c70e39f5
+parent a5969546fc417f4b362e5290ad8ee49b044bfc0e
+author A U Thor <author@example.com> 1600000000 -0000
+committer Committer<committer@example.com> 1697247000 +0530
+encoding ISO-8859-1
+mergetag object a5969546fc417f4b362e5290ad8ee49b044bfc0e
+ type commit
+ tag v1.0
+ 
+ release
+gpgsig -----BEGIN PGP SIGNATURE-----
+ 
+ iQEzBAABCAAdFiEE
+ =abcd
+ -----END PGP SIGNATURE-----
+
+Merge branch 'topic'
+
+   trailing spaces are kept   
+`
+
+	obj, err := object.ParseObject([]byte(fmt.Sprintf("commit %d\x00%s", len(data), data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := object.ParseCommit(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(c.Data()) != data {
+		t.Fatalf("data changed. got %q", c.Data())
+	}
+
+	if parents := c.Parents(); len(parents) != 2 || c.Parent() != parents[0] {
+		t.Errorf("unexpected parents %v", parents)
+	}
+
+	if encoding, ok := c.Header("encoding"); !ok || encoding != "ISO-8859-1" {
+		t.Errorf("unexpected encoding %s", encoding)
+	}
+
+	if tag, _ := c.Header("mergetag"); tag != "object a5969546fc417f4b362e5290ad8ee49b044bfc0e\ntype commit\ntag v1.0\n\nrelease" {
+		t.Errorf("unexpected mergetag %q", tag)
+	}
+
+	if zone := c.Committer().When().Format("-0700"); zone != "+0530" {
+		t.Errorf("unexpected committer zone %s", zone)
+	}
+
+	rebuilt, err := object.NewCommitWithHeaders(c.Headers(), c.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rebuilt.OID() != obj.OID() {
+		t.Fatalf("oid changed. expect %s got %s\n%s", obj.OID(), rebuilt.OID(), rebuilt.Data())
+	}
+
+}
+
+func TestParseCommitZoneIsFixed(t *testing.T) {
+
+	// 夏時間のあるローカルタイムゾーンでもオフセットはそのまま
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	local := time.Local
+	time.Local = ny
+	defer func() { time.Local = local }()
+
+	author := object.NewAuthor("A U Thor", "author@example.com", time.Unix(1697247000, 0).In(time.FixedZone("", -5*60*60)))
+
+	c, err := object.NewCommit(nil, "88e38705fdbd3608cddbe904b67c731f3234c45b", author, author, "message\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if zone := c.Author().When().Format("-0700"); zone != "-0500" {
+		t.Fatalf("expect -0500, got %s", zone)
+	}
+
+}
@@ -14,6 +14,7 @@ const (
 	ClassBlob   class = "blob"
 	ClassTree   class = "tree"
 	ClassCommit class = "commit"
+	ClassTag    class = "tag"
 )
 
 type Object interface {
@@ -0,0 +1,73 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tag 注釈付きタグ。gitで作ったタグを読むだけ
+type Tag interface {
+	Object
+	// Target タグが指すオブジェクト
+	Target() string
+	TargetClass() class
+	Name() string
+	Message() string
+}
+
+type tag struct {
+	target, name, message string
+	targetClass           class
+	*object
+}
+
+func ParseTag(obj Object) (Tag, error) {
+
+	if obj.Class() != ClassTag {
+		return nil, fmt.Errorf("object is not tag: %s", obj.Class())
+	}
+
+	t := &tag{object: &object{id: obj.OID(), class: ClassTag, raw: obj.Raw(), data: obj.Data()}}
+
+	headers, message, ok := strings.Cut(string(obj.Data()), "\n\n")
+	if !ok {
+		headers = strings.TrimSuffix(headers, "\n")
+	}
+	t.message = message
+
+	for _, line := range strings.Split(headers, "\n") {
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "object":
+			t.target = value
+		case "type":
+			t.targetClass = class(value)
+		case "tag":
+			t.name = value
+		}
+	}
+
+	if t.target == "" {
+		return nil, errors.New("tag has no object header")
+	}
+
+	return t, nil
+}
+
+func (t *tag) Target() string {
+	return t.target
+}
+
+func (t *tag) TargetClass() class {
+	return t.targetClass
+}
+
+func (t *tag) Name() string {
+	return t.name
+}
+
+func (t *tag) Message() string {
+	return t.message
+}
@@ -0,0 +1,374 @@
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Trailer コミットメッセージの最後の段落にある "Token: value"
+type Trailer struct {
+	Token string
+	Value string
+}
+
+func (t Trailer) String() string {
+	return t.Token + ": " + t.Value
+}
+
+type TrailerWhere string
+
+const (
+	TrailerEnd    TrailerWhere = "end"
+	TrailerStart  TrailerWhere = "start"
+	TrailerAfter  TrailerWhere = "after"
+	TrailerBefore TrailerWhere = "before"
+)
+
+type TrailerIfExists string
+
+const (
+	TrailerAddIfDifferentNeighbor TrailerIfExists = "addIfDifferentNeighbor"
+	TrailerAddIfDifferent         TrailerIfExists = "addIfDifferent"
+	TrailerAdd                    TrailerIfExists = "add"
+	TrailerReplace                TrailerIfExists = "replace"
+	TrailerDoNothing              TrailerIfExists = "doNothing"
+)
+
+type TrailerIfMissing string
+
+const (
+	TrailerAddIfMissing       TrailerIfMissing = "add"
+	TrailerDoNothingIfMissing TrailerIfMissing = "doNothing"
+)
+
+// TrailerOptions git interpret-trailersの--where、--if-exists、--if-missing
+type TrailerOptions struct {
+	Where     TrailerWhere
+	IfExists  TrailerIfExists
+	IfMissing TrailerIfMissing
+}
+
+// NewTrailerOptions 空ならgitのデフォルトにする
+func NewTrailerOptions(where, ifExists, ifMissing string) (TrailerOptions, error) {
+
+	opts := TrailerOptions{TrailerEnd, TrailerAddIfDifferentNeighbor, TrailerAddIfMissing}
+
+	switch w := TrailerWhere(strings.ToLower(where)); w {
+	case "":
+	case TrailerEnd, TrailerStart, TrailerAfter, TrailerBefore:
+		opts.Where = w
+	default:
+		return opts, fmt.Errorf("unknown value '%s' for key 'where'", where)
+	}
+
+	switch {
+	case ifExists == "":
+	case strings.EqualFold(ifExists, string(TrailerAddIfDifferentNeighbor)):
+	case strings.EqualFold(ifExists, string(TrailerAddIfDifferent)):
+		opts.IfExists = TrailerAddIfDifferent
+	case strings.EqualFold(ifExists, string(TrailerAdd)):
+		opts.IfExists = TrailerAdd
+	case strings.EqualFold(ifExists, string(TrailerReplace)):
+		opts.IfExists = TrailerReplace
+	case strings.EqualFold(ifExists, string(TrailerDoNothing)):
+		opts.IfExists = TrailerDoNothing
+	default:
+		return opts, fmt.Errorf("unknown value '%s' for key 'ifexists'", ifExists)
+	}
+
+	switch {
+	case ifMissing == "":
+	case strings.EqualFold(ifMissing, string(TrailerAddIfMissing)):
+	case strings.EqualFold(ifMissing, string(TrailerDoNothingIfMissing)):
+		opts.IfMissing = TrailerDoNothingIfMissing
+	default:
+		return opts, fmt.Errorf("unknown value '%s' for key 'ifmissing'", ifMissing)
+	}
+
+	return opts, nil
+}
+
+var (
+	trailerLine = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*)\s*:\s*(.*?)\s*$`)
+	// gitが付けるトレーラ。これがあれば段落の1/4がトレーラでもトレーラの段落とみなす
+	gitTrailerPrefixes = []string{"Signed-off-by: ", "(cherry picked from commit "}
+)
+
+// ParseTrailer --trailerの "token=value" か "token: value"
+func ParseTrailer(s string) (Trailer, error) {
+
+	i := strings.IndexAny(s, "=:")
+	if i == -1 {
+		i = len(s)
+	}
+
+	token := strings.TrimSpace(s[:i])
+	if !trailerLine.MatchString(token + ":") {
+		return Trailer{}, fmt.Errorf("invalid trailer '%s'", s)
+	}
+
+	value := ""
+	if i < len(s) {
+		value = strings.TrimSpace(s[i+1:])
+	}
+
+	return Trailer{token, value}, nil
+}
+
+// ParseTrailers 継続行は空白ひとつでつなぐ
+func ParseTrailers(message string) []Trailer {
+
+	trailers := []Trailer{}
+
+	m := splitTrailers(message)
+	for _, item := range m.block {
+		if item.token != "" {
+			trailers = append(trailers, Trailer{item.token, unfold(item.value)})
+		}
+	}
+
+	return trailers
+}
+
+// AddTrailers optsにしたがってtrailersを順に足す。トレーラの段落がなければ空行を挟んで作る
+func AddTrailers(message string, trailers []Trailer, opts TrailerOptions) string {
+
+	m := splitTrailers(message)
+
+	for _, t := range trailers {
+		m.block = addTrailer(m.block, trailerItem{t.Token, t.Value}, opts)
+	}
+
+	return m.String()
+}
+
+// trailerItem トレーラの段落の1行。tokenが空ならトレーラではない行
+type trailerItem struct {
+	token, value string
+}
+
+func (item trailerItem) String() string {
+
+	if item.token == "" {
+		return item.value
+	}
+
+	return Trailer{item.token, item.value}.String()
+}
+
+func (item trailerItem) sameToken(other trailerItem) bool {
+	return item.token != "" && strings.EqualFold(item.token, other.token)
+}
+
+type trailerMessage struct {
+	before, after []string
+	block         []trailerItem
+	// found トレーラの段落があったか。なければ空行を挟んで足す
+	found bool
+	// noNewline メッセージが改行で終わっていない
+	noNewline bool
+}
+
+// String gitと同じく、トレーラを足さなくてもトレーラの段落がなければ空行を足す
+func (m *trailerMessage) String() string {
+
+	lines := append([]string{}, m.before...)
+	if !m.found && !(m.noNewline && len(m.after) == 0) {
+		lines = append(lines, "")
+	}
+
+	for _, item := range m.block {
+		lines = append(lines, item.String())
+	}
+	lines = append(lines, m.after...)
+
+	if m.noNewline && len(m.after) != 0 {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// splitTrailers メッセージをトレーラの段落とその前後に分ける。
+// 最初の段落はタイトルなのでトレーラにはならない。---の行から後はパッチとみなす
+func splitTrailers(message string) *trailerMessage {
+
+	lines := []string{}
+	if message != "" {
+		lines = strings.Split(strings.TrimSuffix(message, "\n"), "\n")
+	}
+	noNewline := message != "" && !strings.HasSuffix(message, "\n")
+
+	end := len(lines)
+	for i, l := range lines {
+		if l == "---" || strings.HasPrefix(l, "--- ") || strings.HasPrefix(l, "---\t") {
+			end = i
+			break
+		}
+	}
+
+	isBlank := func(l string) bool { return strings.TrimSpace(l) == "" }
+	isComment := func(l string) bool { return strings.HasPrefix(l, "#") }
+
+	last := end - 1
+	for last >= 0 && (isBlank(lines[last]) || isComment(lines[last])) {
+		last--
+	}
+
+	start := last
+	for start > 0 && !isBlank(lines[start-1]) {
+		start--
+	}
+
+	title := 0
+	for title < len(lines) && !isBlank(lines[title]) {
+		title++
+	}
+
+	m := &trailerMessage{before: lines[:last+1], after: lines[last+1:], noNewline: noNewline}
+	if last < 0 || start <= title {
+		return m
+	}
+
+	if block, ok := parseTrailerBlock(lines[start : last+1]); ok {
+		m.before, m.block, m.found = lines[:start], block, true
+	}
+
+	return m
+}
+
+// parseTrailerBlock すべてトレーラか、gitの付けるトレーラがあって1/4以上がトレーラならトレーラの段落
+func parseTrailerBlock(lines []string) ([]trailerItem, bool) {
+
+	items := []trailerItem{}
+	trailers, others, recognized := 0, 0, false
+
+	for _, l := range lines {
+
+		switch {
+		case strings.HasPrefix(l, "#"):
+			// gitと同じく段落の中のコメントは捨てる
+			continue
+		case (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(items) != 0 && items[len(items)-1].token != "":
+			items[len(items)-1].value += "\n" + l
+		default:
+
+			m := trailerLine.FindStringSubmatch(l)
+			if m == nil {
+				items = append(items, trailerItem{value: l})
+				others++
+				continue
+			}
+
+			for _, p := range gitTrailerPrefixes {
+				if strings.HasPrefix(l, p) {
+					recognized = true
+				}
+			}
+
+			items = append(items, trailerItem{m[1], m[2]})
+			trailers++
+		}
+	}
+
+	if trailers == 0 {
+		return nil, false
+	}
+
+	return items, others == 0 || (recognized && trailers*3 >= others)
+}
+
+// addTrailer git interpret-trailersと同じく、whereで決まる基準の行に対してif-existsを判断する
+func addTrailer(block []trailerItem, t trailerItem, opts TrailerOptions) []trailerItem {
+
+	afterOrEnd := opts.Where == TrailerAfter || opts.Where == TrailerEnd
+
+	// 同じtokenのトレーラ。after、endなら最後のもの
+	match := -1
+	for i := range block {
+
+		j := i
+		if afterOrEnd {
+			j = len(block) - 1 - i
+		}
+
+		if block[j].sameToken(t) {
+			match = j
+			break
+		}
+	}
+
+	if match == -1 {
+
+		if opts.IfMissing == TrailerDoNothingIfMissing {
+			return block
+		}
+
+		if afterOrEnd {
+			return append(block, t)
+		}
+		return append([]trailerItem{t}, block...)
+	}
+
+	on := match
+	switch opts.Where {
+	case TrailerEnd:
+		on = len(block) - 1
+	case TrailerStart:
+		on = 0
+	}
+
+	same := func(i int) bool {
+		return block[i].sameToken(t) && strings.EqualFold(block[i].value, t.value)
+	}
+
+	insert := func(block []trailerItem) []trailerItem {
+
+		at := on
+		if afterOrEnd {
+			at = on + 1
+		}
+
+		return append(block[:at], append([]trailerItem{t}, block[at:]...)...)
+	}
+
+	switch opts.IfExists {
+	case TrailerAddIfDifferentNeighbor:
+		if same(on) {
+			return block
+		}
+	case TrailerAddIfDifferent:
+		for i := on; i >= 0 && i < len(block); {
+			if same(i) {
+				return block
+			}
+			if afterOrEnd {
+				i--
+			} else {
+				i++
+			}
+		}
+	case TrailerReplace:
+		block = insert(block)
+		if !afterOrEnd || match > on {
+			match++
+		}
+		return append(block[:match], block[match+1:]...)
+	case TrailerDoNothing:
+		return block
+	}
+
+	return insert(block)
+}
+
+// unfold 継続行をつなぐ
+func unfold(value string) string {
+
+	lines := strings.Split(value, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	return strings.Join(lines, " ")
+}
@@ -0,0 +1,98 @@
+package object_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+func TestParseTrailers(t *testing.T) {
+
+	testt := []struct {
+		description string
+		message     string
+		expect      []object.Trailer
+	}{
+		{"trailers", "subject\n\nbody\n\nSigned-off-by: A <a@example.com>\nCo-authored-by : B <b@example.com>\n", []object.Trailer{{"Signed-off-by", "A <a@example.com>"}, {"Co-authored-by", "B <b@example.com>"}}},
+		{"continuation", "subject\n\nFoo: a\n  continued\nBar:b\n\n", []object.Trailer{{"Foo", "a continued"}, {"Bar", "b"}}},
+		{"title is not trailer", "Foo: a\n", []object.Trailer{}},
+		{"not all trailers", "subject\n\ntext\nFoo: a\n", []object.Trailer{}},
+		{"git trailer with text", "subject\n\ntext\nSigned-off-by: A <a@example.com>\n", []object.Trailer{{"Signed-off-by", "A <a@example.com>"}}},
+		{"comments and patch", "subject\n\nFoo: a\n# comment\n---\nBar: b\n", []object.Trailer{{"Foo", "a"}}},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			if trailers := object.ParseTrailers(tc.message); !reflect.DeepEqual(trailers, tc.expect) {
+				t.Errorf("expect %v, got %v", tc.expect, trailers)
+			}
+		})
+	}
+
+}
+
+// 期待値はgit interpret-trailersの出力
+func TestAddTrailers(t *testing.T) {
+
+	testt := []struct {
+		description         string
+		message             string
+		trailers            []object.Trailer
+		where, exists, miss string
+		expect              string
+	}{
+		{"empty message", "", []object.Trailer{{"foo", "a"}}, "", "", "", "\nfoo: a\n"},
+		{"new paragraph", "subject\n\nbody\n# comment\n", []object.Trailer{{"foo", "a"}}, "", "", "", "subject\n\nbody\n\nfoo: a\n# comment\n"},
+		{"no newline", "subject", []object.Trailer{{"foo", "a"}, {"z", ""}}, "", "", "", "subject\nfoo: a\nz: \n"},
+		{"reformat", "subject\n\nFoo : a\n  continued\n\n\n", []object.Trailer{{"bar", "b"}}, "", "", "", "subject\n\nFoo: a\n  continued\nbar: b\n\n\n"},
+		{"same neighbor", "subject\n\nFoo: A\n", []object.Trailer{{"foo", "a"}}, "", "", "", "subject\n\nFoo: A\n"},
+		{"different neighbor", "subject\n\nFoo: a\nBar: b\n", []object.Trailer{{"foo", "a"}}, "", "", "", "subject\n\nFoo: a\nBar: b\nfoo: a\n"},
+		{"add if different", "subject\n\nFoo: a\nBar: b\n", []object.Trailer{{"foo", "a"}}, "", "addIfDifferent", "", "subject\n\nFoo: a\nBar: b\n"},
+		{"after", "subject\n\nFoo: a\nBar: b\nFoo: c\nBaz: d\n", []object.Trailer{{"foo", "e"}}, "after", "add", "", "subject\n\nFoo: a\nBar: b\nFoo: c\nfoo: e\nBaz: d\n"},
+		{"before", "subject\n\nBar: b\nFoo: a\nFoo: c\n", []object.Trailer{{"foo", "e"}}, "before", "add", "", "subject\n\nBar: b\nfoo: e\nFoo: a\nFoo: c\n"},
+		{"start missing", "subject\n\nFoo: a\n", []object.Trailer{{"bar", "b"}}, "start", "", "", "subject\n\nbar: b\nFoo: a\n"},
+		{"replace", "subject\n\nFoo: a\nBar: b\n", []object.Trailer{{"foo", "c"}}, "", "replace", "", "subject\n\nBar: b\nfoo: c\n"},
+		{"do nothing", "subject\n\nFoo: a\n", []object.Trailer{{"foo", "c"}, {"bar", "b"}}, "", "doNothing", "doNothing", "subject\n\nFoo: a\n"},
+		{"divider", "sub\n---\nx", []object.Trailer{{"foo", "a"}}, "", "", "", "sub\n\nfoo: a\n---\nx"},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			opts, err := object.NewTrailerOptions(tc.where, tc.exists, tc.miss)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if message := object.AddTrailers(tc.message, tc.trailers, opts); message != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, message)
+			}
+		})
+	}
+
+}
+
+func TestParseTrailer(t *testing.T) {
+
+	testt := []struct {
+		arg    string
+		expect object.Trailer
+		err    bool
+	}{
+		{"Signed-off-by=A <a@example.com>", object.Trailer{"Signed-off-by", "A <a@example.com>"}, false},
+		{"Reviewed-by: B", object.Trailer{"Reviewed-by", "B"}, false},
+		{"z", object.Trailer{"z", ""}, false},
+		{"bad token=a", object.Trailer{}, true},
+	}
+
+	for _, tc := range testt {
+
+		trailer, err := object.ParseTrailer(tc.arg)
+		if (err != nil) != tc.err || trailer != tc.expect {
+			t.Errorf("%s: expect %v %v, got %v %v", tc.arg, tc.expect, tc.err, trailer, err)
+		}
+	}
+
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/mizuho-u/got/repository/internal"
 )
 
+// EmptyTreeOID エントリのないツリーのID
+const EmptyTreeOID = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
 type Permission string
 
 const (
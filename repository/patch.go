@@ -0,0 +1,588 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// Patch パッチのひとつのファイルへの変更
+type Patch struct {
+	OldPath, NewPath string
+	OldMode, NewMode object.Permission
+	// OldOID、NewOID index行のID。短いこともある
+	OldOID, NewOID string
+	New, Deleted   bool
+	Renamed        bool
+	Copied         bool
+	// git diff --gitの形式なら、パスはワークスペースのルートから
+	git    bool
+	hunks  []*patchHunk
+	binary *binaryPatchData
+}
+
+// patchHunk @@で始まるハンク
+type patchHunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	lines              []*patchLine
+	// leading、trailing 最初の変更の前と最後の変更の後の、変更のない行数
+	leading, trailing int
+}
+
+// patchLine ハンクの1行。textは改行を含み、\ No newline at end of fileが続けば改行を含まない
+type patchLine struct {
+	diff symbol
+	text string
+	// number パッチの中での行番号
+	number int
+}
+
+// binaryPatchData GIT binary patchの内容。reverseは戻すための内容で、ないこともある
+type binaryPatchData struct {
+	forward, reverse *binaryHunkData
+}
+
+type binaryHunkData struct {
+	delta bool
+	data  []byte
+}
+
+// CorruptPatchError パッチとして読めない行があった
+type CorruptPatchError struct {
+	Line int
+}
+
+func (e *CorruptPatchError) Error() string {
+	return fmt.Sprintf("corrupt patch at line %d", e.Line)
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch gitの形式と、--- と +++ で始まる従来の形式のunified diffを読む。
+// パスからstrip個のディレクトリを取り除く。パッチの前後にあるそれ以外の行は読み飛ばす
+func ParsePatch(data []byte, strip int) ([]*Patch, error) {
+
+	p := &patchParser{lines: splitLines(data), strip: strip}
+
+	patches := []*Patch{}
+	for p.n < len(p.lines) {
+
+		line := p.lines[p.n]
+
+		var patch *Patch
+		var err error
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			patch, err = p.gitPatch()
+		case strings.HasPrefix(line, "--- ") && strings.HasPrefix(p.peek(1), "+++ ") && strings.HasPrefix(p.peek(2), "@@ -"):
+			patch, err = p.traditionalPatch()
+		default:
+			p.n++
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		patches = append(patches, patch)
+	}
+
+	return patches, nil
+}
+
+// splitLines 改行を残したまま行に分ける
+func splitLines(data []byte) []string {
+
+	lines := []string{}
+	for len(data) > 0 {
+
+		i := strings.IndexByte(string(data), '\n')
+		if i == -1 {
+			lines = append(lines, string(data))
+			break
+		}
+
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+
+	return lines
+}
+
+type patchParser struct {
+	lines []string
+	// n 次に読む行の位置
+	n     int
+	strip int
+}
+
+func (p *patchParser) peek(i int) string {
+
+	if p.n+i >= len(p.lines) {
+		return ""
+	}
+
+	return p.lines[p.n+i]
+}
+
+// gitPatch diff --gitの行と拡張ヘッダー、ハンクかGIT binary patchを読む
+func (p *patchParser) gitPatch() (*Patch, error) {
+
+	patch := &Patch{git: true}
+	header := strings.TrimSuffix(strings.TrimPrefix(p.lines[p.n], "diff --git "), "\n")
+	p.n++
+	start := p.n
+
+	var oldName, newName string
+	hasOld, hasNew := false, false
+
+headers:
+	for p.n < len(p.lines) {
+
+		line := strings.TrimSuffix(p.lines[p.n], "\n")
+		switch {
+		case strings.HasPrefix(line, "old mode "):
+			patch.OldMode = object.Permission(strings.TrimPrefix(line, "old mode "))
+		case strings.HasPrefix(line, "new mode "):
+			patch.NewMode = object.Permission(strings.TrimPrefix(line, "new mode "))
+		case strings.HasPrefix(line, "deleted file mode "):
+			patch.Deleted, patch.OldMode = true, object.Permission(strings.TrimPrefix(line, "deleted file mode "))
+		case strings.HasPrefix(line, "new file mode "):
+			patch.New, patch.NewMode = true, object.Permission(strings.TrimPrefix(line, "new file mode "))
+		case strings.HasPrefix(line, "rename from "):
+			patch.Renamed, patch.OldPath = true, unquotePath(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			patch.Renamed, patch.NewPath = true, unquotePath(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "copy from "):
+			patch.Copied, patch.OldPath = true, unquotePath(strings.TrimPrefix(line, "copy from "))
+		case strings.HasPrefix(line, "copy to "):
+			patch.Copied, patch.NewPath = true, unquotePath(strings.TrimPrefix(line, "copy to "))
+		case strings.HasPrefix(line, "similarity index "), strings.HasPrefix(line, "dissimilarity index "):
+		case strings.HasPrefix(line, "index "):
+			p.indexLine(patch, strings.TrimPrefix(line, "index "))
+		case strings.HasPrefix(line, "--- "):
+			oldName, hasOld = p.name(strings.TrimPrefix(line, "--- ")), true
+		case strings.HasPrefix(line, "+++ "):
+			newName, hasNew = p.name(strings.TrimPrefix(line, "+++ ")), true
+		default:
+			break headers
+		}
+
+		p.n++
+	}
+
+	if hasOld && oldName == nullPath {
+		patch.New = true
+	}
+	if hasNew && newName == nullPath {
+		patch.Deleted = true
+	}
+
+	// 名前の変更とコピーはその行のパス、それ以外は --- と +++ の行か、diff --gitの行のパスを使う
+	if patch.OldPath == "" {
+		patch.OldPath = oldName
+	}
+	if patch.NewPath == "" {
+		patch.NewPath = newName
+	}
+
+	if patch.OldPath == "" || patch.OldPath == nullPath || patch.NewPath == "" || patch.NewPath == nullPath {
+
+		name, ok := p.headerName(header)
+		if !ok {
+
+			components := "component"
+			if p.strip != 1 {
+				components += "s"
+			}
+			return nil, fmt.Errorf("git diff header lacks filename information when removing %d leading pathname %s (line %d)", p.strip, components, start)
+		}
+
+		if patch.OldPath == "" || patch.OldPath == nullPath {
+			patch.OldPath = name
+		}
+		if patch.NewPath == "" || patch.NewPath == nullPath {
+			patch.NewPath = name
+		}
+	}
+
+	if patch.OldMode == "" && !patch.New {
+		patch.OldMode = patch.NewMode
+	}
+	if patch.NewMode == "" && !patch.Deleted {
+		patch.NewMode = patch.OldMode
+	}
+
+	line := p.peek(0)
+	switch {
+	case strings.HasPrefix(line, "GIT binary patch"):
+		p.n++
+		return patch, p.binaryPatch(patch)
+	case strings.HasPrefix(line, "Binary files "):
+		p.n++
+		patch.binary = &binaryPatchData{}
+		return patch, nil
+	}
+
+	return patch, p.hunks(patch)
+}
+
+// indexLine index abc..def 100644 のIDとモード
+func (p *patchParser) indexLine(patch *Patch, s string) {
+
+	ids, mode, _ := strings.Cut(s, " ")
+	patch.OldOID, patch.NewOID, _ = strings.Cut(ids, "..")
+
+	if mode != "" {
+		if patch.OldMode == "" {
+			patch.OldMode = object.Permission(mode)
+		}
+		if patch.NewMode == "" {
+			patch.NewMode = object.Permission(mode)
+		}
+	}
+}
+
+// headerName diff --git a/x b/x のように、前後で同じになるパス
+func (p *patchParser) headerName(header string) (string, bool) {
+
+	for i := 0; i < len(header); i++ {
+
+		if header[i] != ' ' {
+			continue
+		}
+
+		a, b := p.name(header[:i]), p.name(header[i+1:])
+		if a != "" && a == b {
+			return a, true
+		}
+	}
+
+	return "", false
+}
+
+// name --- や +++ の行のパス。タブの後の日時を取り除き、先頭からstrip個のディレクトリを取り除く
+func (p *patchParser) name(s string) string {
+
+	s = strings.TrimSuffix(s, "\n")
+	if !strings.HasPrefix(s, `"`) {
+		s, _, _ = strings.Cut(s, "\t")
+	}
+	s = unquotePath(s)
+
+	if s == nullPath {
+		return s
+	}
+
+	for i := 0; i < p.strip; i++ {
+
+		_, rest, ok := strings.Cut(s, "/")
+		if !ok {
+			return ""
+		}
+		s = rest
+	}
+
+	return s
+}
+
+// unquotePath gitが引用符で囲んだパス
+func unquotePath(s string) string {
+
+	if !strings.HasPrefix(s, `"`) {
+		return s
+	}
+
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+
+	return s
+}
+
+// traditionalPatch --- と +++ の行で始まるパッチ。/dev/nullの側があれば追加か削除にする
+func (p *patchParser) traditionalPatch() (*Patch, error) {
+
+	oldName, newName := p.name(strings.TrimPrefix(p.lines[p.n], "--- ")), p.name(strings.TrimPrefix(p.lines[p.n+1], "+++ "))
+	p.n += 2
+
+	patch := &Patch{OldPath: oldName, NewPath: newName}
+	switch {
+	case oldName == nullPath:
+		patch.New, patch.OldPath = true, newName
+	case newName == nullPath:
+		patch.Deleted, patch.NewPath = true, oldName
+	}
+
+	if patch.OldPath == "" || patch.NewPath == "" {
+		return nil, &CorruptPatchError{Line: p.n}
+	}
+
+	return patch, p.hunks(patch)
+}
+
+// hunks @@で始まるハンクを続くだけ読む。ハンクの行数が足りなければ壊れたパッチにする
+func (p *patchParser) hunks(patch *Patch) error {
+
+	for strings.HasPrefix(p.peek(0), "@@ -") {
+
+		m := hunkHeader.FindStringSubmatch(p.lines[p.n])
+		if m == nil {
+			return &CorruptPatchError{Line: p.n + 1}
+		}
+		p.n++
+
+		count := func(s string) int {
+			if s == "" {
+				return 1
+			}
+			n, _ := strconv.Atoi(s)
+			return n
+		}
+
+		h := &patchHunk{}
+		h.oldStart, _ = strconv.Atoi(m[1])
+		h.oldLines = count(m[2])
+		h.newStart, _ = strconv.Atoi(m[3])
+		h.newLines = count(m[4])
+
+		oldLines, newLines, changed := h.oldLines, h.newLines, false
+		for oldLines > 0 || newLines > 0 {
+
+			if p.n >= len(p.lines) {
+				return &CorruptPatchError{Line: p.n + 1}
+			}
+
+			line := p.lines[p.n]
+
+			// gitと同じく空行は変更のない空行にする
+			diff := symbol(line[:1])
+			text := line[1:]
+			if line == "\n" {
+				diff, text = Nochange, "\n"
+			}
+
+			switch diff {
+			case Nochange:
+				oldLines, newLines = oldLines-1, newLines-1
+				if changed {
+					h.trailing++
+				} else {
+					h.leading++
+				}
+			case Deletion:
+				oldLines, changed, h.trailing = oldLines-1, true, 0
+			case Insertion:
+				newLines, changed, h.trailing = newLines-1, true, 0
+			case "\\":
+				p.noNewline(h)
+				p.n++
+				continue
+			default:
+				return &CorruptPatchError{Line: p.n + 1}
+			}
+
+			if oldLines < 0 || newLines < 0 {
+				return &CorruptPatchError{Line: p.n + 1}
+			}
+
+			h.lines = append(h.lines, &patchLine{diff: diff, text: text, number: p.n + 1})
+			p.n++
+		}
+
+		if strings.HasPrefix(p.peek(0), `\`) {
+			p.noNewline(h)
+			p.n++
+		}
+
+		patch.hunks = append(patch.hunks, h)
+	}
+
+	if len(patch.hunks) == 0 && !patch.metadataOnly() {
+		return &CorruptPatchError{Line: p.n + 1}
+	}
+
+	return nil
+}
+
+// noNewline \ No newline at end of file。直前の行の改行を取り除く
+func (p *patchParser) noNewline(h *patchHunk) {
+
+	if len(h.lines) == 0 {
+		return
+	}
+
+	last := h.lines[len(h.lines)-1]
+	last.text = strings.TrimSuffix(last.text, "\n")
+}
+
+// metadataOnly ハンクのない、モードの変更、名前の変更、空のファイルの追加か削除
+func (patch *Patch) metadataOnly() bool {
+	return patch.OldMode != patch.NewMode || patch.Renamed || patch.Copied || patch.New || patch.Deleted
+}
+
+// binaryPatch literalかdeltaの内容を、進める向きと戻す向きの順に読む
+func (p *patchParser) binaryPatch(patch *Patch) error {
+
+	forward, err := p.binaryHunk()
+	if err != nil {
+		return err
+	}
+
+	if forward == nil {
+		return &CorruptPatchError{Line: p.n + 1}
+	}
+
+	reverse, err := p.binaryHunk()
+	if err != nil {
+		return err
+	}
+
+	patch.binary = &binaryPatchData{forward: forward, reverse: reverse}
+
+	return nil
+}
+
+// binaryHunk literal 3 や delta 10 の行と、空行までの85進数の行。なければnil
+func (p *patchParser) binaryHunk() (*binaryHunkData, error) {
+
+	header := strings.TrimSuffix(p.peek(0), "\n")
+
+	var delta bool
+	var size string
+	switch {
+	case strings.HasPrefix(header, "literal "):
+		size = strings.TrimPrefix(header, "literal ")
+	case strings.HasPrefix(header, "delta "):
+		delta, size = true, strings.TrimPrefix(header, "delta ")
+	default:
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, &CorruptPatchError{Line: p.n + 1}
+	}
+	p.n++
+
+	deflated := []byte{}
+	for {
+
+		if p.n >= len(p.lines) {
+			return nil, &CorruptPatchError{Line: p.n + 1}
+		}
+
+		line := strings.TrimSuffix(p.lines[p.n], "\n")
+		p.n++
+
+		if line == "" {
+			break
+		}
+
+		data, err := decodeBinaryLine(line)
+		if err != nil {
+			return nil, &CorruptPatchError{Line: p.n}
+		}
+		deflated = append(deflated, data...)
+	}
+
+	data, err := inflate(deflated, n)
+	if err != nil {
+		return nil, &CorruptPatchError{Line: p.n}
+	}
+
+	return &binaryHunkData{delta: delta, data: data}, nil
+}
+
+// Reverse 元に戻すパッチ。削除と追加、前と後を入れ替える
+func (patch *Patch) Reverse() *Patch {
+
+	r := &Patch{
+		OldPath: patch.NewPath, NewPath: patch.OldPath,
+		OldMode: patch.NewMode, NewMode: patch.OldMode,
+		OldOID: patch.NewOID, NewOID: patch.OldOID,
+		New: patch.Deleted, Deleted: patch.New,
+		Renamed: patch.Renamed, Copied: patch.Copied,
+		git: patch.git,
+	}
+
+	for _, h := range patch.hunks {
+
+		rh := &patchHunk{oldStart: h.newStart, oldLines: h.newLines, newStart: h.oldStart, newLines: h.oldLines, leading: h.leading, trailing: h.trailing}
+		for _, l := range h.lines {
+
+			diff := l.diff
+			switch diff {
+			case Deletion:
+				diff = Insertion
+			case Insertion:
+				diff = Deletion
+			}
+			rh.lines = append(rh.lines, &patchLine{diff: diff, text: l.text, number: l.number})
+		}
+
+		r.hunks = append(r.hunks, rh)
+	}
+
+	if patch.binary != nil {
+		r.binary = &binaryPatchData{forward: patch.binary.reverse, reverse: patch.binary.forward}
+	}
+
+	return r
+}
+
+// Name gitと同じく、名前の変更とコピーは old => new にする
+func (patch *Patch) Name() string {
+
+	if patch.OldPath != patch.NewPath && (patch.Renamed || patch.Copied) {
+		return patch.OldPath + " => " + patch.NewPath
+	}
+
+	return patch.path()
+}
+
+// path 削除なら前の、それ以外は後のパス
+func (patch *Patch) path() string {
+
+	if patch.Deleted {
+		return patch.OldPath
+	}
+
+	return patch.NewPath
+}
+
+// TopLevel gitと同じく、diff --gitのパッチのパスは作業ディレクトリではなくワークスペースのルートからとする
+func (patch *Patch) TopLevel() bool {
+	return patch.git
+}
+
+// HasChanges 内容を変えるハンクかバイナリの変更があるか
+func (patch *Patch) HasChanges() bool {
+	return len(patch.hunks) > 0 || patch.binary != nil
+}
+
+// CheckWhitespace 追加する行の空白の誤り。Pathはパッチの入力の名前、Lineはパッチの中の行番号にする
+func (patch *Patch) CheckWhitespace(ws *whitespace, name string) []*WhitespaceError {
+
+	errs := []*WhitespaceError{}
+	for _, h := range patch.hunks {
+		for _, l := range h.lines {
+
+			if l.diff != Insertion {
+				continue
+			}
+
+			text := strings.TrimSuffix(l.text, "\n")
+			if found := ws.check(text); found != 0 {
+				errs = append(errs, &WhitespaceError{Path: name, Line: l.number, Text: text, errors: found})
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+func TestParsePatch(t *testing.T) {
+
+	data := `garbage before the patch
+diff --git a/f b/f
+old mode 100644
+new mode 100755
+index 0ff3bbb..c9fbe27
+--- a/f
++++ b/f
+@@ -2,3 +2,3 @@
+ 2
+-3
++three
+ 4
+diff --git a/d b/d
+deleted file mode 100644
+index 422c2b7..0000000
+--- a/d
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+diff --git a/n b/n
+new file mode 100644
+index 0000000..3e75765
+--- /dev/null
++++ b/n
+@@ -0,0 +1 @@
++new
+\ No newline at end of file
+diff --git a/r b/s
+similarity index 100%
+rename from r
+rename to s
+plain text between the patches
+--- x/t	2023-01-01 00:00:00
++++ y/t	2023-01-01 00:00:00
+@@ -1 +1 @@
+-t
++u
+`
+
+	patches, err := ParsePatch([]byte(data), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(patches) != 5 {
+		t.Fatalf("expect 5 patches, got %d", len(patches))
+	}
+
+	f, d, n, r, trad := patches[0], patches[1], patches[2], patches[3], patches[4]
+
+	if f.OldPath != "f" || f.OldMode != object.RegularFile || f.NewMode != object.ExecutableFile || f.OldOID != "0ff3bbb" || len(f.hunks) != 1 {
+		t.Errorf("unexpected patch %+v", f)
+	}
+	if h := f.hunks[0]; h.oldStart != 2 || h.oldLines != 3 || h.leading != 1 || h.trailing != 1 {
+		t.Errorf("unexpected hunk %+v", h)
+	}
+	if !d.Deleted || d.path() != "d" {
+		t.Errorf("expect a deletion of d, got %+v", d)
+	}
+	if !n.New || n.hunks[0].lines[0].text != "new" {
+		t.Errorf("expect a new file without a newline, got %+v", n)
+	}
+	if !r.Renamed || r.Name() != "r => s" || r.HasChanges() {
+		t.Errorf("expect a rename, got %+v", r)
+	}
+	if trad.OldPath != "t" || trad.NewPath != "t" || trad.TopLevel() {
+		t.Errorf("unexpected traditional patch %+v", trad)
+	}
+
+	var corrupt *CorruptPatchError
+	if _, err := ParsePatch([]byte("--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n 1\n"), 1); !errors.As(err, &corrupt) || corrupt.Line != 5 {
+		t.Errorf("expect corrupt patch at line 5, got %v", err)
+	}
+}
+
+func TestPatchApply(t *testing.T) {
+
+	patch := `--- a/f
++++ b/f
+@@ -3,5 +3,5 @@
+ 3
+ 4
+-5
++five
+ 6
+ 7
+`
+
+	testt := []struct {
+		description string
+		data        string
+		minContext  int
+		reverse     bool
+		expect      string
+		placement   HunkPlacement
+		err         bool
+	}{
+		{"exact", "1\n2\n3\n4\n5\n6\n7\n8\n", -1, false, "1\n2\n3\n4\nfive\n6\n7\n8\n", HunkPlacement{Hunk: 1, At: 3, Leading: 2, Trailing: 2}, false},
+		{"offset", "0\n0\n1\n2\n3\n4\n5\n6\n7\n", -1, false, "0\n0\n1\n2\n3\n4\nfive\n6\n7\n", HunkPlacement{Hunk: 1, At: 5, Offset: 2, Leading: 2, Trailing: 2}, false},
+		{"context mismatch", "1\n2\nx\n4\n5\n6\ny\n", -1, false, "", HunkPlacement{}, true},
+		// gitと同じく、減らした前の行の分もずれに数える
+		{"reduced context", "1\n2\nx\n4\n5\n6\ny\n", 1, false, "1\n2\nx\n4\nfive\n6\ny\n", HunkPlacement{Hunk: 1, At: 4, Offset: 2, Leading: 1, Trailing: 1, Reduced: true}, false},
+		{"reverse", "1\n2\n3\n4\nfive\n6\n7\n", -1, true, "1\n2\n3\n4\n5\n6\n7\n", HunkPlacement{Hunk: 1, At: 3, Leading: 2, Trailing: 2}, false},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			patches, err := ParsePatch([]byte(patch), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p := patches[0]
+			if tc.reverse {
+				p = p.Reverse()
+			}
+
+			result, placements, err := p.Apply([]byte(tc.data), ApplyMinContext(tc.minContext))
+			if tc.err {
+
+				var failed *PatchFailedError
+				if !errors.As(err, &failed) || failed.Error() != "patch failed: f:3" {
+					t.Errorf("expect patch failed, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(result) != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, result)
+			}
+
+			if *placements[0] != tc.placement {
+				t.Errorf("expect %+v, got %+v", tc.placement, *placements[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// ReflogEntry logs/以下の1行。参照がOldからNewに変わった記録
+type ReflogEntry struct {
+	Old, New  string
+	Committer object.Author
+	Message   string
+}
+
+// String gitと同じ、logs/以下の1行の形式
+func (e ReflogEntry) String() string {
+	return fmt.Sprintf("%s %s %s\t%s\n", e.Old, e.New, e.Committer, e.Message)
+}
+
+// ParseReflog 古いものから順に並んだエントリ
+func ParseReflog(data string) ([]ReflogEntry, error) {
+
+	entries := []ReflogEntry{}
+	for i, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+
+		if line == "" {
+			continue
+		}
+
+		ident, message, _ := strings.Cut(line, "\t")
+
+		fields := strings.SplitN(ident, " ", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid reflog entry at line %d", i+1)
+		}
+
+		committer, err := object.ParseAuthor(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reflog entry at line %d: %w", i+1, err)
+		}
+
+		entries = append(entries, ReflogEntry{fields[0], fields[1], committer, message})
+	}
+
+	return entries, nil
+}
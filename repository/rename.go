@@ -0,0 +1,399 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// maxScore 類似度の最大。gitと同じく60000を100%とする
+	maxScore = 60000
+	// defaultRenameScore -Mの類似度を省略したときの50%
+	defaultRenameScore = maxScore / 2
+	// chunkHashBase 内容の断片のハッシュをこの数で割った余りにする
+	chunkHashBase = 107927
+	symlinkMode   = "120000"
+)
+
+type renameDetector struct {
+	score  int
+	copies bool
+}
+
+type RenameOption func(*renameDetector)
+
+// RenameScore この類似度以上の組を名前の変更にする。ParseRenameScoreの値
+func RenameScore(score int) RenameOption {
+
+	return func(rd *renameDetector) {
+		rd.score = score
+	}
+
+}
+
+// FindCopies 変更されたファイルと、名前の変更に使った削除されたファイルからのコピーも見つける
+func FindCopies() RenameOption {
+
+	return func(rd *renameDetector) {
+		rd.copies = true
+	}
+
+}
+
+// ParseRenameScore -M50%のような類似度。%がなければ小数点以下の数字とみなすので、-M5も50%
+func ParseRenameScore(s string) (int, error) {
+
+	if s == "" {
+		return defaultRenameScore, nil
+	}
+
+	num, scale, dot := 0, 1, false
+	for i, c := range s {
+
+		switch {
+		case c == '.' && !dot:
+			scale, dot = 1, true
+		case c == '%' && i == len(s)-1:
+			if dot {
+				scale *= 100
+			} else {
+				scale = 100
+			}
+		case '0' <= c && c <= '9':
+			if scale < 100000 {
+				scale *= 10
+				num = num*10 + int(c-'0')
+			}
+		default:
+			return 0, fmt.Errorf("invalid rename score '%s'", s)
+		}
+	}
+
+	if num >= scale {
+		return maxScore, nil
+	}
+
+	return maxScore * num / scale, nil
+}
+
+// renameSource 名前の変更やコピーの元になるファイル。削除されたファイルか、コピーなら変更されたファイル
+type renameSource struct {
+	path    string
+	oid     string
+	mode    string
+	data    []byte
+	deleted bool
+	used    int
+	chunks  map[uint32]int
+}
+
+type renameCandidate struct {
+	src, dst int
+	score    int
+	sameBase bool
+}
+
+// DetectRenames 削除と追加の組を名前の変更に、-Cなら追加をコピーにまとめる。
+// 内容が同じものを先に組にして、残りは内容の類似度が高い順に組にする
+func DetectRenames(diffs []FileDiff, opts ...RenameOption) []FileDiff {
+
+	rd := &renameDetector{score: defaultRenameScore}
+	for _, opt := range opts {
+		opt(rd)
+	}
+
+	sources := []*renameSource{}
+	dsts := []int{}
+	for i, d := range diffs {
+
+		switch d := d.(type) {
+		case *diffDeleted:
+			sources = append(sources, &renameSource{path: d.APath, oid: d.AOID, mode: d.AMode, data: d.AData, deleted: true})
+		case *diffModified:
+			if rd.copies {
+				sources = append(sources, &renameSource{path: d.APath, oid: d.AOID, mode: d.AMode, data: d.AData})
+			}
+		case *diffAdded:
+			dsts = append(dsts, i)
+		}
+	}
+
+	if len(sources) == 0 || len(dsts) == 0 {
+		return diffs
+	}
+
+	// 追加のインデックスから元のファイル
+	pairs := map[int]*renameSource{}
+	scores := map[int]int{}
+
+	// 内容が同じもの。まだ使っていない元と、ファイル名が同じ元を優先する
+	for _, i := range dsts {
+
+		dst := diffs[i].(*diffAdded)
+
+		var best *renameSource
+		bestScore := -1
+		for _, src := range sources {
+
+			if src.oid != dst.BOID || (src.used != 0 && !rd.copies) {
+				continue
+			}
+
+			score := 0
+			if src.used == 0 {
+				score++
+			}
+			if filepath.Base(src.path) == filepath.Base(dst.BPath) {
+				score++
+			}
+
+			if score > bestScore {
+				best, bestScore = src, score
+			}
+		}
+
+		if best != nil {
+			best.used++
+			pairs[i], scores[i] = best, maxScore
+		}
+	}
+
+	// 内容が似ているもの
+	candidates := []renameCandidate{}
+	for _, i := range dsts {
+
+		if _, ok := pairs[i]; ok {
+			continue
+		}
+
+		dst := diffs[i].(*diffAdded)
+		for j, src := range sources {
+
+			score := rd.similarity(src, dst)
+			if score < rd.score {
+				continue
+			}
+
+			candidates = append(candidates, renameCandidate{src: j, dst: i, score: score, sameBase: filepath.Base(src.path) == filepath.Base(dst.BPath)})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+
+		return candidates[i].sameBase && !candidates[j].sameBase
+	})
+
+	// 先に使っていない元だけで名前の変更を探して、-Cなら残りをコピーにする
+	assign := func(copies bool) {
+
+		for _, c := range candidates {
+
+			src := sources[c.src]
+			if _, ok := pairs[c.dst]; ok || (src.used != 0 && !copies) {
+				continue
+			}
+
+			src.used++
+			pairs[c.dst], scores[c.dst] = src, c.score
+		}
+	}
+
+	assign(false)
+	if rd.copies {
+		assign(true)
+	}
+
+	// 削除されたファイルは最後に使った追加を名前の変更、それより前はコピーにする
+	renamed := map[string]struct{}{}
+	result := []FileDiff{}
+	for i, d := range diffs {
+
+		src, ok := pairs[i]
+		if !ok {
+			result = append(result, d)
+			continue
+		}
+
+		src.used--
+		rename := src.deleted && src.used == 0
+		if rename {
+			renamed[src.path] = struct{}{}
+		}
+
+		dst := d.(*diffAdded)
+		result = append(result, &diffRenamed{
+			diffModified: diffModified{
+				AOID: src.oid, AMode: src.mode, APath: src.path, AData: src.data,
+				BOID: dst.BOID, BMode: dst.BMode, BPath: dst.BPath, BData: dst.BData,
+				workspace: dst.workspace,
+			},
+			copied: !rename,
+			score:  scores[i],
+		})
+	}
+
+	filtered := []FileDiff{}
+	for _, d := range result {
+
+		if deleted, ok := d.(*diffDeleted); ok {
+			if _, ok := renamed[deleted.APath]; ok {
+				continue
+			}
+		}
+
+		filtered = append(filtered, d)
+	}
+
+	return filtered
+}
+
+// similarity 元の内容のうち追加されたファイルに残っているバイト数を、大きいほうのサイズで割ったもの
+func (rd *renameDetector) similarity(src *renameSource, dst *diffAdded) int {
+
+	if src.mode == symlinkMode || dst.BMode == symlinkMode {
+		return 0
+	}
+
+	maxSize, baseSize := max(len(src.data), len(dst.BData)), min(len(src.data), len(dst.BData))
+	if maxSize == 0 || maxSize*(maxScore-rd.score) < (maxSize-baseSize)*maxScore {
+		return 0
+	}
+
+	if src.chunks == nil {
+		src.chunks = chunks(src.data)
+	}
+
+	copied := 0
+	for h, n := range chunks(dst.BData) {
+		copied += min(n, src.chunks[h])
+	}
+
+	return copied * maxScore / maxSize
+}
+
+// chunks 内容を行か64バイトごとに区切って、断片のハッシュごとにバイト数を数える。テキストならCRLFのCRは無視する
+func chunks(data []byte) map[uint32]int {
+
+	text := !isBinary(data)
+
+	counts := map[uint32]int{}
+	var accum1, accum2 uint32
+	n := 0
+	for i, c := range data {
+
+		if text && c == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			continue
+		}
+
+		old := accum1
+		accum1 = (accum1 << 7) ^ (accum2 >> 25)
+		accum2 = (accum2 << 7) ^ (old >> 25)
+		accum1 += uint32(c)
+
+		n++
+		if n < 64 && c != '\n' {
+			continue
+		}
+
+		counts[(accum1+accum2*0x61)%chunkHashBase] += n
+		accum1, accum2, n = 0, 0, 0
+	}
+
+	if n > 0 {
+		counts[(accum1+accum2*0x61)%chunkHashBase] += n
+	}
+
+	return counts
+}
+
+// diffRenamed 名前の変更かコピー。内容が変わっていなければハンクはない
+type diffRenamed struct {
+	diffModified
+	copied bool
+	score  int
+}
+
+func (diff *diffRenamed) ModeLine() string {
+
+	from, to := "rename from", "rename to"
+	if diff.copied {
+		from, to = "copy from", "copy to"
+	}
+
+	l := diff.diffModified.ModeLine()
+	l += fmt.Sprintf("similarity index %d%%\n", diff.score*100/maxScore)
+	l += fmt.Sprintf("%s %s\n", from, diff.APath)
+	l += fmt.Sprintf("%s %s\n", to, diff.BPath)
+
+	return l
+}
+
+func (diff *diffRenamed) Path() string {
+	return diff.BPath
+}
+
+func (diff *diffRenamed) OldPath() string {
+	return diff.APath
+}
+
+func (diff *diffRenamed) Status() status {
+
+	if diff.copied {
+		return statusCopied
+	}
+
+	return statusRenamed
+}
+
+func (diff *diffRenamed) NameStatus() string {
+	return fmt.Sprintf("%s%03d", diff.Status(), diff.score*100/maxScore)
+}
+
+func (diff *diffRenamed) Raw() string {
+
+	bOID := diff.BOID
+	if diff.workspace {
+		bOID = nullOID
+	}
+
+	return raw(diff.AMode, diff.BMode, diff.AOID, bOID, status(diff.NameStatus()))
+}
+
+// DetectIndexRenames HEADとインデックスの差分から名前の変更を見つけて、元のパスの削除と新しいパスの追加をまとめる
+func (repo *repository) DetectIndexRenames(opts ...RenameOption) error {
+
+	diffs, err := repo.diffStaged()
+	if err != nil {
+		return err
+	}
+
+	for _, d := range DetectRenames(diffs, opts...) {
+
+		if d.Status() != statusRenamed {
+			continue
+		}
+
+		from, to := d.OldPath(), d.Path()
+
+		delete(repo.indexChanges, from)
+		delete(repo.changed, from)
+
+		repo.indexChanges[to] = statusRenamed
+		repo.changed[to] = statusRenamed + repo.changed[to][1:]
+		repo.renamed[to] = from
+	}
+
+	return nil
+}
+
+// RenamedFrom pathが名前を変更したファイルなら元のパス
+func (repo *repository) RenamedFrom(path string) (string, bool) {
+
+	from, ok := repo.renamed[path]
+	return from, ok
+}
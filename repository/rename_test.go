@@ -0,0 +1,114 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
+)
+
+func TestParseRenameScore(t *testing.T) {
+
+	testt := []struct {
+		s      string
+		expect int
+	}{
+		{"", 30000},
+		{"50%", 30000},
+		{"5", 30000},
+		{"90%", 54000},
+		{"05", 3000},
+		{"100%", 60000},
+		{"12.5%", 7500},
+	}
+
+	for _, tc := range testt {
+
+		score, err := repository.ParseRenameScore(tc.s)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.s, err)
+		}
+
+		if score != tc.expect {
+			t.Errorf("%s: expect %d, got %d", tc.s, tc.expect, score)
+		}
+	}
+
+	if _, err := repository.ParseRenameScore("50x"); err == nil {
+		t.Error("expect an error for 50x")
+	}
+
+}
+
+func TestDetectRenames(t *testing.T) {
+
+	db := newDatabase()
+
+	lines := func(s ...string) []byte {
+		return []byte(strings.Join(s, "\n") + "\n")
+	}
+
+	a, objects := newTree(t, map[string]*file{
+		"same.txt":    {object.RegularFile, []byte("same\n")},
+		"similar.txt": {object.RegularFile, lines("1", "2", "3", "4", "5", "6")},
+		"other.txt":   {object.RegularFile, lines("a", "b", "c")},
+		"keep.txt":    {object.RegularFile, lines("k", "e", "e", "p")},
+	})
+	db.store(objects...)
+
+	b, objects := newTree(t, map[string]*file{
+		"moved/same.txt": {object.RegularFile, []byte("same\n")},
+		"similar2.txt":   {object.RegularFile, lines("1", "2", "3", "4", "5", "7")},
+		"unrelated.txt":  {object.RegularFile, lines("x", "y", "z")},
+		"keep.txt":       {object.RegularFile, lines("k", "e", "e", "p", "!")},
+		"keep2.txt":      {object.RegularFile, lines("k", "e", "e", "p")},
+	})
+	db.store(objects...)
+
+	td := repository.NewTreeDiff(db)
+	if err := td.Diff(types.ObjectID(a.OID()), types.ObjectID(b.OID())); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := td.FileDiffs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testt := []struct {
+		opts   []repository.RenameOption
+		expect []string
+	}{
+		{
+			expect: []string{"M keep.txt", "A keep2.txt", "R100 same.txt moved/same.txt", "D other.txt", "R083 similar.txt similar2.txt", "A unrelated.txt"},
+		},
+		{
+			opts:   []repository.RenameOption{repository.RenameScore(54000)},
+			expect: []string{"M keep.txt", "A keep2.txt", "R100 same.txt moved/same.txt", "D other.txt", "D similar.txt", "A similar2.txt", "A unrelated.txt"},
+		},
+		{
+			opts:   []repository.RenameOption{repository.FindCopies()},
+			expect: []string{"M keep.txt", "C100 keep.txt keep2.txt", "R100 same.txt moved/same.txt", "D other.txt", "R083 similar.txt similar2.txt", "A unrelated.txt"},
+		},
+	}
+
+	for i, tc := range testt {
+
+		got := []string{}
+		for _, d := range repository.DetectRenames(diffs, tc.opts...) {
+
+			s := d.NameStatus() + " " + d.Path()
+			if d.OldPath() != d.Path() {
+				s = d.NameStatus() + " " + d.OldPath() + " " + d.Path()
+			}
+			got = append(got, s)
+		}
+
+		if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+			t.Errorf("%d: expect %v, got %v", i, tc.expect, got)
+		}
+	}
+
+}
@@ -2,17 +2,23 @@ package repository
 
 import (
 	"io"
+
+	"github.com/mizuho-u/got/repository/internal"
+	"github.com/mizuho-u/got/types"
 )
 
 type repository struct {
 	index            *index
 	object           ObjectLoader
+	pathspec         types.Pathspec
 	workspace        map[string]WorkspaceEntry
 	head             map[string]TreeEntry
 	changed          map[string]status
 	indexChanges     map[string]status
 	workspaceChanges map[string]status
 	untracked        []string
+	// renamed 名前を変更したファイルの新しいパスから元のパス
+	renamed map[string]string
 }
 
 type WorkspaceOption func(*repository) error
@@ -23,7 +29,7 @@ func WithIndex(data io.Reader) WorkspaceOption {
 
 		index, err := NewIndex(IndexSource(data))
 		if err != nil {
-			return nil
+			return err
 		}
 
 		w.index = index
@@ -41,6 +47,16 @@ func WithObjectLoader(l ObjectLoader) WorkspaceOption {
 
 }
 
+// WithPathspec pathspecにマッチするパスだけを扱う
+func WithPathspec(ps types.Pathspec) WorkspaceOption {
+
+	return func(r *repository) error {
+		r.pathspec = ps
+		return nil
+	}
+
+}
+
 func NewRepository(options ...WorkspaceOption) (*repository, error) {
 
 	index, err := NewIndex()
@@ -55,6 +71,7 @@ func NewRepository(options ...WorkspaceOption) (*repository, error) {
 		workspaceChanges: map[string]status{},
 		head:             map[string]TreeEntry{},
 		untracked:        []string{},
+		renamed:          map[string]string{},
 		workspace:        map[string]WorkspaceEntry{}}
 
 	for _, opt := range options {
@@ -67,6 +84,31 @@ func NewRepository(options ...WorkspaceOption) (*repository, error) {
 
 }
 
+// ReadIndex インデックスをdataから読み直す
+func (repo *repository) ReadIndex(data io.Reader) error {
+	return WithIndex(data)(repo)
+}
+
 func (repo *repository) Index() Index {
 	return repo.index
 }
+
+func (repo *repository) Tracked(path string) bool {
+	return repo.index.tracked(path)
+}
+
+func (repo *repository) matches(path string) bool {
+	return repo.pathspec == nil || repo.pathspec.Match(path)
+}
+
+// UnmatchedPathspec インデックスとHEADのどのファイルにもマッチしなかったpathspecを返す
+func (repo *repository) UnmatchedPathspec() []string {
+
+	if repo.pathspec == nil {
+		return []string{}
+	}
+
+	paths := append(internal.Keys(repo.index.entries), internal.Keys(repo.head)...)
+
+	return repo.pathspec.Unmatched(paths)
+}
@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/mizuho-u/got/repository/object"
 )
 
 func TestCommitRepository(t *testing.T) {
@@ -32,7 +34,8 @@ func TestCommitRepository(t *testing.T) {
 		t.Fatal("create workspace failed. ", err)
 	}
 
-	commitId, objects, err := repo.Commit("", "Mizuho Ueda", "mi_ueda@u-m.dev", "First Commit.", getTimeInJst(t, 1511204319))
+	author := object.NewAuthor("Mizuho Ueda", "mi_ueda@u-m.dev", getTimeInJst(t, 1511204319))
+	commitId, objects, err := repo.Commit(nil, author, author, "First Commit.")
 	if err != nil {
 		t.Fatal("commit failed. ", err)
 	}
@@ -0,0 +1,574 @@
+package repository
+
+import (
+	"container/heap"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
+)
+
+type revListOrder int
+
+const (
+	// orderDefault たどった順。コミット日時の新しい順だが、日時がずれていれば親が先に出ることがある
+	orderDefault revListOrder = iota
+	orderDate
+	orderTopo
+)
+
+type revList struct {
+	ol           objectLoader
+	firstParent  bool
+	ancestryPath bool
+	order        revListOrder
+	reverse      bool
+	maxCount     int
+	since, until time.Time
+	commits      map[string]object.Commit
+	// uninteresting 最後のWalkで除いたコミット
+	uninteresting map[string]bool
+}
+
+type RevListOption func(*revList)
+
+// RevListFirstParent マージコミットは最初の親だけをたどる
+func RevListFirstParent() RevListOption {
+
+	return func(rl *revList) {
+		rl.firstParent = true
+	}
+
+}
+
+// RevListAncestryPath 除いたコミットの子孫だけにする
+func RevListAncestryPath() RevListOption {
+
+	return func(rl *revList) {
+		rl.ancestryPath = true
+	}
+
+}
+
+// RevListTopoOrder 子をすべて出すまで親を出さず、ブランチの履歴が混ざらないようにする
+func RevListTopoOrder() RevListOption {
+
+	return func(rl *revList) {
+		rl.order = orderTopo
+	}
+
+}
+
+// RevListDateOrder 子をすべて出すまで親を出さず、それ以外はコミット日時の新しい順
+func RevListDateOrder() RevListOption {
+
+	return func(rl *revList) {
+		rl.order = orderDate
+	}
+
+}
+
+// RevListReverse 古い順にする。件数を絞ったあとで逆にする
+func RevListReverse() RevListOption {
+
+	return func(rl *revList) {
+		rl.reverse = true
+	}
+
+}
+
+// RevListMaxCount 最大n件にする。負なら制限しない
+func RevListMaxCount(n int) RevListOption {
+
+	return func(rl *revList) {
+		rl.maxCount = n
+	}
+
+}
+
+// RevListSince コミット日時がsinceより古いものを除く
+func RevListSince(since time.Time) RevListOption {
+
+	return func(rl *revList) {
+		rl.since = since
+	}
+
+}
+
+// RevListUntil コミット日時がuntilより新しいものを除く
+func RevListUntil(until time.Time) RevListOption {
+
+	return func(rl *revList) {
+		rl.until = until
+	}
+
+}
+
+func NewRevList(ol objectLoader, opts ...RevListOption) *revList {
+
+	rl := &revList{ol: ol, maxCount: -1, commits: map[string]object.Commit{}}
+
+	for _, opt := range opts {
+		opt(rl)
+	}
+
+	return rl
+}
+
+func (rl *revList) load(oid string) (object.Commit, error) {
+
+	if c, ok := rl.commits[oid]; ok {
+		return c, nil
+	}
+
+	o, err := rl.ol.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := object.ParseCommit(o)
+	if err != nil {
+		return nil, err
+	}
+	rl.commits[oid] = c
+
+	return c, nil
+}
+
+// parents firstParentなら最初の親だけ
+func (rl *revList) parents(c object.Commit) []string {
+
+	parents := c.Parents()
+	if rl.firstParent && len(parents) > 1 {
+		return parents[:1]
+	}
+
+	return parents
+}
+
+// Walk includeから到達できて、excludeから到達できないコミットを、オプションの順序と件数にして返す
+func (rl *revList) Walk(include, exclude []types.ObjectID) ([]object.Commit, error) {
+
+	commits, err := rl.walk(include, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	if rl.ancestryPath {
+		commits = rl.limitToAncestryPath(commits, exclude)
+	}
+
+	commits = slices.DeleteFunc(commits, func(c object.Commit) bool {
+		when := c.Committer().When()
+		return (!rl.since.IsZero() && when.Before(rl.since)) || (!rl.until.IsZero() && when.After(rl.until))
+	})
+
+	if rl.order != orderDefault {
+		commits = rl.sortTopologically(commits)
+	}
+
+	if rl.maxCount >= 0 && len(commits) > rl.maxCount {
+		commits = commits[:rl.maxCount]
+	}
+
+	if rl.reverse {
+		slices.Reverse(commits)
+	}
+
+	return commits, nil
+}
+
+// walk コミット日時の新しい順にたどって、除くコミットの祖先に印をつけていく
+func (rl *revList) walk(include, exclude []types.ObjectID) ([]object.Commit, error) {
+
+	queue := &CommitQueue{}
+	seen := map[string]bool{}
+	uninteresting := map[string]bool{}
+	rl.uninteresting = uninteresting
+
+	push := func(oid string, excluded bool) error {
+
+		if excluded && !uninteresting[oid] {
+			uninteresting[oid] = true
+			// 含めるものとしてキューに入っていても、除くものとして入れ直す
+			seen[oid] = false
+		}
+
+		if seen[oid] {
+			return nil
+		}
+		seen[oid] = true
+
+		c, err := rl.load(oid)
+		if err != nil {
+			return err
+		}
+
+		heap.Push(queue, c)
+		return nil
+	}
+
+	for _, oid := range exclude {
+		if err := push(oid.String(), true); err != nil {
+			return nil, err
+		}
+	}
+	for _, oid := range include {
+		if err := push(oid.String(), false); err != nil {
+			return nil, err
+		}
+	}
+
+	candidates := []object.Commit{}
+	done := map[string]bool{}
+
+	for queue.Len() != 0 && !queue.everyUninteresting(uninteresting) {
+
+		c := heap.Pop(queue).(object.Commit)
+		excluded := uninteresting[c.OID()]
+
+		// 同じコミットが含めるものと除くもので2回入ることがある
+		if done[c.OID()] && !excluded {
+			continue
+		}
+		done[c.OID()] = true
+
+		// 除くものは最初の親以外もたどって、到達できるものをすべて除く
+		parents := c.Parents()
+		if !excluded {
+			candidates = append(candidates, c)
+			parents = rl.parents(c)
+		}
+
+		for _, p := range parents {
+			if err := push(p, excluded); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	commits := []object.Commit{}
+	for _, c := range candidates {
+		if !uninteresting[c.OID()] {
+			commits = append(commits, c)
+		}
+	}
+
+	return commits, nil
+}
+
+// sortTopologically 子をすべて出してから親を出す。topoならひとつの親の系列を続けて出す
+func (rl *revList) sortTopologically(commits []object.Commit) []object.Commit {
+
+	// 一覧の中にある子の数
+	children := map[string]int{}
+	for _, c := range commits {
+		children[c.OID()] = 0
+	}
+	for _, c := range commits {
+		for _, p := range rl.parents(c) {
+			if n, ok := children[p]; ok {
+				children[p] = n + 1
+			}
+		}
+	}
+
+	tips := []object.Commit{}
+	for _, c := range commits {
+		if children[c.OID()] == 0 {
+			tips = append(tips, c)
+		}
+	}
+
+	var next func() object.Commit
+	var push func(c object.Commit)
+	var empty func() bool
+
+	if rl.order == orderTopo {
+
+		// 最後に入れた親から出すので、たどっている系列を先に出し切る
+		stack := slices.Clone(tips)
+		slices.Reverse(stack)
+
+		next = func() object.Commit {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			return c
+		}
+		push = func(c object.Commit) { stack = append(stack, c) }
+		empty = func() bool { return len(stack) == 0 }
+
+	} else {
+
+		queue := CommitQueue(tips)
+		heap.Init(&queue)
+
+		next = func() object.Commit { return heap.Pop(&queue).(object.Commit) }
+		push = func(c object.Commit) { heap.Push(&queue, c) }
+		empty = func() bool { return queue.Len() == 0 }
+	}
+
+	sorted := []object.Commit{}
+	for !empty() {
+
+		c := next()
+		sorted = append(sorted, c)
+
+		for _, p := range rl.parents(c) {
+
+			n, ok := children[p]
+			if !ok {
+				continue
+			}
+
+			if children[p] = n - 1; n == 1 {
+				push(rl.commits[p])
+			}
+		}
+	}
+
+	return sorted
+}
+
+// PathObject --objectsで出すツリーとブロブ。ルートのツリーのパスは空
+type PathObject struct {
+	OID  string
+	Path string
+}
+
+// Objects commitsのツリーから到達できるツリーとブロブ。最後のWalkで除いたコミットのツリーにあるものは除く
+func (rl *revList) Objects(commits []object.Commit, exclude []types.ObjectID) ([]PathObject, error) {
+
+	seen := map[string]bool{}
+
+	// 除くコミットと、一覧のコミットの親のうち除いたもののツリーに印をつける
+	bottoms := []string{}
+	for _, oid := range exclude {
+		bottoms = append(bottoms, oid.String())
+	}
+	for _, c := range commits {
+		for _, p := range c.Parents() {
+			if rl.uninteresting[p] {
+				bottoms = append(bottoms, p)
+			}
+		}
+	}
+
+	for _, oid := range bottoms {
+
+		c, err := rl.load(oid)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rl.walkTree(c.Tree(), "", seen, func(PathObject) {}); err != nil {
+			return nil, err
+		}
+	}
+
+	objects := []PathObject{}
+	for _, c := range commits {
+
+		err := rl.walkTree(c.Tree(), "", seen, func(o PathObject) {
+			objects = append(objects, o)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// walkTree ツリー、その中身の順にたどる。一度見たものはたどらない
+func (rl *revList) walkTree(oid, name string, seen map[string]bool, f func(PathObject)) error {
+
+	if seen[oid] {
+		return nil
+	}
+	seen[oid] = true
+	f(PathObject{OID: oid, Path: name})
+
+	o, err := rl.ol.Load(oid)
+	if err != nil {
+		return err
+	}
+
+	tree, err := object.ParseTree(o)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range tree.Children() {
+
+		p := path.Join(name, e.Basename())
+		if e.IsTree() {
+
+			if err := rl.walkTree(e.OID(), p, seen, f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !seen[e.OID()] {
+			seen[e.OID()] = true
+			f(PathObject{OID: e.OID(), Path: p})
+		}
+	}
+
+	return nil
+}
+
+// limitToAncestryPath bottomsのどれかを祖先に持つコミットだけにする
+func (rl *revList) limitToAncestryPath(commits []object.Commit, bottoms []types.ObjectID) []object.Commit {
+
+	inList := map[string]object.Commit{}
+	for _, c := range commits {
+		inList[c.OID()] = c
+	}
+
+	bottom := map[string]bool{}
+	for _, oid := range bottoms {
+		bottom[oid.String()] = true
+	}
+
+	memo := map[string]bool{}
+	var onPath func(c object.Commit) bool
+	onPath = func(c object.Commit) bool {
+
+		if v, ok := memo[c.OID()]; ok {
+			return v
+		}
+		memo[c.OID()] = false
+
+		for _, p := range c.Parents() {
+
+			if parent, ok := inList[p]; bottom[p] || (ok && onPath(parent)) {
+				memo[c.OID()] = true
+				break
+			}
+		}
+
+		return memo[c.OID()]
+	}
+
+	limited := []object.Commit{}
+	for _, c := range commits {
+		if onPath(c) {
+			limited = append(limited, c)
+		}
+	}
+
+	return limited
+}
+
+// MergeBases aとbの共通の祖先のうち、ほかの共通の祖先の祖先ではないもの
+func (rl *revList) MergeBases(a, b types.ObjectID) ([]types.ObjectID, error) {
+
+	fromA, err := rl.ancestors([]string{a.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	fromB, err := rl.ancestors([]string{b.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	common := []string{}
+	parents := []string{}
+	for oid := range fromA {
+
+		if !fromB[oid] {
+			continue
+		}
+		common = append(common, oid)
+
+		c, err := rl.load(oid)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, c.Parents()...)
+	}
+
+	// 共通の祖先の親から到達できるものはより古い共通の祖先
+	older, err := rl.ancestors(parents)
+	if err != nil {
+		return nil, err
+	}
+
+	bases := []object.Commit{}
+	for _, oid := range common {
+		if !older[oid] {
+			bases = append(bases, rl.commits[oid])
+		}
+	}
+
+	queue := CommitQueue(bases)
+	heap.Init(&queue)
+
+	oids := []types.ObjectID{}
+	for queue.Len() != 0 {
+		oids = append(oids, types.ObjectID(heap.Pop(&queue).(object.Commit).OID()))
+	}
+
+	return oids, nil
+}
+
+// ancestors startsとその祖先
+func (rl *revList) ancestors(starts []string) (map[string]bool, error) {
+
+	seen := map[string]bool{}
+	stack := append([]string{}, starts...)
+
+	for len(stack) != 0 {
+
+		oid := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if seen[oid] {
+			continue
+		}
+		seen[oid] = true
+
+		c, err := rl.load(oid)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, c.Parents()...)
+	}
+
+	return seen, nil
+}
+
+// CommitQueue heap.Interfaceを満たし、コミット日時の新しい順に取り出す
+type CommitQueue []object.Commit
+
+func (q CommitQueue) Len() int { return len(q) }
+func (q CommitQueue) Less(i, j int) bool {
+	return q[i].Committer().When().After(q[j].Committer().When())
+}
+func (q CommitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *CommitQueue) Push(x any)   { *q = append(*q, x.(object.Commit)) }
+func (q *CommitQueue) Pop() any {
+
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+
+	return c
+}
+
+// everyUninteresting キューに除くコミットしか残っていなければ、それ以上たどっても含めるものはない
+func (q CommitQueue) everyUninteresting(uninteresting map[string]bool) bool {
+
+	for _, c := range q {
+		if !uninteresting[c.OID()] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,151 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
+)
+
+// newHistory A-B-C-D-E と B-F-G のブランチ。DはCとGのマージ
+//
+//	A - B - C - D - E
+//	     \     /
+//	      F - G
+func newHistory(t *testing.T) (*database, map[string]types.ObjectID) {
+
+	t.Helper()
+
+	db := newDatabase()
+	oids := map[string]types.ObjectID{}
+
+	tree, objects := newTree(t, map[string]*file{"a.txt": {object.RegularFile, []byte("a")}})
+	db.store(objects...)
+
+	for i, c := range []struct {
+		name    string
+		parents []string
+	}{
+		{"A", nil}, {"B", []string{"A"}}, {"C", []string{"B"}}, {"F", []string{"B"}},
+		{"G", []string{"F"}}, {"D", []string{"C", "G"}}, {"E", []string{"D"}},
+	} {
+
+		author := object.NewAuthor("got", "got@example.com", time.Unix(1697245200+int64(i), 0))
+
+		headers := []object.Header{{Key: "tree", Value: tree.OID()}}
+		for _, p := range c.parents {
+			headers = append(headers, object.Header{Key: "parent", Value: oids[p].String()})
+		}
+		headers = append(headers, object.Header{Key: "author", Value: author.String()}, object.Header{Key: "committer", Value: author.String()})
+
+		commit, err := object.NewCommitWithHeaders(headers, c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		db.store(commit)
+		oids[c.name] = types.ObjectID(commit.OID())
+	}
+
+	return db, oids
+}
+
+func TestRevListWalk(t *testing.T) {
+
+	db, oids := newHistory(t)
+
+	testt := []struct {
+		include, exclude []string
+		opt              string
+		expect           string
+	}{
+		{include: []string{"E"}, expect: "[E D G F C B A]"},
+		{include: []string{"E"}, exclude: []string{"G"}, expect: "[E D C]"},
+		{include: []string{"G"}, exclude: []string{"E"}, expect: "[]"},
+		{include: []string{"G", "C"}, exclude: []string{"B"}, expect: "[G F C]"},
+		{include: []string{"E"}, exclude: []string{"C"}, expect: "[E D G F]"},
+		{include: []string{"E"}, exclude: []string{"C"}, opt: "first-parent", expect: "[E D]"},
+		{include: []string{"E"}, exclude: []string{"C"}, opt: "ancestry-path", expect: "[E D]"},
+		{include: []string{"E"}, exclude: []string{"F"}, opt: "ancestry-path", expect: "[E D G]"},
+		{include: []string{"E"}, opt: "first-parent", expect: "[E D C B A]"},
+		{include: []string{"E"}, opt: "topo-order", expect: "[E D G F C B A]"},
+		{include: []string{"E"}, opt: "date-order", expect: "[E D G F C B A]"},
+		{include: []string{"E", "C"}, exclude: []string{"A"}, opt: "reverse", expect: "[B C F G D E]"},
+		{include: []string{"E"}, opt: "max-count", expect: "[E D]"},
+	}
+
+	for _, tc := range testt {
+
+		rl := repository.NewRevList(db)
+		switch tc.opt {
+		case "first-parent":
+			rl = repository.NewRevList(db, repository.RevListFirstParent())
+		case "ancestry-path":
+			rl = repository.NewRevList(db, repository.RevListAncestryPath())
+		case "topo-order":
+			rl = repository.NewRevList(db, repository.RevListTopoOrder())
+		case "date-order":
+			rl = repository.NewRevList(db, repository.RevListDateOrder())
+		case "reverse":
+			rl = repository.NewRevList(db, repository.RevListReverse())
+		case "max-count":
+			rl = repository.NewRevList(db, repository.RevListMaxCount(2))
+		}
+
+		commits, err := rl.Walk(names(oids, tc.include), names(oids, tc.exclude))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		messages := []string{}
+		for _, c := range commits {
+			messages = append(messages, c.Message())
+		}
+
+		if fmt.Sprint(messages) != tc.expect {
+			t.Errorf("%v ^%v %s: expect %s, got %v", tc.include, tc.exclude, tc.opt, tc.expect, messages)
+		}
+	}
+
+}
+
+func TestMergeBases(t *testing.T) {
+
+	db, oids := newHistory(t)
+
+	testt := []struct {
+		a, b, expect string
+	}{
+		{"E", "G", "G"},
+		{"C", "G", "B"},
+		{"C", "F", "B"},
+		{"D", "C", "C"},
+		{"A", "A", "A"},
+	}
+
+	for _, tc := range testt {
+
+		bases, err := repository.NewRevList(db).MergeBases(oids[tc.a], oids[tc.b])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bases) != 1 || bases[0] != oids[tc.expect] {
+			t.Errorf("merge base of %s and %s: expect %s, got %v", tc.a, tc.b, oids[tc.expect], bases)
+		}
+	}
+
+}
+
+func names(oids map[string]types.ObjectID, names []string) []types.ObjectID {
+
+	ids := []types.ObjectID{}
+	for _, name := range names {
+		ids = append(ids, oids[name])
+	}
+
+	return ids
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mizuho-u/got/repository/internal"
+)
+
+type RemoveOptions struct {
+	Cached    bool
+	Force     bool
+	Recursive bool
+}
+
+// Remove pathspecにマッチするファイルをインデックスから削除して、そのパスを返す。
+// Scanしたあとに呼ぶ。ローカルの変更が失われる場合はForceがなければ何もしない
+func (repo *repository) Remove(opts RemoveOptions) ([]string, error) {
+
+	files := []string{}
+	for name := range repo.index.entries {
+		if repo.matches(name) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	if !opts.Recursive {
+		for _, name := range files {
+			if repo.pathspec != nil && !repo.pathspec.MatchFile(name) {
+				return nil, fmt.Errorf("not removing '%s' recursively without -r", repo.matchedDir(name))
+			}
+		}
+	}
+
+	if !opts.Force {
+		if err := repo.checkRemovable(files, opts.Cached); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range files {
+		repo.index.Delete(name)
+	}
+
+	return files, nil
+}
+
+func (repo *repository) matchedDir(name string) string {
+
+	for _, dir := range internal.ParentDirs(name) {
+		if repo.pathspec.MatchFile(dir) {
+			return dir
+		}
+	}
+
+	return "."
+}
+
+func (repo *repository) checkRemovable(files []string, cached bool) error {
+
+	both, staged, local := []string{}, []string{}, []string{}
+
+	for _, name := range files {
+
+		_, stagedChange := repo.indexChanges[name]
+		localChange := repo.workspaceChanges[name] == statusFileModified
+
+		switch {
+		case stagedChange && localChange:
+			both = append(both, name)
+		case cached:
+		case stagedChange:
+			staged = append(staged, name)
+		case localChange:
+			local = append(local, name)
+		}
+	}
+
+	switch {
+	case len(both) != 0:
+		return removeError("has staged content different from both the\nfile and the HEAD", "(use -f to force removal)", both)
+	case len(staged) != 0:
+		return removeError("has changes staged in the index", "(use --cached to keep the file, or -f to force removal)", staged)
+	case len(local) != 0:
+		return removeError("has local modifications", "(use --cached to keep the file, or -f to force removal)", local)
+	}
+
+	return nil
+}
+
+func removeError(reason, hint string, files []string) error {
+
+	subject := "the following file"
+	if len(files) > 1 {
+		subject = "the following files"
+		reason = strings.Replace(reason, "has", "have", 1)
+	}
+
+	return fmt.Errorf("%s %s:\n    %s\n%s", subject, reason, strings.Join(files, "\n    "), hint)
+}
@@ -76,16 +76,21 @@ func (repo *repository) scan(workspaceScanner WorkspaceScanner) error {
 			break
 		}
 
+		if !repo.matches(p.Name()) {
+			continue
+		}
+
 		repo.workspace[p.Name()] = p
 
 		if repo.Index().tracked(p.Name()) {
 			continue
 		}
 
+		// pathspecにマッチしないディレクトリにはまとめない
 		entry := p.Name()
 		for _, d := range p.Parents() {
 
-			if !repo.Index().tracked(d) {
+			if !repo.Index().tracked(d) && repo.matches(d) {
 				entry = d + "/"
 				break
 			}
@@ -111,6 +116,10 @@ func (repo *repository) detectChanges(headScanner TreeScanner) {
 
 		repo.head[name] = entry
 
+		if !repo.matches(name) {
+			return
+		}
+
 		if !repo.index.trackedFile(name) {
 			repo.changed[name] = statusFileDeleted + statusNone
 			repo.indexChanges[name] = statusFileDeleted
@@ -121,6 +130,10 @@ func (repo *repository) detectChanges(headScanner TreeScanner) {
 
 	for _, e := range repo.index.entries {
 
+		if !repo.matches(e.filename) {
+			continue
+		}
+
 		indexStatus := statusNone
 		if h, ok := repo.head[e.filename]; !ok {
 			indexStatus = statusIndexAdded
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"path/filepath"
+	"sort"
 
 	"github.com/mizuho-u/got/internal"
 	"github.com/mizuho-u/got/repository/object"
@@ -20,12 +21,39 @@ func newPair(a, b object.TreeEntry) pair {
 }
 
 type treeDiff struct {
-	ol      objectLoader
-	changes map[string]pair
+	ol       objectLoader
+	pathspec types.Pathspec
+	changes  map[string]pair
 }
 
-func NewTreeDiff(ol objectLoader) *treeDiff {
-	return &treeDiff{ol, map[string]pair{}}
+type treeDiffOption func(*treeDiff)
+
+// TreeDiffPathspec pathspecにマッチする変更だけを検出する
+func TreeDiffPathspec(ps types.Pathspec) treeDiffOption {
+
+	return func(td *treeDiff) {
+		td.pathspec = ps
+	}
+
+}
+
+func NewTreeDiff(ol objectLoader, opts ...treeDiffOption) *treeDiff {
+
+	td := &treeDiff{ol: ol, changes: map[string]pair{}}
+
+	for _, opt := range opts {
+		opt(td)
+	}
+
+	return td
+}
+
+func (td *treeDiff) matches(path string) bool {
+	return td.pathspec == nil || td.pathspec.Match(path)
+}
+
+func (td *treeDiff) matchesDir(path string) bool {
+	return td.pathspec == nil || td.pathspec.MatchDir(path)
 }
 
 func (td *treeDiff) Diff(a, b types.ObjectID) error {
@@ -120,8 +148,10 @@ func (td *treeDiff) detectDeletion(a, b map[string]object.TreeEntry, prefix stri
 			b = types.ObjectID(bChild.OID())
 		}
 
-		if err := td.compare(a, b, path); err != nil {
-			return err
+		if td.matchesDir(path) {
+			if err := td.compare(a, b, path); err != nil {
+				return err
+			}
 		}
 
 		// blob
@@ -139,6 +169,10 @@ func (td *treeDiff) detectDeletion(a, b map[string]object.TreeEntry, prefix stri
 			continue
 		}
 
+		if !td.matches(path) {
+			continue
+		}
+
 		td.changes[path] = newPair(c, o)
 
 	}
@@ -159,8 +193,10 @@ func (td *treeDiff) detectAdditions(a, b map[string]object.TreeEntry, prefix str
 
 		path := filepath.Join(prefix, basename)
 		if bChild.IsTree() {
-			td.compare(types.NullObjectID, types.ObjectID(bChild.OID()), path)
-		} else {
+			if td.matchesDir(path) {
+				td.compare(types.NullObjectID, types.ObjectID(bChild.OID()), path)
+			}
+		} else if td.matches(path) {
 			td.changes[path] = newPair(aChild, bChild)
 		}
 
@@ -173,3 +209,45 @@ func (td *treeDiff) detectAdditions(a, b map[string]object.TreeEntry, prefix str
 func (td *treeDiff) Changes() map[string]pair {
 	return td.changes
 }
+
+// FileDiffs Diffで見つけた変更をパスの順に差分にする
+func (td *treeDiff) FileDiffs() ([]FileDiff, error) {
+
+	paths := internal.Keys(td.changes)
+	sort.Strings(paths)
+
+	diffs := []FileDiff{}
+	for _, path := range paths {
+
+		change := td.changes[path]
+
+		aOID, aMode, aData, err := td.blob(change.Item1())
+		if err != nil {
+			return nil, err
+		}
+
+		bOID, bMode, bData, err := td.blob(change.Item2())
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, newFileDiff(path, aOID, aMode, aData, bOID, bMode, bData, false))
+	}
+
+	return diffs, nil
+}
+
+// blob エントリがなければnullOIDと空の内容
+func (td *treeDiff) blob(e object.TreeEntry) (oid, mode string, data []byte, err error) {
+
+	if e == nil {
+		return nullOID, "", []byte(nullContents), nil
+	}
+
+	o, err := td.ol.Load(e.OID())
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return e.OID(), string(e.Permission()), o.Data(), nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type whitespaceRule int
+
+const (
+	// wsBlankAtEOL 行末の空白
+	wsBlankAtEOL whitespaceRule = 1 << iota
+	// wsSpaceBeforeTab インデントのタブの前の空白
+	wsSpaceBeforeTab
+	// wsIndentWithNonTab タブ幅以上の空白だけのインデント
+	wsIndentWithNonTab
+	// wsTabInIndent インデントのタブ
+	wsTabInIndent
+	// wsBlankAtEOF ファイルの末尾に足した空行
+	wsBlankAtEOF
+	// wsCRAtEOL 行末の\rを空白にしない
+	wsCRAtEOL
+
+	wsTrailingSpace = wsBlankAtEOL | wsBlankAtEOF
+	wsDefaultRule   = wsBlankAtEOL | wsSpaceBeforeTab | wsBlankAtEOF
+)
+
+// defaultTabWidth gitと同じ、indent-with-non-tabのタブ幅
+const defaultTabWidth = 8
+
+// whitespaceRuleNames core.whitespaceの名前。gitと同じく、名前の先頭だけでも最初にマッチしたものにする
+var whitespaceRuleNames = []struct {
+	name string
+	rule whitespaceRule
+}{
+	{"trailing-space", wsTrailingSpace},
+	{"space-before-tab", wsSpaceBeforeTab},
+	{"indent-with-non-tab", wsIndentWithNonTab},
+	{"cr-at-eol", wsCRAtEOL},
+	{"blank-at-eol", wsBlankAtEOL},
+	{"blank-at-eof", wsBlankAtEOF},
+	{"tab-in-indent", wsTabInIndent},
+}
+
+type whitespace struct {
+	rule     whitespaceRule
+	tabWidth int
+}
+
+// ParseWhitespace core.whitespaceの値。カンマで区切った名前を順に足し、-をつけた名前は取り除く。空ならgitの既定
+func ParseWhitespace(s string) (*whitespace, error) {
+
+	ws := &whitespace{rule: wsDefaultRule, tabWidth: defaultTabWidth}
+
+	for _, token := range strings.Split(s, ",") {
+
+		token = strings.TrimLeft(token, " \t\n\r")
+
+		negated := strings.HasPrefix(token, "-")
+		token = strings.TrimPrefix(token, "-")
+		if token == "" {
+			continue
+		}
+
+		if width, ok := strings.CutPrefix(token, "tabwidth="); ok {
+
+			// gitと同じく範囲外の幅は無視する
+			if n, err := strconv.Atoi(width); err == nil && 0 < n && n < 64 {
+				ws.tabWidth = n
+			}
+			continue
+		}
+
+		for _, r := range whitespaceRuleNames {
+
+			if !strings.HasPrefix(r.name, token) {
+				continue
+			}
+
+			if negated {
+				ws.rule &^= r.rule
+			} else {
+				ws.rule |= r.rule
+			}
+			break
+		}
+	}
+
+	if ws.rule&wsTabInIndent != 0 && ws.rule&wsIndentWithNonTab != 0 {
+		return nil, fmt.Errorf("cannot enforce both tab-in-indent and indent-with-non-tab")
+	}
+
+	return ws, nil
+}
+
+// check textの空白の誤り。gitと同じく行末の空白を調べてから、その前までのインデントを調べる
+func (ws *whitespace) check(text string) whitespaceRule {
+
+	if ws.rule&wsCRAtEOL != 0 {
+		text = strings.TrimSuffix(text, "\r")
+	}
+
+	var result whitespaceRule
+
+	trailing := len(text)
+	if ws.rule&wsBlankAtEOL != 0 {
+		for trailing > 0 && isSpace(text[trailing-1]) {
+			trailing--
+			result |= wsBlankAtEOL
+		}
+	}
+
+	// writtenは最後のタブの次の位置
+	i, written := 0, 0
+	for ; i < trailing; i++ {
+
+		if text[i] == ' ' {
+			continue
+		}
+		if text[i] != '\t' {
+			break
+		}
+
+		if ws.rule&wsSpaceBeforeTab != 0 && written < i {
+			result |= wsSpaceBeforeTab
+		} else if ws.rule&wsTabInIndent != 0 {
+			result |= wsTabInIndent
+		}
+		written = i + 1
+	}
+
+	if ws.rule&wsIndentWithNonTab != 0 && i-written >= ws.tabWidth {
+		result |= wsIndentWithNonTab
+	}
+
+	return result
+}
+
+// blank 空白だけの行か
+func blankLine(text string) bool {
+
+	for i := 0; i < len(text); i++ {
+		if !isSpace(text[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trailingBlanks 末尾に続く空白だけの行の数
+func trailingBlanks(ls []*line) int {
+
+	n := 0
+	for i := len(ls) - 1; i >= 0 && blankLine(ls[i].text); i-- {
+		n++
+	}
+
+	return n
+}
+
+// WhitespaceError 追加した行の空白の誤り
+type WhitespaceError struct {
+	Path string
+	// Line 変更後のファイルでの行番号
+	Line int
+	// Text 誤りのある行。ファイル末尾の空行なら空
+	Text   string
+	errors whitespaceRule
+}
+
+// Message gitと同じく、誤りをカンマで区切った説明
+func (e *WhitespaceError) Message() string {
+
+	msgs := []string{}
+	if e.errors&wsBlankAtEOL != 0 {
+		msgs = append(msgs, "trailing whitespace")
+	}
+	if e.errors&wsBlankAtEOF != 0 {
+		msgs = append(msgs, "new blank line at EOF")
+	}
+	if e.errors&wsSpaceBeforeTab != 0 {
+		msgs = append(msgs, "space before tab in indent")
+	}
+	if e.errors&wsIndentWithNonTab != 0 {
+		msgs = append(msgs, "indent with spaces")
+	}
+	if e.errors&wsTabInIndent != 0 {
+		msgs = append(msgs, "tab in indent")
+	}
+
+	return strings.Join(msgs, ", ")
+}
+
+// EOF ファイル末尾に空行を足した誤りか。行の内容は出さない
+func (e *WhitespaceError) EOF() bool {
+	return e.errors == wsBlankAtEOF
+}
+
+func (e *WhitespaceError) String() string {
+	return fmt.Sprintf("%s:%d: %s.", e.Path, e.Line, e.Message())
+}
+
+// CheckWhitespace diffsのハンクで追加した行の空白の誤りを探す。
+// gitと同じく、変更後のファイル末尾の空行が変更前より増えていれば、その空行の始まりをblank-at-eofにする
+func CheckWhitespace(diffs []FileDiff, ws *whitespace) []*WhitespaceError {
+
+	errs := []*WhitespaceError{}
+	for _, d := range diffs {
+
+		if d.Binary() {
+			continue
+		}
+
+		for _, h := range d.Hunks() {
+			for _, e := range h.edits {
+
+				if e.diff != Insertion {
+					continue
+				}
+
+				if found := ws.check(e.line.text); found != 0 {
+					errs = append(errs, &WhitespaceError{Path: d.Path(), Line: e.bline.number, Text: e.line.text, errors: found})
+				}
+			}
+		}
+
+		if ws.rule&wsBlankAtEOF == 0 {
+			continue
+		}
+
+		a, b := d.contents()
+		al, _ := lines(strings.NewReader(string(a)))
+		bl, _ := lines(strings.NewReader(string(b)))
+
+		if before, after := trailingBlanks(al), trailingBlanks(bl); after > before {
+			errs = append(errs, &WhitespaceError{Path: d.Path(), Line: len(bl) - after + 1, errors: wsBlankAtEOF})
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,45 @@
+package repository
+
+import "testing"
+
+func TestWhitespaceCheck(t *testing.T) {
+
+	testt := []struct {
+		description string
+		config      string
+		text        string
+		expect      string
+	}{
+		{"trailing whitespace", "", "a \t", "trailing whitespace"},
+		{"blank line", "", "  \t", "trailing whitespace"},
+		{"space before tab", "", "  \tx", "space before tab in indent"},
+		{"both", "", " \tx ", "trailing whitespace, space before tab in indent"},
+		{"trailing cr", "", "a\r", "trailing whitespace"},
+		{"cr at eol", "cr-at-eol", "a\r", ""},
+		{"indent with spaces", "indent-with-non-tab", "        x", "indent with spaces"},
+		{"short indent", "indent-with-non-tab", "       x", ""},
+		{"tab width", "indent,tabwidth=4", "    x", "indent with spaces"},
+		{"tab in indent", "tab-in-indent", "\tx", "tab in indent"},
+		{"disabled", "-trailing,-space", " \tx ", ""},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			ws, err := ParseWhitespace(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := &WhitespaceError{errors: ws.check(tc.text)}
+			if e.Message() != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, e.Message())
+			}
+		})
+	}
+
+	if _, err := ParseWhitespace("tab-in-indent,indent-with-non-tab"); err == nil {
+		t.Error("expect an error for tab-in-indent with indent-with-non-tab")
+	}
+
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Word 単語で比べた差分の一部。変更のない部分は追加した側の内容で、改行を含むことがある
+type Word struct {
+	diff symbol
+	text string
+	// line 変更のない行そのもの
+	line bool
+}
+
+func (w *Word) Diff() symbol {
+	return w.diff
+}
+
+func (w *Word) Text() string {
+	return w.text
+}
+
+// Line ハンクの変更のない行か。gitと同じく空行でも単語と同じように出す
+func (w *Word) Line() bool {
+	return w.line
+}
+
+// CompileWordRegex --word-diff-regexやdiff.wordRegexの拡張正規表現
+func CompileWordRegex(s string) (*regexp.Regexp, error) {
+
+	re, err := regexp.CompilePOSIX(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %s", s)
+	}
+
+	return re, nil
+}
+
+// WordDiff ハンクの変更を単語で比べる。gitと同じく変更のない行ごとに区切って、その間の削除した行と追加した行をまとめて比べる
+func (h *hunk) WordDiff() []*Word {
+
+	words := []*Word{}
+	var minus, plus strings.Builder
+
+	flush := func() {
+		words = append(words, diffWords(minus.String(), plus.String(), h.wordRegex)...)
+		minus.Reset()
+		plus.Reset()
+	}
+
+	for _, e := range h.edits {
+
+		switch e.diff {
+		case Deletion:
+			minus.WriteString(e.line.text + "\n")
+		case Insertion:
+			plus.WriteString(e.line.text + "\n")
+		default:
+			flush()
+			words = append(words, &Word{diff: Nochange, text: e.line.text + "\n", line: true})
+		}
+	}
+
+	flush()
+
+	return words
+}
+
+// wordSpan 単語の位置[begin, end)
+type wordSpan struct {
+	begin, end int
+}
+
+// splitWords reにマッチする部分を単語にする。マッチしなければ空白で区切る。単語は改行をまたがない
+func splitWords(text string, re *regexp.Regexp) []wordSpan {
+
+	spans := []wordSpan{}
+	for begin := 0; begin < len(text); {
+
+		if re != nil {
+
+			if m := re.FindStringIndex(text[begin:]); m != nil {
+
+				start, end := begin+m[0], begin+m[1]
+				if i := strings.IndexByte(text[start:end], '\n'); i != -1 {
+					end = start + i
+				}
+
+				if start >= end {
+					return spans
+				}
+
+				spans = append(spans, wordSpan{start, end})
+				begin = end
+				continue
+			}
+		}
+
+		for begin < len(text) && isSpace(text[begin]) {
+			begin++
+		}
+		if begin >= len(text) {
+			return spans
+		}
+
+		end := begin + 1
+		for end < len(text) && !isSpace(text[end]) {
+			end++
+		}
+
+		spans = append(spans, wordSpan{begin, end})
+		begin = end
+	}
+
+	return spans
+}
+
+// diffWords minusとplusを単語で比べる。変更のない部分はplusから出し、削除した単語と追加した単語の間の空白もそのまま出す
+func diffWords(minus, plus string, re *regexp.Regexp) []*Word {
+
+	if minus == "" && plus == "" {
+		return nil
+	}
+
+	if plus == "" {
+		return []*Word{{diff: Deletion, text: minus}}
+	}
+
+	ms, ps := splitWords(minus, re), splitWords(plus, re)
+
+	toLines := func(text string, spans []wordSpan) []*line {
+
+		lines := make([]*line, len(spans))
+		for i, s := range spans {
+			lines[i] = &line{i + 1, text[s.begin:s.end]}
+		}
+		return lines
+	}
+
+	es := diffLines(&diffOptions{algorithm: DiffMyers}, toLines(minus, ms), toLines(plus, ps))
+
+	words := []*Word{}
+	add := func(diff symbol, text string) {
+		if text != "" {
+			words = append(words, &Word{diff: diff, text: text})
+		}
+	}
+
+	// gitと同じく、変更した単語がなければ直前の単語の終わりを位置にする
+	end := func(spans []wordSpan, i int) int {
+		if i == 0 {
+			return 0
+		}
+		return spans[i-1].end
+	}
+
+	// 続けて変更した単語ごとに、削除と追加の範囲を出す
+	current, i, j := 0, 0, 0
+	for k := 0; k < len(es); {
+
+		if es[k].diff == Nochange {
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		mi, pj := i, j
+		for ; k < len(es) && es[k].diff != Nochange; k++ {
+			if es[k].diff == Deletion {
+				i++
+			} else {
+				j++
+			}
+		}
+
+		minusBegin, minusEnd := end(ms, mi), end(ms, mi)
+		if i > mi {
+			minusBegin, minusEnd = ms[mi].begin, ms[i-1].end
+		}
+
+		plusBegin, plusEnd := end(ps, pj), end(ps, pj)
+		if j > pj {
+			plusBegin, plusEnd = ps[pj].begin, ps[j-1].end
+		}
+
+		add(Nochange, plus[current:plusBegin])
+		add(Deletion, minus[minusBegin:minusEnd])
+		add(Insertion, plus[plusBegin:plusEnd])
+		current = plusEnd
+	}
+
+	add(Nochange, plus[current:])
+
+	return words
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+
+	testt := []struct {
+		description string
+		minus, plus string
+		re          *regexp.Regexp
+		expect      string
+	}{
+		{"changed word", "one two three\n", "one 2 three\n", nil, "one [-two-]{+2+} three\n"},
+		{"deleted word joins the previous word", "alpha beta gamma\n", "alpha gamma\n", nil, "alpha[-beta-] gamma\n"},
+		{"whitespace only change", "foo  bar\n", "foo bar\n", nil, "foo bar\n"},
+		{"deleted lines", "removed\n", "", nil, "[-removed\n-]"},
+		{"words across lines", "removed line\n", "new\nadded line\n", nil, "[-removed-]{+new\nadded+} line\n"},
+		{"regex", "foo(a)+bar(b)\n", "foo(c)*bar(b)\n", regexp.MustCompile("[[:alnum:]]+|[^[:space:]]"), "foo([-a-]{+c+})[-+-]{+*+}bar(b)\n"},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			var b strings.Builder
+			for _, w := range diffWords(tc.minus, tc.plus, tc.re) {
+
+				switch w.Diff() {
+				case Deletion:
+					b.WriteString("[-" + w.Text() + "-]")
+				case Insertion:
+					b.WriteString("{+" + w.Text() + "+}")
+				default:
+					b.WriteString(w.Text())
+				}
+			}
+
+			if b.String() != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, b.String())
+			}
+		})
+	}
+
+}
@@ -0,0 +1,23 @@
+package e2e
+
+import (
+	"testing"
+)
+
+func TestCheckIgnore(t *testing.T) {
+
+	build := buildpath(t)
+
+	dir := initDir(t, build)
+
+	createFile(t, dir, ".gitignore", []byte("*.log\n"))
+	createFile(t, dir, "debug.log", []byte("debug\n"))
+
+	out := executeCmd(t, build+" -C "+dir+" check-ignore -v "+dir+"/debug.log")
+
+	expect := ".gitignore:1:*.log\tdebug.log\n"
+	if out != expect {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
@@ -2,7 +2,9 @@ package e2e
 
 import (
 	"os"
+	"os/exec"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -74,3 +76,85 @@ func TestCommitExcutableFiles(t *testing.T) {
 	}
 
 }
+
+func TestCommitMessageOptions(t *testing.T) {
+
+	build := buildpath(t)
+	tempdir := initDir(t, build)
+
+	createFile(t, tempdir, "hello.txt", []byte("Hello world.\n"))
+	executeCmd(t, build+" -C "+tempdir+" add .")
+
+	// -mはひとつずつ段落になる
+	executeCmd(t, build+" -C "+tempdir+` commit -m "subject" -m "body"`)
+
+	out, err := exec.Command("git", "-C", tempdir, "log", "-1", "--format=%B").CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+
+	if string(out) != "subject\n\nbody\n\n" {
+		t.Fatalf("unexpected message %q", out)
+	}
+
+	createFile(t, tempdir, "hello.txt", []byte("Hello world 2.\n"))
+	msg := createFile(t, tempdir, "msg.txt", []byte("from file  \n\n\n# kept\n"))
+
+	executeCmd(t, build+" -C "+tempdir+" commit -a -F "+msg)
+
+	out, err = exec.Command("git", "-C", tempdir, "log", "-1", "--format=%B").CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+
+	if string(out) != "from file\n\n# kept\n\n" {
+		t.Fatalf("unexpected message %q", out)
+	}
+
+}
+
+func TestCommitIdentityOverrides(t *testing.T) {
+
+	build := buildpath(t)
+	tempdir := initDir(t, build)
+
+	createFile(t, tempdir, "hello.txt", []byte("Hello world.\n"))
+	executeCmd(t, build+" -C "+tempdir+" add .")
+
+	env := "GIT_COMMITTER_NAME=Committer GIT_COMMITTER_EMAIL=committer@example.com GIT_COMMITTER_DATE='@1697247000 +0900' "
+	executeCmd(t, env+build+" -C "+tempdir+` commit -m first --author "A U Thor <author@example.com>" --date "2020-09-13 14:26:40 +0200"`)
+
+	out, err := exec.Command("git", "-C", tempdir, "cat-file", "-p", "HEAD").CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+
+	for _, line := range []string{
+		"author A U Thor <author@example.com> 1600000000 +0200\n",
+		"committer Committer <committer@example.com> 1697247000 +0900\n",
+	} {
+		if !strings.Contains(string(out), line) {
+			t.Fatalf("expect %q in %s", line, out)
+		}
+	}
+
+}
+
+func TestInterpretTrailersLikeGit(t *testing.T) {
+
+	build := buildpath(t)
+	msg := createFile(t, t.TempDir(), "msg.txt", []byte("subject\n\nbody\n\nFoo : a\n  continued\nSigned-off-by: A <a@example.com>\n# comment\n"))
+
+	for _, args := range []string{
+		"--trailer 'Signed-off-by=A <a@example.com>' --trailer reviewed-by:B",
+		"--where start --if-exists replace --trailer foo=b",
+		"--parse",
+	} {
+
+		expect := executeCmd(t, "git interpret-trailers "+args+" < "+msg)
+		if out := executeCmd(t, build+" interpret-trailers "+args+" "+msg); out != expect {
+			t.Errorf("%s: expect %q, got %q", args, expect, out)
+		}
+	}
+
+}
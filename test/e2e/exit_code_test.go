@@ -0,0 +1,51 @@
+package e2e
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+
+	build := buildpath(t)
+
+	repo := initDir(t, build)
+	createFile(t, repo, "debug.log", []byte("debug\n"))
+
+	testt := []struct {
+		description string
+		args        []string
+		code        int
+		stderr      string
+	}{
+		{description: "not a repository", args: []string{"-C", t.TempDir(), "status"}, code: 128, stderr: "fatal: not a git repository"},
+		{description: "no path is ignored", args: []string{"-C", repo, "check-ignore", "debug.log"}, code: 1, stderr: ""},
+		{description: "unknown flag", args: []string{"-C", repo, "status", "--unknown"}, code: 129, stderr: "error: unknown flag: --unknown"},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			cmd := exec.Command(build, tc.args...)
+			stderr := &strings.Builder{}
+			cmd.Stderr = stderr
+
+			var exitErr *exec.ExitError
+			if err := cmd.Run(); !errors.As(err, &exitErr) {
+				t.Fatalf("expect exit error, got %v", err)
+			}
+
+			if exitErr.ExitCode() != tc.code {
+				t.Errorf("expect exit code %d, got %d", tc.code, exitErr.ExitCode())
+			}
+
+			if !strings.HasPrefix(stderr.String(), tc.stderr) || (tc.stderr == "" && stderr.Len() != 0) {
+				t.Errorf("expect stderr %q, got %q", tc.stderr, stderr)
+			}
+
+		})
+	}
+
+}
@@ -54,3 +54,34 @@ func TestInit(t *testing.T) {
 	}
 
 }
+
+func TestInitBare(t *testing.T) {
+
+	build := buildpath(t)
+	dir := t.TempDir()
+
+	out, err := exec.Command(build, "init", "--bare", "-b", "trunk", dir).CombinedOutput()
+	if err != nil {
+		t.Fatal("exec got command failed ", err, string(out))
+	}
+
+	expect := "Initialized empty Got repository in " + dir
+	if string(out) != expect {
+		t.Errorf("expect %s, got %s", expect, out)
+	}
+
+	head, err := os.ReadFile(dir + "/HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(head) != "ref: refs/heads/trunk" {
+		t.Errorf("unexpected HEAD %s", head)
+	}
+
+	out, err = exec.Command(build, "-C", dir, "status").CombinedOutput()
+	if err == nil {
+		t.Errorf("expect status to fail in a bare repository, got %s", out)
+	}
+
+}
@@ -0,0 +1,26 @@
+package e2e
+
+import (
+	"testing"
+)
+
+func TestRm(t *testing.T) {
+
+	build := buildpath(t)
+
+	dir := initDir(t, build)
+
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b/c.txt", []byte("c\n"))
+	executeCmd(t, build+" -C "+dir+" add .")
+
+	out := executeCmd(t, build+" -C "+dir+" rm -f -r b")
+
+	expect := "rm 'b/c.txt'\n"
+	if out != expect {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+	testlsfiles(t, dir, "a.txt\n")
+
+}
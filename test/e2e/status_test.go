@@ -137,3 +137,22 @@ func TestStatusDeletedFiles(t *testing.T) {
 		t.Errorf("expect \"D  a\", but %s", out)
 	}
 }
+
+func TestStatusPathspec(t *testing.T) {
+
+	build := buildpath(t)
+
+	dir := initDir(t, build)
+
+	createFile(t, dir, "services/api/main.go", []byte("package main\n"))
+	createFile(t, dir, "services/web/index.js", []byte("\n"))
+	createFile(t, dir, "README.md", []byte("\n"))
+
+	out := executeCmd(t, build+" -C "+dir+"/services status --porcelain api ':(top)*.md'")
+
+	expect := "?? README.md\n?? services/api/\n"
+	if out != expect {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// タイムゾーンを含む形式
+var zonedDateLayouts = []string{
+	// RFC 2822
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	// git log のデフォルト
+	"Mon Jan 2 15:04:05 2006 -0700",
+	// ISO 8601
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05-0700",
+}
+
+// タイムゾーンがなければローカル時刻として扱う形式
+var localDateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04:05",
+	"Mon Jan 2 15:04:05 2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+var rawDate = regexp.MustCompile(`^@?(\d+)(?: ([+-]\d{4}))?$`)
+
+var relativeUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// ParseDate gitと同じ形式の日時を解釈する。
+// RFC 2822、ISO 8601、`@<unix> <tz>`、`2 weeks ago`のような相対指定を受け付ける
+func ParseDate(s string, now time.Time) (time.Time, error) {
+
+	s = strings.TrimSpace(s)
+
+	if m := rawDate.FindStringSubmatch(s); m != nil && (strings.HasPrefix(s, "@") || m[2] != "") {
+		return parseRawDate(m[1], m[2])
+	}
+
+	for _, layout := range zonedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	for _, layout := range localDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, ok := parseRelativeDate(s, now); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date format: %s", s)
+}
+
+func parseRawDate(unix, zone string) (time.Time, error) {
+
+	sec, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t := time.Unix(sec, 0).UTC()
+	if zone == "" {
+		return t, nil
+	}
+
+	hours, _ := strconv.Atoi(zone[1:3])
+	minutes, _ := strconv.Atoi(zone[3:5])
+
+	offset := hours*60*60 + minutes*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+
+	return t.In(time.FixedZone("", offset)), nil
+}
+
+// parseRelativeDate now、yesterday、`1 day 2 hours ago`、`2.weeks.ago`
+func parseRelativeDate(s string, now time.Time) (time.Time, bool) {
+
+	words := strings.Fields(strings.ReplaceAll(strings.ToLower(s), ".", " "))
+
+	switch {
+	case len(words) == 1 && words[0] == "now":
+		return now, true
+	case len(words) == 1 && words[0] == "yesterday":
+		return now.AddDate(0, 0, -1), true
+	case len(words) < 3 || len(words)%2 == 0 || words[len(words)-1] != "ago":
+		return time.Time{}, false
+	}
+
+	t := now
+	for i := 0; i < len(words)-1; i += 2 {
+
+		n, err := strconv.Atoi(words[i])
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		switch unit := strings.TrimSuffix(words[i+1], "s"); unit {
+		case "month":
+			t = t.AddDate(0, -n, 0)
+		case "year":
+			t = t.AddDate(-n, 0, 0)
+		default:
+			d, ok := relativeUnits[unit]
+			if !ok {
+				return time.Time{}, false
+			}
+			t = t.Add(-time.Duration(n) * d)
+		}
+	}
+
+	return t, true
+}
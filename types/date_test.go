@@ -0,0 +1,59 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+
+	jst := time.FixedZone("", 9*60*60)
+	now := time.Date(2023, 10, 14, 12, 0, 0, 0, jst)
+
+	testt := []struct {
+		date string
+		unix int64
+		zone string
+	}{
+		{date: "Sat, 14 Oct 2023 10:30:00 +0200", unix: 1697272200, zone: "+0200"},
+		{date: "14 Oct 2023 10:30:00 -0500", unix: 1697297400, zone: "-0500"},
+		{date: "Sat Oct 14 10:30:00 2023 +0900", unix: 1697247000, zone: "+0900"},
+		{date: "2023-10-14T10:30:00+09:00", unix: 1697247000, zone: "+0900"},
+		{date: "2023-10-14T10:30:00Z", unix: 1697279400, zone: "+0000"},
+		{date: "2023-10-14 10:30:00 +0900", unix: 1697247000, zone: "+0900"},
+		{date: "2023-10-14 10:30:00", unix: 1697247000, zone: "+0900"},
+		{date: "2023-10-14", unix: 1697209200, zone: "+0900"},
+		{date: "@1697247000 +0900", unix: 1697247000, zone: "+0900"},
+		{date: "1697247000 -0130", unix: 1697247000, zone: "-0130"},
+		{date: "@1697247000", unix: 1697247000, zone: "+0000"},
+		{date: "now", unix: 1697252400, zone: "+0900"},
+		{date: "yesterday", unix: 1697166000, zone: "+0900"},
+		{date: "2 hours ago", unix: 1697245200, zone: "+0900"},
+		{date: "1.week.ago", unix: 1696647600, zone: "+0900"},
+		{date: "1 month 3 days ago", unix: 1694401200, zone: "+0900"},
+	}
+
+	for _, tc := range testt {
+
+		d, err := ParseDate(tc.date, now)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.date, err)
+		}
+
+		if zone := d.Format("-0700"); d.Unix() != tc.unix || zone != tc.zone {
+			t.Errorf("%s: expect %d %s, got %d %s", tc.date, tc.unix, tc.zone, d.Unix(), zone)
+		}
+
+	}
+
+}
+
+func TestParseDateInvalid(t *testing.T) {
+
+	for _, date := range []string{"", "1697247000", "tomorrow", "3 fortnights ago", "ago"} {
+		if _, err := ParseDate(date, time.Now()); err == nil {
+			t.Errorf("%q: expect error", date)
+		}
+	}
+
+}
@@ -0,0 +1,345 @@
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mizuho-u/got/internal"
+)
+
+type Pathspec interface {
+	fmt.Stringer
+	Match(path string) bool
+	MatchFile(path string) bool
+	MatchDir(dir string) bool
+	Unmatched(paths []string) []string
+	IsEmpty() bool
+}
+
+type pathspec struct {
+	items []*pathspecItem
+}
+
+type pathspecItem struct {
+	original string
+	pattern  string
+	exclude  bool
+	icase    bool
+	glob     bool
+	literal  bool
+	re       *regexp.Regexp
+}
+
+// NewPathspec specsをワークスペースルートからのパスに変換する。
+// 相対パスは作業ディレクトリ(cwd)から、:(top) と :/ はルートからのパスとして扱う
+func NewPathspec(root, cwd string, specs ...string) (Pathspec, error) {
+
+	prefix, err := filepath.Rel(root, cwd)
+	if err != nil || strings.HasPrefix(prefix, "..") {
+		return nil, fmt.Errorf("%s is outside repository at %s", cwd, root)
+	}
+
+	ps := &pathspec{items: []*pathspecItem{}}
+
+	for _, s := range specs {
+
+		item, err := parsePathspecItem(root, prefix, s)
+		if err != nil {
+			return nil, err
+		}
+
+		ps.items = append(ps.items, item)
+	}
+
+	return ps, nil
+}
+
+func parsePathspecItem(root, prefix, s string) (*pathspecItem, error) {
+
+	item := &pathspecItem{original: s}
+	top := false
+
+	spec := s
+	switch {
+	case strings.HasPrefix(spec, ":("):
+
+		end := strings.Index(spec, ")")
+		if end == -1 {
+			return nil, fmt.Errorf("missing ')' at the end of pathspec magic in '%s'", s)
+		}
+
+		for _, magic := range strings.Split(spec[2:end], ",") {
+			switch strings.TrimSpace(magic) {
+			case "top":
+				top = true
+			case "exclude":
+				item.exclude = true
+			case "icase":
+				item.icase = true
+			case "glob":
+				item.glob = true
+			case "literal":
+				item.literal = true
+			case "":
+			default:
+				return nil, fmt.Errorf("invalid pathspec magic '%s' in '%s'", magic, s)
+			}
+		}
+
+		spec = spec[end+1:]
+
+	case strings.HasPrefix(spec, ":"):
+
+		// :/ や :! などの短い形式
+		i := 1
+		for ; i < len(spec); i++ {
+			switch spec[i] {
+			case '/':
+				top = true
+				continue
+			case '!', '^':
+				item.exclude = true
+				continue
+			case ':':
+				i++
+			}
+			break
+		}
+
+		spec = spec[i:]
+	}
+
+	if item.glob && item.literal {
+		return nil, fmt.Errorf("'literal' and 'glob' are incompatible in '%s'", s)
+	}
+
+	switch {
+	case filepath.IsAbs(spec):
+
+		rel, err := filepath.Rel(root, spec)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("%s: '%s' is outside repository at '%s'", s, spec, root)
+		}
+		spec = rel
+
+	case !top:
+
+		spec = filepath.Join(prefix, spec)
+		if strings.HasPrefix(spec, "..") {
+			return nil, fmt.Errorf("%s: '%s' is outside repository at '%s'", s, spec, root)
+		}
+
+	default:
+		spec = filepath.Clean("/" + spec)[1:]
+	}
+
+	if spec == "." {
+		spec = ""
+	}
+	item.pattern = spec
+
+	if !item.literal && internal.HasWildcard(spec) {
+
+		re, err := internal.CompileWildmatch(spec, item.glob, item.icase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pathspec '%s': %w", s, err)
+		}
+		item.re = re
+	}
+
+	return item, nil
+}
+
+// match pathかその親ディレクトリがマッチすればtrue
+func (item *pathspecItem) match(path string) bool {
+
+	if item.pattern == "" || item.matchSelf(path) {
+		return true
+	}
+
+	pattern := item.pattern
+	if item.icase {
+		pattern, path = strings.ToLower(pattern), strings.ToLower(path)
+	}
+
+	if strings.HasPrefix(path, pattern+"/") {
+		return true
+	}
+
+	if item.re == nil {
+		return false
+	}
+
+	for _, dir := range internal.ParentDirs(path) {
+		if item.re.MatchString(dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchSelf 親ディレクトリを介さずにpathそのものがマッチすればtrue
+func (item *pathspecItem) matchSelf(path string) bool {
+
+	if item.pattern == "" {
+		return false
+	}
+
+	if item.icase {
+		if strings.EqualFold(path, item.pattern) {
+			return true
+		}
+	} else if path == item.pattern {
+		return true
+	}
+
+	return item.re != nil && item.re.MatchString(path)
+}
+
+// mayMatchInside dirの中にマッチするパスがありうるか
+func (item *pathspecItem) mayMatchInside(dir string) bool {
+
+	if item.pattern == "" || item.match(dir) {
+		return true
+	}
+
+	literal := item.pattern
+	if item.re != nil {
+		// ワイルドカードより前の部分で判定する
+		i := strings.IndexAny(literal, `*?[\`)
+		literal = literal[:i]
+		if i := strings.LastIndex(literal, "/"); i != -1 {
+			literal = literal[:i]
+		} else {
+			return true
+		}
+	}
+
+	if item.icase {
+		literal, dir = strings.ToLower(literal), strings.ToLower(dir)
+	}
+
+	return strings.HasPrefix(literal, dir+"/") || literal == dir || strings.HasPrefix(dir, literal+"/")
+}
+
+// Match includeのどれかにマッチして、excludeのどれにもマッチしなければtrue。
+// includeがなければすべてのパスがincludeされる
+func (ps *pathspec) Match(path string) bool {
+
+	included, hasInclude := false, false
+
+	for _, item := range ps.items {
+
+		if item.exclude {
+			if item.match(path) {
+				return false
+			}
+			continue
+		}
+
+		hasInclude = true
+		if !included && item.match(path) {
+			included = true
+		}
+	}
+
+	return included || !hasInclude
+}
+
+// MatchFile Matchと同じだが、親ディレクトリがマッチしただけのパスはfalse
+func (ps *pathspec) MatchFile(path string) bool {
+
+	if !ps.Match(path) {
+		return false
+	}
+
+	for _, item := range ps.items {
+		if !item.exclude && item.matchSelf(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchDir dirの中にマッチするパスがありうるならtrue。ディレクトリを読み飛ばすのに使う
+func (ps *pathspec) MatchDir(dir string) bool {
+
+	hasInclude := false
+
+	for _, item := range ps.items {
+
+		if item.exclude {
+			if item.re == nil && item.match(dir) {
+				return false
+			}
+			continue
+		}
+
+		hasInclude = true
+		if item.mayMatchInside(dir) {
+			return true
+		}
+	}
+
+	return !hasInclude
+}
+
+// Unmatched pathsのどれにもマッチしなかったincludeを返す
+func (ps *pathspec) Unmatched(paths []string) []string {
+
+	unmatched := []string{}
+
+	for _, item := range ps.items {
+
+		if item.exclude || item.pattern == "" {
+			continue
+		}
+
+		matched := false
+		for _, p := range paths {
+			if item.match(p) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			unmatched = append(unmatched, item.original)
+		}
+	}
+
+	return unmatched
+}
+
+func (ps *pathspec) IsEmpty() bool {
+	return len(ps.items) == 0
+}
+
+func (ps *pathspec) String() string {
+
+	items := []string{}
+	for _, item := range ps.items {
+
+		magic := []string{}
+		if item.exclude {
+			magic = append(magic, "exclude")
+		}
+		if item.icase {
+			magic = append(magic, "icase")
+		}
+		if item.glob {
+			magic = append(magic, "glob")
+		}
+		if item.literal {
+			magic = append(magic, "literal")
+		}
+
+		items = append(items, fmt.Sprintf(":(%s)%s", strings.Join(magic, ","), item.pattern))
+	}
+
+	return strings.Join(items, " ")
+}
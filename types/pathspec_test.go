@@ -0,0 +1,104 @@
+package types
+
+import "testing"
+
+func TestPathspecMatch(t *testing.T) {
+
+	testt := []struct {
+		cwd    string
+		specs  []string
+		path   string
+		expect bool
+	}{
+		{cwd: "/repo", specs: []string{}, path: "a.txt", expect: true},
+		{cwd: "/repo", specs: []string{"."}, path: "a/b.txt", expect: true},
+		{cwd: "/repo", specs: []string{"a"}, path: "a/b.txt", expect: true},
+		{cwd: "/repo", specs: []string{"a"}, path: "ab.txt", expect: false},
+		{cwd: "/repo", specs: []string{"/repo/a/b.txt"}, path: "a/b.txt", expect: true},
+		{cwd: "/repo/a", specs: []string{"b.txt"}, path: "a/b.txt", expect: true},
+		{cwd: "/repo/a", specs: []string{"b.txt"}, path: "b.txt", expect: false},
+		{cwd: "/repo/a", specs: []string{"../c.txt"}, path: "c.txt", expect: true},
+		{cwd: "/repo/a", specs: []string{":/c.txt"}, path: "c.txt", expect: true},
+		{cwd: "/repo/a", specs: []string{":(top)c.txt"}, path: "c.txt", expect: true},
+		{cwd: "/repo", specs: []string{"*.txt"}, path: "a/b.txt", expect: true},
+		{cwd: "/repo", specs: []string{"*.txt"}, path: "a/b.go", expect: false},
+		{cwd: "/repo", specs: []string{":(glob)*.txt"}, path: "a/b.txt", expect: false},
+		{cwd: "/repo", specs: []string{":(glob)**/*.txt"}, path: "a/b.txt", expect: true},
+		{cwd: "/repo", specs: []string{":(literal)*.txt"}, path: "a.txt", expect: false},
+		{cwd: "/repo", specs: []string{":(literal)*.txt"}, path: "*.txt", expect: true},
+		{cwd: "/repo", specs: []string{":(icase)README"}, path: "readme", expect: true},
+		{cwd: "/repo", specs: []string{"a", ":!a/b.txt"}, path: "a/b.txt", expect: false},
+		{cwd: "/repo", specs: []string{"a", ":^a/b.txt"}, path: "a/c.txt", expect: true},
+		{cwd: "/repo", specs: []string{":(exclude)*.go"}, path: "a.txt", expect: true},
+		{cwd: "/repo", specs: []string{":(exclude)*.go"}, path: "a.go", expect: false},
+	}
+
+	for _, tc := range testt {
+
+		ps, err := NewPathspec("/repo", tc.cwd, tc.specs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ps.Match(tc.path) != tc.expect {
+			t.Errorf("%s %v: expect %t for %s", tc.cwd, tc.specs, tc.expect, tc.path)
+		}
+
+	}
+
+}
+
+func TestPathspecMatchDir(t *testing.T) {
+
+	testt := []struct {
+		specs  []string
+		dir    string
+		expect bool
+	}{
+		{specs: []string{"a/b/c.txt"}, dir: "a", expect: true},
+		{specs: []string{"a/b/c.txt"}, dir: "a/b", expect: true},
+		{specs: []string{"a/b/c.txt"}, dir: "d", expect: false},
+		{specs: []string{"a/*.txt"}, dir: "a", expect: true},
+		{specs: []string{"a/*.txt"}, dir: "b", expect: false},
+		{specs: []string{"*.txt"}, dir: "b", expect: true},
+		{specs: []string{":!a"}, dir: "a", expect: false},
+	}
+
+	for _, tc := range testt {
+
+		ps, err := NewPathspec("/repo", "/repo", tc.specs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ps.MatchDir(tc.dir) != tc.expect {
+			t.Errorf("%v: expect %t for %s", tc.specs, tc.expect, tc.dir)
+		}
+
+	}
+
+}
+
+func TestPathspecUnmatched(t *testing.T) {
+
+	ps, err := NewPathspec("/repo", "/repo", "a", "*.go", "b.txt", ":!c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unmatched := ps.Unmatched([]string{"a/a.txt", "c/main.go"})
+	if len(unmatched) != 1 || unmatched[0] != "b.txt" {
+		t.Errorf("expect [b.txt], got %v", unmatched)
+	}
+
+}
+
+func TestInvalidPathspec(t *testing.T) {
+
+	for _, spec := range []string{"../outside", "/tmp/outside", ":(unknown)a", ":(top", ":(glob,literal)a"} {
+		if _, err := NewPathspec("/repo", "/repo", spec); err == nil {
+			t.Errorf("expect error for %s", spec)
+		}
+	}
+
+}
@@ -0,0 +1,140 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RevisionRange rev-listなどに渡すコミットの集合。Includeから到達できて、Excludeから到達できないもの
+type RevisionRange struct {
+	Include []Revision
+	Exclude []Revision
+	// Symmetric A...B の組。AとBを含めて、AとBのマージベースを除く
+	Symmetric [][2]Revision
+}
+
+// NewRevisionRange A..B、A...B、^A、--notを解釈する。--notは後ろの引数の含める、除くを入れ替える
+func NewRevisionRange(args ...string) (*RevisionRange, error) {
+
+	r := &RevisionRange{}
+	not := false
+
+	for _, arg := range args {
+
+		if arg == "--not" {
+			not = !not
+			continue
+		}
+
+		if err := r.add(arg, not); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func (r *RevisionRange) add(arg string, not bool) error {
+
+	if a, b, ok := splitRange(arg, "..."); ok {
+
+		if not {
+			// --notの後ろの対称差は両方を除くだけにする
+			r.Exclude = append(r.Exclude, a, b)
+		} else {
+			r.Symmetric = append(r.Symmetric, [2]Revision{a, b})
+		}
+
+		return nil
+	}
+
+	if a, b, ok := splitRange(arg, ".."); ok {
+
+		r.include(b, not)
+		r.include(a, !not)
+		return nil
+	}
+
+	negative := false
+	if rest, ok := strings.CutPrefix(arg, "^"); ok {
+		arg, negative = rest, true
+	}
+
+	rev, err := NewRevision(arg)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := rev.(*emptyRevision); ok {
+		return fmt.Errorf("invalid revision range '%s'", arg)
+	}
+
+	r.include(rev, negative != not)
+	return nil
+}
+
+func (r *RevisionRange) include(rev Revision, exclude bool) {
+
+	if exclude {
+		r.Exclude = append(r.Exclude, rev)
+	} else {
+		r.Include = append(r.Include, rev)
+	}
+}
+
+// splitRange sepの両側がrevisionならその組。片方が空ならHEAD
+func splitRange(arg, sep string) (Revision, Revision, bool) {
+
+	i := strings.Index(arg, sep)
+	if i == -1 || (sep == ".." && strings.HasPrefix(arg[i:], "...")) {
+		return nil, nil, false
+	}
+
+	// HEAD:../pathのようなパスの..は範囲ではない
+	left, right := arg[:i], arg[i+len(sep):]
+	if (left == "" && right == "") || strings.Contains(left, ":") {
+		return nil, nil, false
+	}
+
+	parse := func(s string) (Revision, error) {
+
+		if s == "" {
+			return &ref{"HEAD"}, nil
+		}
+
+		return parseRevision(s)
+	}
+
+	a, err := parse(left)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	b, err := parse(right)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	return a, b, true
+}
+
+// IsSingle ひとつのrevisionだけで範囲になっていない
+func (r *RevisionRange) IsSingle() bool {
+	return len(r.Include) == 1 && len(r.Exclude) == 0 && len(r.Symmetric) == 0
+}
+
+func (r *RevisionRange) String() string {
+
+	s := []string{}
+	for _, rev := range r.Include {
+		s = append(s, rev.String())
+	}
+	for _, rev := range r.Exclude {
+		s = append(s, "^"+rev.String())
+	}
+	for _, pair := range r.Symmetric {
+		s = append(s, fmt.Sprintf("(symmetric %s %s)", pair[0], pair[1]))
+	}
+
+	return strings.Join(s, " ")
+}
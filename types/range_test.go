@@ -0,0 +1,47 @@
+package types
+
+import "testing"
+
+func TestParseRevisionRange(t *testing.T) {
+
+	testt := []struct {
+		args   []string
+		expect string
+	}{
+		{args: []string{"main"}, expect: "(ref main)"},
+		{args: []string{"main..topic"}, expect: "(ref topic) ^(ref main)"},
+		{args: []string{"main.."}, expect: "(ref HEAD) ^(ref main)"},
+		{args: []string{"..topic"}, expect: "(ref topic) ^(ref HEAD)"},
+		{args: []string{"main...topic"}, expect: "(symmetric (ref main) (ref topic))"},
+		{args: []string{"HEAD~2..HEAD^2"}, expect: "(parent (ref HEAD) 2) ^(ancestor (ref HEAD) 2)"},
+		{args: []string{"topic", "^main"}, expect: "(ref topic) ^(ref main)"},
+		{args: []string{"topic", "--not", "main", "^old"}, expect: "(ref topic) (ref old) ^(ref main)"},
+		{args: []string{"--not", "main..topic"}, expect: "(ref main) ^(ref topic)"},
+		{args: []string{"--not", "main...topic", "--not", "next"}, expect: "(ref next) ^(ref main) ^(ref topic)"},
+		{args: []string{"HEAD:../a..b"}, expect: "(path (ref HEAD) ../a..b)"},
+	}
+
+	for _, tc := range testt {
+
+		r, err := NewRevisionRange(tc.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r.String() != tc.expect {
+			t.Errorf("%v: expect %s, got %s", tc.args, tc.expect, r)
+		}
+	}
+
+}
+
+func TestParseInvalidRevisionRange(t *testing.T) {
+
+	for _, arg := range []string{"..", "^", "main..to^{x}", "^HEAD^{unknown}"} {
+
+		if r, err := NewRevisionRange(arg); err == nil {
+			t.Errorf("expect error with %s, got %s", arg, r)
+		}
+	}
+
+}
@@ -1,9 +1,12 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Revision interface {
@@ -11,22 +14,49 @@ type Revision interface {
 	Resolve(resolver resolver) (ObjectID, error)
 }
 
-const (
-	parentOperator   = `^(.+)\^$`
-	ancestorOperator = `^(.+)~(\d+)$`
+var (
+	parentOperator   = regexp.MustCompile(`^(.+)\^(\d*)$`)
+	ancestorOperator = regexp.MustCompile(`^(.+)~(\d*)$`)
+	peelOperator     = regexp.MustCompile(`^(.+)\^\{([^{}]*)\}$`)
+	reflogOperator   = regexp.MustCompile(`^(.*)@\{([^{}]+)\}$`)
+	indexStage       = regexp.MustCompile(`^:([0-3]):(.*)$`)
 )
 
 var refAlias = map[string]string{
 	"@": "HEAD",
 }
 
+// peelTypes ^{type}で指定できる種類。空ならタグをはがす
+var peelTypes = []string{"", "object", "commit", "tree", "blob", "tag"}
+
 func NewRevision(s string) (Revision, error) {
 
 	if s == "" {
 		return &emptyRevision{}, nil
 	}
 
-	return parseRevision(s)
+	rev, err := parseRevision(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &namedRevision{Revision: rev, name: s}, nil
+}
+
+// namedRevision 利用者が書いたままの文字列を残したrevision
+type namedRevision struct {
+	Revision
+	name string
+}
+
+// RevisionName 利用者が書いたままのrevision。reflogのメッセージなどに使う
+func RevisionName(rev Revision) string {
+
+	if n, ok := rev.(*namedRevision); ok {
+		return n.name
+	}
+
+	return rev.String()
 }
 
 type emptyRevision struct{}
@@ -39,31 +69,101 @@ func (r *emptyRevision) Resolve(resolver resolver) (ObjectID, error) {
 	return "", nil
 }
 
+// parseRevision gitのrevisionsの文法。:で始まるものと rev:path を先に見て、残りは末尾の演算子から分解する
 func parseRevision(s string) (Revision, error) {
 
-	if match := regexp.MustCompile(parentOperator).FindStringSubmatch(s); len(match) != 0 {
+	if pattern, ok := strings.CutPrefix(s, ":/"); ok {
+		return newSearch(nil, pattern)
+	}
+
+	if match := indexStage.FindStringSubmatch(s); match != nil {
+		stage, _ := strconv.Atoi(match[1])
+		return newIndexPath(stage, match[2])
+	}
+
+	if path, ok := strings.CutPrefix(s, ":"); ok {
+		return newIndexPath(0, path)
+	}
+
+	if i := pathSeparator(s); i > 0 {
+
+		rev, err := parseRevision(s[:i])
+		if err != nil {
+			return nil, err
+		}
+
+		return newTreePath(rev, s[i+1:])
+	}
+
+	return parseOperator(s)
+}
+
+// pathSeparator {}の外にある最初の:の位置
+func pathSeparator(s string) int {
+
+	depth := 0
+	for i, c := range s {
+
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func parseOperator(s string) (Revision, error) {
+
+	if match := peelOperator.FindStringSubmatch(s); len(match) != 0 {
+
+		rev, err := parseOperator(match[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if pattern, ok := strings.CutPrefix(match[2], "/"); ok {
+			return newSearch(rev, pattern)
+		}
+
+		return newPeel(rev, match[2])
+
+	} else if match := parentOperator.FindStringSubmatch(s); len(match) != 0 {
+
+		rev, err := parseOperator(match[1])
+		if err != nil {
+			return nil, err
+		}
 
-		rev, err := parseRevision(match[1])
+		n, err := count(match[2])
 		if err != nil {
 			return nil, err
 		}
 
-		return newParent(rev)
+		return newParent(rev, n)
 
-	} else if match := regexp.MustCompile(ancestorOperator).FindStringSubmatch(s); len(match) != 0 {
+	} else if match := ancestorOperator.FindStringSubmatch(s); len(match) != 0 {
 
-		rev, err := parseRevision(match[1])
+		rev, err := parseOperator(match[1])
 		if err != nil {
 			return nil, err
 		}
 
-		n, err := strconv.Atoi(match[2])
+		n, err := count(match[2])
 		if err != nil {
 			return nil, err
 		}
 
 		return newAncestor(rev, n)
 
+	} else if match := reflogOperator.FindStringSubmatch(s); len(match) != 0 {
+		return newReflog(match[1], match[2])
 	} else if ref, err := newRef(s); err == nil {
 		return ref, nil
 	}
@@ -72,6 +172,16 @@ func parseRevision(s string) (Revision, error) {
 
 }
 
+// count ^や~の後ろの数字。省略すれば1
+func count(s string) (int, error) {
+
+	if s == "" {
+		return 1, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
 type ref struct {
 	name string
 }
@@ -97,16 +207,23 @@ func (r *ref) Resolve(resolver resolver) (ObjectID, error) {
 	return resolver.Ref(r.name)
 }
 
+// parent n番目の親。0ならコミットそのもの
 type parent struct {
 	rev Revision
+	n   int
 }
 
-func newParent(rev Revision) (*parent, error) {
-	return &parent{rev}, nil
+func newParent(rev Revision, n int) (*parent, error) {
+	return &parent{rev, n}, nil
 }
 
 func (p *parent) String() string {
-	return fmt.Sprintf("(parent %s)", p.rev)
+
+	if p.n == 1 {
+		return fmt.Sprintf("(parent %s)", p.rev)
+	}
+
+	return fmt.Sprintf("(parent %s %d)", p.rev, p.n)
 }
 
 func (p *parent) Resolve(resolver resolver) (ObjectID, error) {
@@ -116,7 +233,7 @@ func (p *parent) Resolve(resolver resolver) (ObjectID, error) {
 		return "", err
 	}
 
-	return resolver.Parent(oid)
+	return resolver.Parent(oid, p.n)
 }
 
 type ancestor struct {
@@ -139,9 +256,15 @@ func (a *ancestor) Resolve(resolver resolver) (ObjectID, error) {
 		return "", err
 	}
 
+	// ~0でもコミットにする
+	oid, err = resolver.Parent(oid, 0)
+	if err != nil {
+		return "", err
+	}
+
 	for i := 0; i < a.n; i++ {
 
-		oid, err = resolver.Parent(oid)
+		oid, err = resolver.Parent(oid, 1)
 		if err != nil {
 			return "", err
 		}
@@ -151,7 +274,212 @@ func (a *ancestor) Resolve(resolver resolver) (ObjectID, error) {
 	return oid, nil
 }
 
+// peel ^{type}。タグをたどってtypeのオブジェクトにする
+type peel struct {
+	rev   Revision
+	class string
+}
+
+func newPeel(rev Revision, class string) (*peel, error) {
+
+	for _, t := range peelTypes {
+		if t == class {
+			return &peel{rev, class}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid object type '%s' in ^{}", class)
+}
+
+func (p *peel) String() string {
+
+	if p.class == "" {
+		return fmt.Sprintf("(peel %s)", p.rev)
+	}
+
+	return fmt.Sprintf("(peel %s %s)", p.rev, p.class)
+}
+
+func (p *peel) Resolve(resolver resolver) (ObjectID, error) {
+
+	oid, err := p.rev.Resolve(resolver)
+	if err != nil {
+		return "", err
+	}
+
+	return resolver.Peel(oid, p.class)
+}
+
+// search :/patternと rev^{/pattern}。メッセージがpatternにマッチする一番新しいコミット
+type search struct {
+	rev     Revision
+	pattern *regexp.Regexp
+}
+
+func newSearch(rev Revision, pattern string) (*search, error) {
+
+	if pattern == "" {
+		return nil, errors.New("empty commit message pattern")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message pattern '%s': %w", pattern, err)
+	}
+
+	return &search{rev, re}, nil
+}
+
+func (s *search) String() string {
+
+	if s.rev == nil {
+		return fmt.Sprintf("(search %s)", s.pattern)
+	}
+
+	return fmt.Sprintf("(search %s %s)", s.rev, s.pattern)
+}
+
+func (s *search) Resolve(resolver resolver) (ObjectID, error) {
+
+	if s.rev == nil {
+		return resolver.Search("", s.pattern)
+	}
+
+	oid, err := s.rev.Resolve(resolver)
+	if err != nil {
+		return "", err
+	}
+
+	return resolver.Search(oid, s.pattern)
+}
+
+// treePath rev:path。revのツリーにあるpathのオブジェクト
+type treePath struct {
+	rev  Revision
+	path string
+}
+
+func newTreePath(rev Revision, path string) (*treePath, error) {
+	return &treePath{rev, path}, nil
+}
+
+func (t *treePath) String() string {
+	return fmt.Sprintf("(path %s %s)", t.rev, t.path)
+}
+
+func (t *treePath) Resolve(resolver resolver) (ObjectID, error) {
+
+	oid, err := t.rev.Resolve(resolver)
+	if err != nil {
+		return "", err
+	}
+
+	return resolver.TreeEntry(oid, t.path)
+}
+
+// indexPath :path と :stage:path。インデックスにあるpathのオブジェクト
+type indexPath struct {
+	stage int
+	path  string
+}
+
+func newIndexPath(stage int, path string) (*indexPath, error) {
+
+	if path == "" {
+		return nil, errors.New("empty path in the index")
+	}
+
+	return &indexPath{stage, path}, nil
+}
+
+func (i *indexPath) String() string {
+	return fmt.Sprintf("(index %d %s)", i.stage, i.path)
+}
+
+func (i *indexPath) Resolve(resolver resolver) (ObjectID, error) {
+	return resolver.IndexEntry(i.stage, i.path)
+}
+
+// reflog ref@{n}、ref@{date}、ref@{upstream}。refが空なら今のブランチ
+type reflog struct {
+	ref      string
+	n        int
+	date     string
+	upstream bool
+}
+
+func newReflog(name, spec string) (*reflog, error) {
+
+	if name != "" {
+
+		r, err := newRef(name)
+		if err != nil {
+			return nil, err
+		}
+		name = r.name
+	}
+
+	switch lower := strings.ToLower(spec); {
+	case lower == "u" || lower == "upstream":
+		return &reflog{ref: name, upstream: true}, nil
+	case regexp.MustCompile(`^\d+$`).MatchString(spec):
+		n, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, err
+		}
+		return &reflog{ref: name, n: n}, nil
+	}
+
+	if _, err := ParseDate(spec, time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid reflog selector '@{%s}'", spec)
+	}
+
+	return &reflog{ref: name, date: spec}, nil
+}
+
+func (r *reflog) String() string {
+
+	switch {
+	case r.upstream:
+		return fmt.Sprintf("(upstream %s)", r.ref)
+	case r.date != "":
+		return fmt.Sprintf("(reflog %s %s)", r.ref, r.date)
+	default:
+		return fmt.Sprintf("(reflog %s %d)", r.ref, r.n)
+	}
+}
+
+func (r *reflog) Resolve(resolver resolver) (ObjectID, error) {
+
+	switch {
+	case r.upstream:
+		return resolver.Upstream(r.ref)
+	case r.date != "":
+
+		when, err := ParseDate(r.date, time.Now())
+		if err != nil {
+			return "", err
+		}
+
+		return resolver.ReflogAt(r.ref, when)
+	default:
+		return resolver.Reflog(r.ref, r.n)
+	}
+}
+
 type resolver interface {
+	// Ref 参照名かoidの先頭。コミット以外のオブジェクトでもいい
 	Ref(name string) (ObjectID, error)
-	Parent(oid ObjectID) (ObjectID, error)
+	// Parent n番目の親。0ならoidをコミットにしたもの
+	Parent(oid ObjectID, n int) (ObjectID, error)
+	// Peel classのオブジェクトになるまでタグやコミットをたどる。classが空ならタグだけ
+	Peel(oid ObjectID, class string) (ObjectID, error)
+	// Search fromから、fromが空ならすべての参照からたどって、メッセージがマッチするコミット
+	Search(from ObjectID, pattern *regexp.Regexp) (ObjectID, error)
+	TreeEntry(oid ObjectID, path string) (ObjectID, error)
+	IndexEntry(stage int, path string) (ObjectID, error)
+	// Reflog refがn回前に指していたもの。refが空なら今のブランチ
+	Reflog(ref string, n int) (ObjectID, error)
+	ReflogAt(ref string, when time.Time) (ObjectID, error)
+	Upstream(ref string) (ObjectID, error)
 }
@@ -13,6 +13,20 @@ func TestParseRevision(t *testing.T) {
 		{revision: "HEAD~42", expect: "(ancestor (ref HEAD) 42)"},
 		{revision: "master^^", expect: "(parent (parent (ref master)))"},
 		{revision: "abc123~3", expect: "(ancestor (ref abc123) 3)"},
+		{revision: "HEAD^2~", expect: "(ancestor (parent (ref HEAD) 2) 1)"},
+		{revision: "HEAD^0", expect: "(parent (ref HEAD) 0)"},
+		{revision: "v1.0^{commit}", expect: "(peel (ref v1.0) commit)"},
+		{revision: "v1.0^{}", expect: "(peel (ref v1.0))"},
+		{revision: "HEAD^{/fix: bug}", expect: "(search (ref HEAD) fix: bug)"},
+		{revision: ":/fix bug", expect: "(search fix bug)"},
+		{revision: "HEAD:cmd/root.go", expect: "(path (ref HEAD) cmd/root.go)"},
+		{revision: "HEAD~2^{tree}:", expect: "(path (peel (ancestor (ref HEAD) 2) tree) )"},
+		{revision: ":cmd/root.go", expect: "(index 0 cmd/root.go)"},
+		{revision: ":2:cmd/root.go", expect: "(index 2 cmd/root.go)"},
+		{revision: "@{1}", expect: "(reflog  1)"},
+		{revision: "main@{2023-10-14 10:30:00}^", expect: "(parent (reflog main 2023-10-14 10:30:00))"},
+		{revision: "@{u}", expect: "(upstream )"},
+		{revision: "main@{upstream}~1", expect: "(ancestor (upstream main) 1)"},
 	}
 
 	for _, tc := range testt {
@@ -29,3 +43,14 @@ func TestParseRevision(t *testing.T) {
 	}
 
 }
+
+func TestParseInvalidRevision(t *testing.T) {
+
+	for _, revision := range []string{"HEAD^{unknown}", "main@{someday}", ":/", ":/(", ":", "ma..in", "HEAD^x"} {
+
+		if rev, err := parseRevision(revision); err == nil {
+			t.Errorf("expect error with %s, got %s", revision, rev)
+		}
+	}
+
+}
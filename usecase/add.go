@@ -1,22 +1,50 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mizuho-u/got/internal"
 	"github.com/mizuho-u/got/io/database"
 	"github.com/mizuho-u/got/io/workspace"
 	"github.com/mizuho-u/got/repository"
 )
 
+var ErrNothingSpecified = errors.New("Nothing specified, nothing added.")
+
 func Add(ctx GotContextReaderWriter, paths ...string) error {
 
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return ErrNothingSpecified
+	}
+
+	for _, path := range paths {
+		if err := statOutside(ctx, path); err != nil {
+			return err
+		}
+	}
+
+	pathspec, err := newPathspec(ctx, paths...)
+	if err != nil {
+		return err
+	}
+
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 	defer db.Close()
 
-	err := db.Index().OpenForUpdate()
+	err = openIndexForUpdate(db)
 	if err != nil {
 		return err
 	}
 
-	opt := []repository.WorkspaceOption{}
+	opt := []repository.WorkspaceOption{repository.WithPathspec(pathspec)}
 	if !db.Index().IsNew() {
 		opt = append(opt, repository.WithIndex(db.Index()))
 	}
@@ -25,20 +53,32 @@ func Add(ctx GotContextReaderWriter, paths ...string) error {
 		return err
 	}
 
-	for _, path := range paths {
+	opts, err := scanOptions(ctx, db, repo.Tracked)
+	if err != nil {
+		return err
+	}
 
-		scanner, err := workspace.Scan(ctx.WorkspaceRoot(), path, ctx.GotRoot())
-		if err != nil {
-			return err
-		}
+	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot(), append(opts, workspace.WithPathspec(pathspec))...)
+	if err != nil {
+		return err
+	}
 
-		objects, err := repo.Add(scanner)
-		if err != nil {
+	objects, err := repo.Add(scanner)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range repo.UnmatchedPathspec() {
+		if err := unmatchedError(ctx, spec, opts...); err != nil {
 			return err
 		}
+	}
 
-		db.Objects().Store(objects...)
+	// ワークスペースから消えた追跡済みファイルは削除をステージする
+	repo.RemoveDeleted()
 
+	if err := db.Objects().Store(objects...); err != nil {
+		return err
 	}
 
 	if err := db.Index().Update(repo.Index()); err != nil {
@@ -47,3 +87,41 @@ func Add(ctx GotContextReaderWriter, paths ...string) error {
 
 	return nil
 }
+
+// statOutside ワークスペースの外にある存在しないパスはpathspecとして解釈する前にエラーにする
+func statOutside(ctx GotContextReader, spec string) error {
+
+	if strings.HasPrefix(spec, ":") || internal.HasWildcard(spec) {
+		return nil
+	}
+
+	path := spec
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.WorkingDirectory(), path)
+	}
+
+	if rel, err := filepath.Rel(ctx.WorkspaceRoot(), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	_, err := os.Stat(path)
+
+	return err
+}
+
+// unmatchedError 存在するパスがマッチしなかったのは除外ルールで無視されているか、空のディレクトリだから
+func unmatchedError(ctx GotContextReader, spec string, opts ...workspace.ScanOption) error {
+
+	path := spec
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.WorkingDirectory(), path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("pathspec '%s' did not match any files", spec)
+	}
+
+	_, err := workspace.Scan(ctx.WorkspaceRoot(), path, ctx.GotRoot(), opts...)
+
+	return err
+}
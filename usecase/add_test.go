@@ -111,6 +111,49 @@ func TestOtherProcessesLockingTheIndex(t *testing.T) {
 
 }
 
+func TestAddPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b.go", []byte("b\n"))
+	createFile(t, dir, "c/d.txt", []byte("d\n"))
+	createFile(t, dir, "c/e.txt", []byte("e\n"))
+
+	add(t, dir, "*.txt", ":!c/e.txt")
+
+	testlsfiles(t, dir, "a.txt\nc/d.txt\n")
+
+}
+
+func TestAddStagesDeletion(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b.txt", []byte("b\n"))
+	add(t, dir, ".")
+
+	removeAll(t, dir, "a.txt")
+	add(t, dir, ".")
+
+	testlsfiles(t, dir, "b.txt\n")
+
+}
+
+func TestAddUnmatchedPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+
+	out := &bytes.Buffer{}
+	err := usecase.Add(newContext(dir, "", "", out, out), "a.txt", "*.go")
+	if err == nil || err.Error() != "pathspec '*.go' did not match any files" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	testlsfiles(t, dir, "")
+
+}
+
 func testlsfiles(t *testing.T, dir string, expect string) {
 
 	t.Helper()
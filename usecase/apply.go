@@ -0,0 +1,610 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
+	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
+)
+
+// squelchWhitespaceErrors gitと同じく、空白の誤りはこの数まで出す
+const squelchWhitespaceErrors = 5
+
+type ApplyOptions struct {
+	// Check 当てられるか確かめるだけで、ファイルは変えない
+	Check bool
+	// Index ワークスペースとインデックスの両方に当てる
+	Index bool
+	// ThreeWay index行のblobを元に3-wayマージし、できなければそのまま当てる。Indexも有効にする
+	ThreeWay bool
+	Reverse  bool
+	Verbose  bool
+	// MinContext 当てる場所が見つからなければ、前後の変更のない行をこの数まで減らす。負なら減らさない
+	MinContext int
+	// Strip パスから取り除くディレクトリの数
+	Strip int
+	// Whitespace nowarn、warn、error、error-allのいずれか。空ならapply.whitespaceに従う
+	Whitespace string
+	// AllowEmpty パッチがひとつもなくてもエラーにしない
+	AllowEmpty bool
+}
+
+// PatchInput パッチの入力。Nameは空白の誤りに出す名前
+type PatchInput struct {
+	Name string
+	Data []byte
+}
+
+// ApplyInputError パッチが読めなかった。gitと同じく error: を付けて終了コード128にする
+type ApplyInputError struct {
+	Err error
+}
+
+func (e *ApplyInputError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ApplyInputError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ApplyInputError) ExitCode() int {
+	return ExitFatal
+}
+
+// ApplyFailedError 当てられないパッチがあった、または3-wayマージで衝突した。理由はすでに出力している
+type ApplyFailedError struct{}
+
+func (e *ApplyFailedError) Error() string {
+	return "patch does not apply"
+}
+
+func (e *ApplyFailedError) ExitCode() int {
+	return ExitError
+}
+
+// WhitespaceApplyError --whitespace=errorで空白の誤りがあったので当てなかった。gitと同じく error: を付けて終了コード128にする
+type WhitespaceApplyError struct {
+	Lines int
+}
+
+func (e *WhitespaceApplyError) Error() string {
+
+	if e.Lines == 1 {
+		return "1 line adds whitespace errors."
+	}
+
+	return fmt.Sprintf("%d lines add whitespace errors.", e.Lines)
+}
+
+func (e *WhitespaceApplyError) ExitCode() int {
+	return ExitFatal
+}
+
+// Apply inputsのパッチをワークスペースに当てる。gitと同じく、すべてのパッチを確かめてから書き込むので、
+// ひとつでも当てられなければ何も変えない
+func Apply(ctx GotContextReaderWriter, opts ApplyOptions, inputs ...PatchInput) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	if opts.ThreeWay {
+		opts.Index = true
+	}
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	action := opts.Whitespace
+	if action == "" {
+		action, _ = db.Config().Get("apply.whitespace")
+	}
+	switch action {
+	case "":
+		action = "warn"
+	case "nowarn", "warn", "error", "error-all":
+	default:
+		return fmt.Errorf("unrecognized whitespace option '%s'", action)
+	}
+
+	v, _ := db.Config().Get("core.whitespace")
+	ws, err := repository.ParseWhitespace(v)
+	if err != nil {
+		return err
+	}
+
+	patches := []*repository.Patch{}
+	wsErrors := []*repository.WhitespaceError{}
+	for _, input := range inputs {
+
+		parsed, err := repository.ParsePatch(input.Data, opts.Strip)
+		if err != nil {
+			return &ApplyInputError{Err: err}
+		}
+
+		if len(parsed) == 0 && !opts.AllowEmpty {
+			return &ApplyInputError{Err: errors.New(`No valid patches in input (allow with "--allow-empty")`)}
+		}
+
+		for _, p := range parsed {
+
+			if opts.Reverse {
+				p = p.Reverse()
+			}
+
+			// 空白の誤りは実際に当てるときだけ調べる
+			if !opts.Check && action != "nowarn" {
+				wsErrors = append(wsErrors, p.CheckWhitespace(ws, input.Name)...)
+			}
+
+			// gitと同じく、サブディレクトリでは従来の形式のパスをそこからのパスにし、外のパスへのパッチは当てない
+			if prefix := workingPrefix(ctx); prefix != "" {
+
+				if !p.TopLevel() {
+					p.OldPath, p.NewPath = path.Join(prefix, p.OldPath), path.Join(prefix, p.NewPath)
+				}
+
+				if !strings.HasPrefix(p.NewPath, prefix+"/") {
+
+					if opts.Verbose {
+						ctx.OutError(fmt.Errorf("Skipped patch '%s'.", p.Name()))
+					}
+					continue
+				}
+			}
+
+			patches = append(patches, p)
+		}
+	}
+
+	squelch := squelchWhitespaceErrors
+	if action == "error-all" {
+		squelch = len(wsErrors)
+	}
+	for i, e := range wsErrors {
+		if i < squelch {
+			ctx.OutError(fmt.Errorf("%s\n%s", e, e.Text))
+		}
+	}
+
+	if len(wsErrors) > 0 {
+
+		if squelched := len(wsErrors) - squelch; squelched > 0 {
+
+			if squelched == 1 {
+				ctx.OutError(errors.New("warning: squelched 1 whitespace error"))
+			} else {
+				ctx.OutError(fmt.Errorf("warning: squelched %d whitespace errors", squelched))
+			}
+		}
+
+		if action == "error" || action == "error-all" {
+			return &WhitespaceApplyError{Lines: len(wsErrors)}
+		}
+	}
+
+	index, err := repository.NewIndex()
+	if err != nil {
+		return err
+	}
+
+	if opts.Index {
+
+		if opts.Check {
+			err = db.Index().OpenForRead()
+		} else {
+			err = openIndexForUpdate(db)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !db.Index().IsNew() {
+			if index, err = repository.NewIndex(repository.IndexSource(db.Index())); err != nil {
+				return err
+			}
+		}
+	}
+
+	a := &applier{ctx: ctx, opts: opts, db: db, ws: workspace.New(ctx.WorkspaceRoot()), index: index, files: map[string]*applyTarget{}}
+
+	results := []*applyResult{}
+	failed := false
+	for _, p := range patches {
+
+		result, err := a.check(p)
+		if err != nil {
+			ctx.OutError(fmt.Errorf("error: %w", err))
+			failed = true
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	if failed {
+		return &ApplyFailedError{}
+	}
+
+	if opts.Check {
+		return nil
+	}
+
+	conflicts, err := a.write(results)
+	if err != nil {
+		return err
+	}
+
+	if opts.Index {
+		if err := db.Index().Update(index); err != nil {
+			return err
+		}
+	}
+
+	if len(wsErrors) == 1 {
+		ctx.OutError(errors.New("warning: 1 line adds whitespace errors."))
+	} else if len(wsErrors) > 1 {
+		ctx.OutError(fmt.Errorf("warning: %d lines add whitespace errors.", len(wsErrors)))
+	}
+
+	if len(conflicts) > 0 {
+
+		sort.Strings(conflicts)
+		for _, c := range conflicts {
+			ctx.OutError(fmt.Errorf("U %s", c))
+		}
+
+		return &ApplyFailedError{}
+	}
+
+	return nil
+}
+
+// applyTarget 前のパッチを当てた後のファイル。deletedなら削除した
+type applyTarget struct {
+	data    []byte
+	mode    object.Permission
+	deleted bool
+}
+
+// applyResult 確かめたパッチと、当てた後の内容
+type applyResult struct {
+	patch      *repository.Patch
+	data       []byte
+	mode       object.Permission
+	conflicted bool
+}
+
+type applier struct {
+	ctx   GotContextReaderWriter
+	opts  ApplyOptions
+	db    database.Database
+	ws    repository.Workspace
+	index repository.Index
+	// files 確かめたパッチを当てた後のファイル。同じファイルへの続くパッチはこれに当てる
+	files map[string]*applyTarget
+}
+
+// load pathの今の内容。前のパッチで変えていればその結果を、--indexならインデックスと一致するかも確かめる
+func (a *applier) load(name string) ([]byte, object.Permission, error) {
+
+	if t, ok := a.files[name]; ok {
+
+		if t.deleted {
+			return nil, "", fmt.Errorf("%s: No such file or directory", name)
+		}
+
+		return t.data, t.mode, nil
+	}
+
+	entry, indexed := a.index.Get(name)
+	if a.opts.Index && !indexed {
+		return nil, "", fmt.Errorf("%s: does not exist in index", name)
+	}
+
+	stat, err := a.ws.Stat(name)
+	if err != nil || stat.IsDir() {
+		return nil, "", fmt.Errorf("%s: No such file or directory", name)
+	}
+
+	f, err := a.ws.Open(name)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if a.opts.Index {
+
+		blob, err := object.NewBlob(name, data)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if blob.OID() != entry.TreeEntry().OID() {
+			return nil, "", fmt.Errorf("%s: does not match index", name)
+		}
+	}
+
+	return data, stat.Permission(), nil
+}
+
+// exists 前のパッチを当てた後に、pathがワークスペースか--indexならインデックスにあるか
+func (a *applier) exists(name string) error {
+
+	if t, ok := a.files[name]; ok {
+
+		if t.deleted {
+			return nil
+		}
+
+		return fmt.Errorf("%s: already exists in working directory", name)
+	}
+
+	if _, ok := a.index.Get(name); a.opts.Index && ok {
+		return fmt.Errorf("%s: already exists in index", name)
+	}
+
+	if _, err := a.ws.Stat(name); err == nil {
+		return fmt.Errorf("%s: already exists in working directory", name)
+	}
+
+	return nil
+}
+
+// check パッチを当てられるか確かめ、当てた後の内容を返す。ファイルはまだ変えない
+func (a *applier) check(p *repository.Patch) (*applyResult, error) {
+
+	if a.opts.Verbose {
+		a.ctx.OutError(fmt.Errorf("Checking patch %s...", p.Name()))
+	}
+
+	var data []byte
+	mode := p.OldMode
+	if !p.New {
+
+		current, currentMode, err := a.load(p.OldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.OldMode != "" && p.OldMode != currentMode {
+			a.ctx.OutError(fmt.Errorf("warning: %s has type %s, expected %s", p.OldPath, currentMode, p.OldMode))
+		}
+
+		data, mode = current, currentMode
+	}
+
+	if p.New || (p.OldPath != p.NewPath && (p.Renamed || p.Copied)) {
+		if err := a.exists(p.NewPath); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &applyResult{patch: p, mode: mode}
+	if p.NewMode != "" {
+		result.mode = p.NewMode
+	}
+	if result.mode == "" {
+		result.mode = object.RegularFile
+	}
+
+	threeway := false
+	// 内容を変えないパッチはマージするものがないので、そのまま当てる
+	if a.opts.ThreeWay && !p.Deleted && !p.New && p.HasChanges() {
+
+		merged, conflicted, err := a.threeway(p, data)
+		if err == nil {
+			result.data, result.conflicted, threeway = merged, conflicted, true
+		} else if !errors.Is(err, errThreewayFailed) {
+			a.ctx.OutError(fmt.Errorf("error: %w", err))
+		}
+	}
+
+	if !threeway {
+
+		if a.opts.ThreeWay {
+			a.ctx.OutError(errors.New("Falling back to direct application..."))
+		}
+
+		applied, err := a.applyHunks(p, data)
+		if err != nil {
+			return nil, err
+		}
+		result.data = applied
+	}
+
+	if p.Deleted && len(result.data) > 0 {
+		return nil, fmt.Errorf("removal patch leaves file contents")
+	}
+
+	if p.Renamed && p.OldPath != p.NewPath {
+		a.files[p.OldPath] = &applyTarget{deleted: true}
+	}
+	a.files[p.NewPath] = &applyTarget{data: result.data, mode: result.mode, deleted: p.Deleted}
+
+	return result, nil
+}
+
+// errThreewayFailed 3-wayマージの前にパッチをbaseに当てられなかった。理由はすでに出力している
+var errThreewayFailed = errors.New("failed to apply the patch to the base")
+
+// threeway index行のIDのblobをbaseにし、パッチを当てたものをtheirs、今の内容をoursにしてマージする
+func (a *applier) threeway(p *repository.Patch, ours []byte) ([]byte, bool, error) {
+
+	lacks := errors.New("repository lacks the necessary blob to perform 3-way merge.")
+
+	// index行がなければbaseがわからない
+	if len(p.OldOID) < 4 {
+		return nil, false, lacks
+	}
+
+	objects, err := a.db.Objects().LoadPrefix(p.OldOID)
+	if err != nil || len(objects) != 1 || objects[0].Class() != object.ClassBlob {
+		return nil, false, lacks
+	}
+
+	theirs, err := a.applyHunks(p, objects[0].Data())
+	if err != nil {
+		a.ctx.OutError(fmt.Errorf("error: %w", err))
+		return nil, false, errThreewayFailed
+	}
+
+	merged, conflicted := repository.Merge3(objects[0].Data(), ours, theirs, "ours", "theirs")
+	if conflicted {
+		a.ctx.OutError(fmt.Errorf("Applied patch to '%s' with conflicts.", p.NewPath))
+	} else {
+		a.ctx.OutError(fmt.Errorf("Applied patch to '%s' cleanly.", p.NewPath))
+	}
+
+	return merged, conflicted, nil
+}
+
+// applyHunks ハンクをdataに当て、gitと同じくずらした位置と減らした変更のない行を出す
+func (a *applier) applyHunks(p *repository.Patch, data []byte) ([]byte, error) {
+
+	options := []repository.ApplyOption{}
+	if a.opts.MinContext >= 0 {
+		options = append(options, repository.ApplyMinContext(a.opts.MinContext))
+	}
+
+	applied, placements, err := p.Apply(data, options...)
+
+	var failed *repository.PatchFailedError
+	if errors.As(err, &failed) {
+
+		if a.opts.Verbose {
+			a.ctx.OutError(fmt.Errorf("error: while searching for:\n%s", failed.Preimage))
+		}
+		a.ctx.OutError(fmt.Errorf("error: %w", err))
+
+		return nil, fmt.Errorf("%s: patch does not apply", failed.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pl := range placements {
+
+		if a.opts.Verbose && pl.Moved() {
+
+			offset := pl.Offset
+			if a.opts.Reverse {
+				offset = -offset
+			}
+
+			unit := "lines"
+			if offset == 1 || offset == -1 {
+				unit = "line"
+			}
+			a.ctx.OutError(fmt.Errorf("Hunk #%d succeeded at %d (offset %d %s).", pl.Hunk, pl.At, offset, unit))
+		}
+
+		if pl.Reduced {
+			a.ctx.OutError(fmt.Errorf("Context reduced to (%d/%d) to apply fragment at %d", pl.Leading, pl.Trailing, pl.At))
+		}
+	}
+
+	return applied, nil
+}
+
+// write 確かめたパッチの結果をワークスペースに書き、--indexならインデックスも変える。
+// インデックスには衝突のステージがないので、衝突したファイルはワークスペースだけを変える
+func (a *applier) write(results []*applyResult) (conflicts []string, err error) {
+
+	// gitと同じく、先に削除と名前の変更の元を消してから書き込む
+	removed := []string{}
+	for _, r := range results {
+
+		p := r.patch
+		if p.Deleted || (p.Renamed && p.OldPath != p.NewPath) {
+
+			removed = append(removed, p.OldPath)
+			if a.opts.Index {
+				a.index.Delete(p.OldPath)
+			}
+		}
+	}
+
+	if err := removeFiles(a.ws, removed); err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+
+		p := r.patch
+		if !p.Deleted {
+
+			stat, err := a.writeFile(p.NewPath, r.data, r.mode)
+			if err != nil {
+				return nil, err
+			}
+
+			if a.opts.Index && !r.conflicted {
+
+				blob, err := object.NewBlob(p.NewPath, r.data)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := a.db.Objects().Store(blob); err != nil {
+					return nil, err
+				}
+
+				a.index.Add(repository.NewIndexEntry(p.NewPath, blob.OID(), stat.Stats()))
+			}
+		}
+
+		if r.conflicted {
+			conflicts = append(conflicts, p.NewPath)
+		}
+
+		if a.opts.Verbose {
+			a.ctx.OutError(fmt.Errorf("Applied patch %s cleanly.", p.Name()))
+		}
+	}
+
+	return conflicts, nil
+}
+
+// writeFile nameをdataで置き換える
+func (a *applier) writeFile(name string, data []byte, mode object.Permission) (repository.WorkspaceFileStat, error) {
+
+	if err := a.ws.RemoveFile(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	f, err := a.ws.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return a.ws.Stat(name)
+}
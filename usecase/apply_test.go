@@ -0,0 +1,235 @@
+package usecase_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func seqLines(from, to int) string {
+
+	s := ""
+	for i := from; i <= to; i++ {
+		s += fmt.Sprintf("%d\n", i)
+	}
+
+	return s
+}
+
+const applyTestPatch = `diff --git a/f b/f
+index 0ff3bbb..c9fbe27 100644
+--- a/f
++++ b/f
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five 
+ 6
+ 7
+ 8
+diff --git a/d b/d
+deleted file mode 100644
+index 422c2b7..0000000
+--- a/d
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+diff --git a/n b/n
+new file mode 100644
+index 0000000..3e75765
+--- /dev/null
++++ b/n
+@@ -0,0 +1 @@
++new
+`
+
+func TestApplyLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "f", []byte(seqLines(1, 20))))
+	add(t, dir, createFile(t, dir, "d", []byte("a\nb\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	// ずれた位置に当てる
+	add(t, dir, createFile(t, dir, "f", []byte("x\ny\n"+seqLines(1, 20))))
+
+	patchFile := createFile(t, t.TempDir(), "p.diff", []byte(applyTestPatch))
+	input := usecase.PatchInput{Name: patchFile, Data: []byte(applyTestPatch)}
+
+	cmd := exec.Command("git", "apply", "--check", "--index", "-v", patchFile)
+	cmd.Dir = dir
+	expect, _ := cmd.CombinedOutput()
+
+	out := &bytes.Buffer{}
+	if err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{Check: true, Index: true, Verbose: true, MinContext: -1, Strip: 1}, input); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != string(expect) {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+	out.Reset()
+	if err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{Index: true, MinContext: -1, Strip: 1}, input); err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := patchFile + ":10: trailing whitespace.\nfive \nwarning: 1 line adds whitespace errors.\n"; out.String() != expect {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "f")); string(data) != "x\ny\n"+strings.Replace(seqLines(1, 20), "5\n", "five \n", 1) {
+		t.Errorf("unexpected f\n%s", data)
+	}
+	if exists(dir, "d") {
+		t.Error("expect d to be removed")
+	}
+
+	status, _ := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if string(status) != "D  d\nM  f\nA  n\n" {
+		t.Errorf("unexpected status \n%s", status)
+	}
+
+	// 当てた後は同じパッチは当てられず、何も変えない
+	cmd = exec.Command("git", "apply", "--check", "--index", patchFile)
+	cmd.Dir = dir
+	expect, _ = cmd.CombinedOutput()
+
+	out.Reset()
+	err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{Index: true, MinContext: -1, Strip: 1}, input)
+	if msg, code := usecase.Report(err); msg != "" || code != usecase.ExitError {
+		t.Errorf("unexpected report %q %d", msg, code)
+	}
+
+	if lines := strings.SplitAfter(out.String(), "\n"); strings.Join(lines[2:], "") != string(expect) {
+		t.Errorf("expect \n%s, got \n%s", expect, out)
+	}
+
+	// 戻せば元どおりになる
+	out.Reset()
+	if err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{Index: true, Reverse: true, MinContext: -1, Strip: 1}, input); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	status, _ = exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if string(status) != "M  f\n" {
+		t.Errorf("unexpected status \n%s", status)
+	}
+
+	for _, tc := range []struct {
+		data string
+		msg  string
+	}{
+		{"garbage\n", `error: No valid patches in input (allow with "--allow-empty")`},
+		{"--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n 1\n", "error: corrupt patch at line 5"},
+	} {
+
+		err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{MinContext: -1, Strip: 1}, usecase.PatchInput{Name: "<stdin>", Data: []byte(tc.data)})
+		if msg, code := usecase.Report(err); msg != tc.msg || code != usecase.ExitFatal {
+			t.Errorf("unexpected report %q %d", msg, code)
+		}
+	}
+
+}
+
+func TestApplyThreeWay(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "f", []byte(seqLines(1, 10))))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	oid, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD:f").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := fmt.Sprintf(`diff --git a/f b/f
+index %s..1234567 100644
+--- a/f
++++ b/f
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`, strings.TrimSpace(string(oid)))
+
+	// 変更のない行が違えば、3-wayマージできれいに当たる
+	add(t, dir, createFile(t, dir, "f", []byte(strings.Replace(seqLines(1, 10), "3\n", "three\n", 1))))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{ThreeWay: true, MinContext: -1, Strip: 1}, usecase.PatchInput{Name: "<stdin>", Data: []byte(patch)}); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	if out.String() != "Applied patch to 'f' cleanly.\n" {
+		t.Errorf("unexpected output \n%s", out)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "f")); string(data) != "1\n2\nthree\n4\nfive\n6\n7\n8\n9\n10\n" {
+		t.Errorf("unexpected f\n%s", data)
+	}
+
+	// 同じ行を変えていれば衝突する
+	add(t, dir, createFile(t, dir, "f", []byte(strings.Replace(seqLines(1, 10), "5\n", "FIVE\n", 1))))
+
+	out.Reset()
+	err = usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{ThreeWay: true, MinContext: -1, Strip: 1}, usecase.PatchInput{Name: "<stdin>", Data: []byte(patch)})
+
+	var failed *usecase.ApplyFailedError
+	if !errors.As(err, &failed) {
+		t.Fatalf("expect ApplyFailedError, got %v", err)
+	}
+
+	if out.String() != "Applied patch to 'f' with conflicts.\nU f\n" {
+		t.Errorf("unexpected output \n%s", out)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "f")); string(data) != "1\n2\n3\n4\n<<<<<<< ours\nFIVE\n=======\nfive\n>>>>>>> theirs\n6\n7\n8\n9\n10\n" {
+		t.Errorf("unexpected f\n%s", data)
+	}
+
+}
+
+func TestApplyThreeWayModeOnly(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "f", []byte("a\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	// index行のないモードだけの変更は、マージせずにそのまま当てる
+	patch := "diff --git a/f b/f\nold mode 100644\nnew mode 100755\n"
+
+	out := &bytes.Buffer{}
+	if err := usecase.Apply(newContext(dir, "", "", out, out), usecase.ApplyOptions{ThreeWay: true, MinContext: -1, Strip: 1}, usecase.PatchInput{Name: "<stdin>", Data: []byte(patch)}); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	if out.String() != "Falling back to direct application...\n" {
+		t.Errorf("unexpected output \n%s", out)
+	}
+
+	staged, _ := exec.Command("git", "-C", dir, "ls-files", "-s", "f").Output()
+	if !strings.HasPrefix(string(staged), "100755 ") {
+		t.Errorf("expect f to be executable in the index, got %s", staged)
+	}
+
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
+	"github.com/mizuho-u/got/repository"
+)
+
+var ErrNoPathIgnored = errors.New("no path is ignored")
+
+func CheckIgnore(ctx GotContextReaderWriter, verbose bool, paths ...string) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	err := db.Index().OpenForRead()
+	if err != nil {
+		return err
+	}
+
+	opt := []repository.WorkspaceOption{}
+	if !db.Index().IsNew() {
+		opt = append(opt, repository.WithIndex(db.Index()))
+	}
+
+	repo, err := repository.NewRepository(opt...)
+	if err != nil {
+		return err
+	}
+
+	ignore, err := loadIgnore(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	matched := false
+	for _, path := range paths {
+
+		rel, err := filepath.Rel(ctx.WorkspaceRoot(), path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." || repo.Tracked(rel) {
+			continue
+		}
+
+		if err := workspace.LoadIgnoreRules(ignore, ctx.WorkspaceRoot(), filepath.Dir(rel)); err != nil {
+			return err
+		}
+
+		isDir := false
+		if info, err := os.Stat(path); err == nil {
+			isDir = info.IsDir()
+		}
+
+		rule, ignored := ignore.Match(rel, isDir)
+		if ignored {
+			matched = true
+		}
+
+		switch {
+		case rule != nil && verbose:
+			ctx.Out(fmt.Sprintf("%s:%d:%s\t%s\n", rule.Source(), rule.Line(), rule.Pattern(), displayPath(ctx, rel)), none)
+		case ignored:
+			ctx.Out(fmt.Sprintf("%s\n", displayPath(ctx, rel)), none)
+		}
+
+	}
+
+	if !matched {
+		return ErrNoPathIgnored
+	}
+
+	return nil
+}
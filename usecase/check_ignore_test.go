@@ -0,0 +1,145 @@
+package usecase_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestStatusIgnoredFiles(t *testing.T) {
+
+	dir := initDir(t)
+
+	createFile(t, dir, ".gitignore", []byte("bin/\n*.log\n"))
+	createFile(t, dir, "main.go", []byte("package main\n"))
+	createFile(t, dir, "debug.log", []byte("debug\n"))
+	createFile(t, dir, "bin/got", []byte("binary\n"))
+	createFile(t, dir, "node_modules/a/index.js", []byte("js\n"))
+	createFile(t, dir, "web/.gitignore", []byte("/node_modules\n!keep.log\n"))
+	createFile(t, dir, "web/node_modules/b/index.js", []byte("js\n"))
+	createFile(t, dir, "web/keep.log", []byte("keep\n"))
+	createFile(t, dir, ".git/info/exclude", []byte("node_modules/\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, out), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "?? .gitignore\n?? main.go\n?? web/\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+	add(t, dir, dir)
+
+	out.Reset()
+	if err := usecase.Status(newContext(dir, "", "", out, out), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect = "A  .gitignore\nA  main.go\nA  web/.gitignore\nA  web/keep.log\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestStatusTrackedFilesInIgnoredDirectories(t *testing.T) {
+
+	dir := initDir(t)
+
+	f := createFile(t, dir, "bin/tracked.sh", []byte("echo\n"))
+	add(t, dir, f)
+	commit(t, dir, "", "", "commit message", time.Unix(1677142145, 0))
+
+	createFile(t, dir, ".gitignore", []byte("bin/\n"))
+	createFile(t, dir, "bin/tracked.sh", []byte("echo changed\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, out), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := " M bin/tracked.sh\n?? .gitignore\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestAddIgnoredFile(t *testing.T) {
+
+	dir := initDir(t)
+
+	createFile(t, dir, ".gitignore", []byte("*.log\n"))
+	f := createFile(t, dir, "debug.log", []byte("debug\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Add(newContext(dir, "", "", out, out), f); err == nil {
+		t.Fatal("expect error, got nil")
+	}
+
+}
+
+func TestCheckIgnore(t *testing.T) {
+
+	testt := []struct {
+		description string
+		verbose     bool
+		paths       []string
+		expect      string
+		err         error
+	}{
+		{
+			description: "prints ignored paths",
+			paths:       []string{"debug.log", "main.go", "web/node_modules"},
+			expect:      "debug.log\nweb/node_modules\n",
+		},
+		{
+			description: "verbose prints the matching rule",
+			verbose:     true,
+			paths:       []string{"debug.log", "web/keep.log", "web/node_modules/x.js"},
+			expect:      ".gitignore:2:*.log\tdebug.log\nweb/.gitignore:2:!keep.log\tweb/keep.log\nweb/.gitignore:1:/node_modules\tweb/node_modules/x.js\n",
+		},
+		{
+			description: "no paths are ignored",
+			paths:       []string{"main.go"},
+			expect:      "",
+			err:         usecase.ErrNoPathIgnored,
+		},
+	}
+
+	for _, tc := range testt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			dir := initDir(t)
+
+			createFile(t, dir, ".gitignore", []byte("bin/\n*.log\n"))
+			createFile(t, dir, "web/.gitignore", []byte("/node_modules\n!keep.log\n"))
+			createDir(t, dir, "web/node_modules/x.js")
+
+			paths := []string{}
+			for _, p := range tc.paths {
+				paths = append(paths, filepath.Join(dir, p))
+			}
+
+			out := &bytes.Buffer{}
+			err := usecase.CheckIgnore(newContext(dir, "", "", out, out), tc.verbose, paths...)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expect error %v, got %v", tc.err, err)
+			}
+
+			if out.String() != tc.expect {
+				t.Fatalf("expect \n%s, got \n%s", tc.expect, out)
+			}
+
+		})
+
+	}
+
+}
@@ -1,27 +1,35 @@
 package usecase
 
 import (
-	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/mizuho-u/got/internal"
 	"github.com/mizuho-u/got/io/database"
 	"github.com/mizuho-u/got/io/workspace"
 	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
 	"github.com/mizuho-u/got/types"
 )
 
 func Checkout(ctx GotContextReaderWriter, revision types.Revision) error {
 
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
 	ws := workspace.New(ctx.WorkspaceRoot())
 
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 	defer db.Close()
 
-	err := db.Index().OpenForUpdate()
+	err := openIndexForUpdate(db)
 	if err != nil {
 		return err
 	}
 
-	oid, err := revision.Resolve(&resolver{refs: db.Refs(), objects: db.Objects()})
+	oid, err := resolveCommit(ctx, db, revision)
 	if err != nil {
 		return err
 	}
@@ -47,10 +55,92 @@ func Checkout(ctx GotContextReaderWriter, revision types.Revision) error {
 	}
 
 	if conflicts := m.Conflicts(); len(conflicts) != 0 {
-		return errors.Join(conflicts...)
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	committer := object.NewAuthor(ctx.CommitterName(), ctx.CommitterEmail(), time.Now())
+	if err := db.Refs().UpdateHeadCommit(oid.String(), committer, checkoutReflogMessage(db, head.OID(), revision)); err != nil {
+		return lockError(err)
+	}
+
+	if err := db.Index().Update(index); err != nil {
+		return err
+	}
+
+	// フックが失敗してもチェックアウトは取り消さず、終了コードだけに反映する
+	return newHooks(ctx, db.Config()).run("post-checkout", nil, head.OID(), oid.String(), "1")
+
+}
+
+// checkoutReflogMessage gitと同じく、移動前のブランチ(detachedならコミット)と指定したrevisionをreflogに残す
+func checkoutReflogMessage(db database.Database, headOID string, revision types.Revision) string {
+
+	from, _ := db.Refs().HeadRef()
+	if from == "" {
+		from = headOID
+	}
+	return fmt.Sprintf("checkout: moving from %s to %s", strings.TrimPrefix(from, "refs/heads/"), types.RevisionName(revision))
+}
+
+// CheckoutPaths pathspecにマッチするファイルをrevisionのコミットから、revisionが空ならインデックスから取り出してワークスペースを上書きする
+func CheckoutPaths(ctx GotContextReaderWriter, revision types.Revision, paths ...string) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	pathspec, err := newPathspec(ctx, paths...)
+	if err != nil {
+		return err
+	}
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	err = openIndexForUpdate(db)
+	if err != nil {
+		return err
+	}
+
+	index, err := repository.NewIndex()
+	if err != nil {
+		return err
+	}
+
+	if !db.Index().IsNew() {
+		if index, err = repository.NewIndex(repository.IndexSource(db.Index())); err != nil {
+			return err
+		}
+	}
+
+	oid, err := resolveTree(ctx, db, revision)
+	if err != nil {
+		return err
+	}
+
+	entries := map[string]object.TreeEntry{}
+	if oid == "" {
+
+		for name, entry := range index.Iter() {
+			if pathspec.Match(name) {
+				entries[name] = entry.TreeEntry()
+			}
+		}
+
+	} else {
+
+		db.Objects().ScanTree(oid.String()).Walk(func(name string, entry repository.TreeEntry) {
+			if !entry.IsTree() && pathspec.Match(name) {
+				entries[name] = entry
+			}
+		})
 	}
 
-	if err := db.Refs().UpdateHeadCommit(oid.String()); err != nil {
+	if unmatched := pathspec.Unmatched(internal.Keys(entries)); len(unmatched) != 0 {
+		return fmt.Errorf("pathspec '%s' did not match any file(s) known to git", unmatched[0])
+	}
+
+	if err := repository.CheckoutFiles(workspace.New(ctx.WorkspaceRoot()), db.Objects(), index, entries); err != nil {
 		return err
 	}
 
@@ -58,6 +148,11 @@ func Checkout(ctx GotContextReaderWriter, revision types.Revision) error {
 		return err
 	}
 
-	return nil
+	head, err := db.Refs().Head()
+	if err != nil {
+		return err
+	}
 
+	// ファイルのチェックアウトはHEADが変わらない
+	return newHooks(ctx, db.Config()).run("post-checkout", nil, head.OID(), head.OID(), "0")
 }
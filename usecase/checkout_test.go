@@ -80,7 +80,10 @@ func TestCheckout(t *testing.T) {
 				add(t, dir, createFile(t, dir, path, data))
 			}
 
-			commit(t, dir, "", "", "commit b", time.Unix(1694356071, 0))
+			// same fileはツリーが変わらないので空のコミットを許す
+			if err := usecase.Commit(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "commit b", AllowEmpty: true}); err != nil {
+				t.Fatal(err)
+			}
 
 			rev, err := types.NewRevision("HEAD^")
 			if err != nil {
@@ -121,3 +124,63 @@ func TestCheckout(t *testing.T) {
 	}
 
 }
+
+func TestCheckoutPaths(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a1\n"))
+	createFile(t, dir, "b/c.txt", []byte("c1\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "a.txt", []byte("a2\n"))
+	createFile(t, dir, "b/c.txt", []byte("c2\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "second", time.Unix(1694356072, 0))
+
+	createFile(t, dir, "a.txt", []byte("a3\n"))
+	removeAll(t, dir, "b")
+
+	// インデックスから戻す
+	empty, _ := types.NewRevision("")
+	if err := usecase.CheckoutPaths(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), empty, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	testFileContent(t, dir, "a.txt", "a2\n")
+
+	// コミットから戻す
+	rev, _ := types.NewRevision("HEAD^")
+	if err := usecase.CheckoutPaths(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), rev, "b"); err != nil {
+		t.Fatal(err)
+	}
+	testFileContent(t, dir, "b/c.txt", "c1\n")
+
+	out := &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, &bytes.Buffer{}), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.String() != "M  b/c.txt\n" {
+		t.Fatalf("unexpected status %s", out)
+	}
+
+	if err := usecase.CheckoutPaths(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), rev, "d.txt"); err == nil {
+		t.Fatal("expect error for unknown path")
+	}
+
+}
+
+func testFileContent(t *testing.T, dir, name, expect string) {
+
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != expect {
+		t.Fatalf("expect %s content %q, got %q", name, expect, data)
+	}
+
+}
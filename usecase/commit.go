@@ -1,25 +1,88 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
 	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
 )
 
-func Commit(ctx GotContextReaderWriter, commitMessage string, now time.Time) error {
+var ErrEmptyCommitMessage = errors.New("Aborting commit due to empty commit message.")
+
+var ErrWhitespaceCommit = errors.New("Aborting commit due to whitespace errors.")
+
+type CommitOptions struct {
+	// Message -m や -F で指定されたメッセージ
+	Message string
+	// Edit エディタでメッセージを編集する
+	Edit bool
+	// All 追跡済みファイルの変更と削除をすべてステージしてからコミットする
+	All bool
+	// Amend HEADのコミットを作り直す。Messageが空ならHEADのメッセージを使う
+	Amend bool
+	// Paths マッチするファイルのワークスペースの内容だけをコミットする
+	Paths []string
+	// Author --authorで指定された "Name <email>"
+	Author string
+	// AuthorDate --dateやGIT_AUTHOR_DATEで指定されたauthorの日時
+	AuthorDate string
+	// CommitterDate GIT_COMMITTER_DATEで指定されたcommitterの日時
+	CommitterDate string
+	// Sign SSH鍵で署名する。commit.gpgSignでも署名する
+	Sign bool
+	// SigningKey 署名に使う鍵。空ならuser.signingKey
+	SigningKey string
+	// NoVerify pre-commitとcommit-msgフックを実行しない
+	NoVerify bool
+	// AllowEmpty 親とツリーが変わらなくてもコミットする
+	AllowEmpty bool
+	// AllowEmptyMessage メッセージが空でもコミットする
+	AllowEmptyMessage bool
+	// Signoff committerのSigned-off-byトレーラを足す
+	Signoff bool
+	// Trailers --trailerで指定された "token=value"
+	Trailers []string
+	// CheckWhitespace 親との差分で追加した行に空白の誤りがあればコミットしない
+	CheckWhitespace bool
+}
+
+func Commit(ctx GotContextReaderWriter, now time.Time, opts CommitOptions) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	if opts.All && len(opts.Paths) != 0 {
+		return errors.New("paths with -a does not make sense")
+	}
 
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 	defer db.Close()
 
-	err := db.Index().OpenForRead()
+	err := openIndexForUpdate(db)
 	if err != nil {
 		return err
 	}
 
-	repo, err := repository.NewRepository(repository.WithIndex(db.Index()))
+	opt := []repository.WorkspaceOption{repository.WithIndex(db.Index())}
+	if len(opts.Paths) != 0 {
+
+		pathspec, err := newPathspec(ctx, opts.Paths...)
+		if err != nil {
+			return err
+		}
+		opt = append(opt, repository.WithPathspec(pathspec))
+	}
+
+	repo, err := repository.NewRepository(opt...)
 	if err != nil {
 		return err
 	}
@@ -29,9 +92,167 @@ func Commit(ctx GotContextReaderWriter, commitMessage string, now time.Time) err
 		return err
 	}
 
-	parent := head.OID()
+	parents := []string{}
+	if head.OID() != "" {
+		parents = append(parents, head.OID())
+	}
+
+	message := opts.Message
 
-	commitId, objects, err := repo.Commit(parent, ctx.Username(), ctx.Email(), commitMessage, now)
+	if opts.Amend {
+
+		if head.OID() == "" {
+			return errors.New("You have nothing to amend.")
+		}
+
+		// マージコミットをamendしても親はすべて残す
+		parents = head.Parents()
+		if message == "" {
+			message = head.Message()
+		}
+	}
+
+	// 差分は最初の親と取る
+	parent := ""
+	if len(parents) != 0 {
+		parent = parents[0]
+	}
+
+	author, committer, err := identities(ctx, now, opts, head)
+	if err != nil {
+		return err
+	}
+
+	if message, err = commitTrailers(ctx, message, opts); err != nil {
+		return err
+	}
+
+	commitOpts := []repository.CommitOption{}
+	if sign, err := commitSigner(ctx, db.Config(), opts); err != nil {
+		return err
+	} else if sign != nil {
+		commitOpts = append(commitOpts, repository.WithSigner(sign))
+	}
+
+	if len(opts.Paths) != 0 || opts.All || opts.Edit {
+
+		scanOpts, err := scanOptions(ctx, db, repo.Tracked)
+		if err != nil {
+			return err
+		}
+
+		scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot(), scanOpts...)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.Scan(scanner, db.Objects().ScanTree(head.Tree())); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Paths) != 0 || opts.All {
+
+		if unmatched := repo.UnmatchedPathspec(); len(unmatched) != 0 {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to git", unmatched[0])
+		}
+
+		// 追跡済みファイルの変更をインデックスに反映してからコミットする
+		staged, err := repo.UpdateTracked()
+		if err != nil {
+			return err
+		}
+
+		if err := db.Objects().Store(staged...); err != nil {
+			return err
+		}
+	}
+
+	var verify *hooks
+	if !opts.NoVerify {
+
+		verify = newHooks(ctx, db.Config())
+		if !opts.Edit {
+			verify.env = append(verify.env, "GIT_EDITOR=:")
+		}
+
+		index, cleanup, err := verify.withIndex(repo.Index())
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+
+		if err := verify.run("pre-commit", nil); err != nil {
+			return err
+		}
+
+		// pre-commitがステージし直した内容もコミットする
+		staged, err := os.Open(index)
+		if err != nil {
+			return err
+		}
+		defer staged.Close()
+
+		if err := repo.ReadIndex(staged); err != nil {
+			return err
+		}
+	}
+
+	if opts.CheckWhitespace {
+
+		treeId, objects, err := repo.WriteTree()
+		if err != nil {
+			return err
+		}
+
+		if err := db.Objects().Store(objects...); err != nil {
+			return err
+		}
+
+		if err := checkCommitWhitespace(ctx, db, treeId, parent); err != nil {
+			return err
+		}
+	}
+
+	if !opts.AllowEmpty {
+
+		treeId, _, err := repo.WriteTree()
+		if err != nil {
+			return err
+		}
+
+		empty, err := sameTree(db, treeId, parents)
+		if err != nil {
+			return err
+		}
+
+		if empty {
+
+			// gitと同じくstatusを表示して終了する
+			db.Close()
+			if err := Status(ctx, false, opts.Paths...); err != nil {
+				return err
+			}
+
+			return &NothingToCommitError{Reason: "nothing to commit"}
+		}
+	}
+
+	template := message
+	if opts.Edit {
+		template = repo.CommitTemplate(message)
+	}
+
+	if message, err = editMessage(ctx, db.Config(), template, opts.Edit, verify); err != nil {
+		return err
+	}
+
+	message = repository.CleanupMessage(message, opts.Edit)
+	if message == "" && !opts.AllowEmptyMessage {
+		return ErrEmptyCommitMessage
+	}
+
+	commitId, objects, err := repo.Commit(parents, author, committer, message, commitOpts...)
 	if err != nil {
 		return err
 	}
@@ -40,17 +261,108 @@ func Commit(ctx GotContextReaderWriter, commitMessage string, now time.Time) err
 		return err
 	}
 
-	if err := db.Refs().UpdateHeadCommit(commitId); err != nil {
+	if err := db.Refs().UpdateHeadCommit(commitId, committer, reflogMessage(opts.Amend, head.OID() == "", message)); err != nil {
+		return lockError(err)
+	}
+
+	if err := db.Index().Update(repo.Index()); err != nil {
+		return err
+	}
+
+	if err := ctx.Out(msg(parent, commitId, message), none); err != nil {
+		return err
+	}
+
+	// post-commitの結果はコミットに影響しない
+	newHooks(ctx, db.Config()).run("post-commit", nil)
+
+	return nil
+}
+
+var ident = regexp.MustCompile(`^\s*(.*?)\s*<(.*)>\s*$`)
+
+// identities authorは--author、--date、amendするコミットの順に決める。committerは常に今の利用者
+func identities(ctx GotContextReader, now time.Time, opts CommitOptions, head object.Commit) (author, committer object.Author, err error) {
+
+	name, email, when := ctx.Username(), ctx.Email(), now
+	if opts.Amend {
+		name, email, when = head.Author().Name(), head.Author().Email(), head.Author().When()
+	}
+
+	if opts.Author != "" {
+
+		m := ident.FindStringSubmatch(opts.Author)
+		if m == nil {
+			return nil, nil, fmt.Errorf("--author '%s' is not 'Name <email>'", opts.Author)
+		}
+		name, email = m[1], m[2]
+	}
+
+	if opts.AuthorDate != "" {
+		if when, err = types.ParseDate(opts.AuthorDate, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	committed := now
+	if opts.CommitterDate != "" {
+		if committed, err = types.ParseDate(opts.CommitterDate, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return object.NewAuthor(name, email, when), object.NewAuthor(ctx.CommitterName(), ctx.CommitterEmail(), committed), nil
+}
+
+// sameTree treeIdが最初の親のツリーと同じか。親がなければ空のツリーと比べる
+func sameTree(db database.Database, treeId string, parents []string) (bool, error) {
+
+	if len(parents) == 0 {
+		return treeId == object.EmptyTreeOID, nil
+	}
+
+	commit, err := db.Objects().LoadCommit(parents[0])
+	if err != nil {
+		return false, err
+	}
+
+	return treeId == commit.Tree(), nil
+}
+
+// checkCommitWhitespace コミットするツリーと親の差分に、core.whitespaceの空白の誤りがあれば出力してErrWhitespaceCommitを返す
+func checkCommitWhitespace(ctx GotContextWriter, db database.Database, treeId, parent string) error {
+
+	diffs, err := diffTrees(db, nil, types.ObjectID(parent), types.ObjectID(treeId))
+	if err != nil {
 		return err
 	}
 
-	if err := ctx.Out(msg(parent, commitId, commitMessage), none); err != nil {
+	found, err := checkWhitespace(ctx, db.Config(), diffs)
+	if err != nil {
 		return err
 	}
 
+	if found {
+		return ErrWhitespaceCommit
+	}
+
 	return nil
 }
 
+// reflogMessage gitと同じく、コミットの種類とメッセージの1行目をreflogに残す
+func reflogMessage(amend, initial bool, message string) string {
+
+	kind := "commit"
+	switch {
+	case amend:
+		kind = "commit (amend)"
+	case initial:
+		kind = "commit (initial)"
+	}
+
+	return fmt.Sprintf("%s: %s", kind, strings.Split(message, "\n")[0])
+}
+
 func msg(parent, commitId, commitMessage string) string {
 
 	prefix := ""
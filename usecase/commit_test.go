@@ -1,8 +1,17 @@
 package usecase_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mizuho-u/got/usecase"
 )
 
 func TestFirstCommit(t *testing.T) {
@@ -66,3 +75,436 @@ func TestCommitExcutableFiles(t *testing.T) {
 	}
 
 }
+
+func TestCommitPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b.txt", []byte("b\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "a.txt", []byte("a2\n"))
+	createFile(t, dir, "b.txt", []byte("b2\n"))
+	createFile(t, dir, "c.txt", []byte("c\n"))
+	add(t, dir, "b.txt", "c.txt")
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", Paths: []string{"a.txt"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a.txtだけがコミットされ、b.txtとc.txtはステージされたまま
+	out = &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, out), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "M  b.txt\nA  c.txt\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestCommitUnknownPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "b.txt", []byte("b\n"))
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", Paths: []string{"b.txt"}})
+	if err == nil || err.Error() != "pathspec 'b.txt' did not match any file(s) known to git" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+}
+
+func TestCommitAll(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b.txt", []byte("b\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "a.txt", []byte("a2\n"))
+	removeAll(t, dir, "b.txt")
+	createFile(t, dir, "c.txt", []byte("c\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", All: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 追跡していないc.txtはコミットされない
+	out = &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, out), true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "?? c.txt\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestCommitAmend(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first\n\nbody\n", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "b.txt", []byte("b\n"))
+	add(t, dir, "b.txt")
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Amend: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 親のないまま、HEADのメッセージを引き継いで作り直される
+	testgitlog(t, dir, "%P|%B|", "|first\n\nbody\n|\n")
+	testlstree(t, dir, "a.txt\nb.txt\n")
+
+}
+
+func TestCommitAmendMerge(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	// マージコミットはgitで作る
+	runGit(t, dir, "checkout", "-q", "-b", "side")
+	runGit(t, dir, "add", createFile(t, dir, "side.txt", []byte("s\n")))
+	runGit(t, dir, "commit", "-q", "-m", "side")
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "add", createFile(t, dir, "main.txt", []byte("m\n")))
+	runGit(t, dir, "commit", "-q", "-m", "main")
+	runGit(t, dir, "merge", "-q", "--no-ff", "side", "-m", "merge side")
+
+	parents, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%P").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Amend: true, Message: "amended merge"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 両方の親が残り、ツリーも変わらない
+	testgitlog(t, dir, "%P|%s", strings.TrimSuffix(string(parents), "\n")+"|amended merge\n")
+	testlstree(t, dir, "a.txt\nmain.txt\nside.txt\n")
+
+}
+
+func TestCommitAmendWithoutHead(t *testing.T) {
+
+	dir := initDir(t)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Amend: true})
+	if err == nil || err.Error() != "You have nothing to amend." {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+}
+
+func TestCommitEditMessage(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	// テンプレートのコメント行は取り除かれる
+	t.Setenv("GIT_EDITOR", `sed -i -e '1s/^/edited  /'`)
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "message", Edit: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%B", "edited  message\n\n")
+
+	data, err := os.ReadFile(filepath.Join(dir, ".git", "COMMIT_EDITMSG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "#\tnew file:   a.txt\n") {
+		t.Fatalf("template does not list staged changes. %s", data)
+	}
+
+}
+
+func TestCommitEmptyMessage(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	t.Setenv("GIT_EDITOR", ":")
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Edit: true})
+	if !errors.Is(err, usecase.ErrEmptyCommitMessage) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+}
+
+func testgitlog(t *testing.T, dir, format, expect string) {
+
+	t.Helper()
+
+	out, err := exec.Command("git", "-C", dir, "log", "-1", "--format="+format).CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+
+	if string(out) != expect {
+		t.Fatalf("unexpected log. expect %q, got %q", expect, out)
+	}
+
+}
+
+func testlstree(t *testing.T, dir, expect string) {
+
+	t.Helper()
+
+	out, err := exec.Command("git", "-C", dir, "ls-tree", "-r", "--name-only", "HEAD").CombinedOutput()
+	if err != nil {
+		t.Fatal(string(out))
+	}
+
+	if string(out) != expect {
+		t.Fatalf("unexpected tree. expect %q, got %q", expect, out)
+	}
+
+}
+
+func TestCommitNothingToCommit(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "b.txt", []byte("b\n"))
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second"})
+
+	var nothing *usecase.NothingToCommitError
+	if !errors.As(err, &nothing) {
+		t.Fatalf("expect NothingToCommitError, got %v", err)
+	}
+
+	if msg, code := usecase.Report(err); msg != "" || code != usecase.ExitError {
+		t.Fatalf("unexpected report %q %d", msg, code)
+	}
+
+	expect := "Untracked files:\n\n\tb.txt   \n\nnothing added to commit but untracked files present"
+	if out.String() != expect {
+		t.Fatalf("expect \n%q, got \n%q", expect, out)
+	}
+
+	// --allow-empty なら親と同じツリーでコミットできる
+	out = &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", AllowEmpty: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%s", "second\n")
+
+}
+
+func TestCommitNothingToCommitInitial(t *testing.T) {
+
+	dir := initDir(t)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"})
+
+	var nothing *usecase.NothingToCommitError
+	if !errors.As(err, &nothing) {
+		t.Fatalf("expect NothingToCommitError, got %v", err)
+	}
+
+}
+
+func TestCommitWithoutIndex(t *testing.T) {
+
+	dir := initDir(t)
+
+	// インデックスのファイルがないリポジトリでも、空のインデックスとしてコミットできる
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first", AllowEmpty: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%s", "first\n")
+	testlstree(t, dir, "")
+
+	if exists(dir, ".git/index.lock") {
+		t.Error("expect the index lock to be released")
+	}
+
+}
+
+func TestCommitAllowEmptyMessage(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "  \n\n"})
+	if !errors.Is(err, usecase.ErrEmptyCommitMessage) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "", AllowEmptyMessage: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%B", "\n")
+
+}
+
+func TestCommitAuthorAndCommitter(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	ctx := usecase.NewContext(context.Background(), dir, ".git", "User", "user@example.com", out, out, usecase.WithCommitter("Committer", "committer@example.com"))
+
+	opts := usecase.CommitOptions{
+		Message:       "first",
+		Author:        "A U Thor <author@example.com>",
+		AuthorDate:    "@1600000000 +0200",
+		CommitterDate: "2023-10-14T10:30:00+09:00",
+	}
+	if err := usecase.Commit(ctx, time.Unix(1694356071, 0), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%an <%ae> %ad|%cn <%ce> %cd", "A U Thor <author@example.com> Sun Sep 13 14:26:40 2020 +0200|Committer <committer@example.com> Sat Oct 14 10:30:00 2023 +0900\n")
+
+}
+
+func TestCommitInvalidAuthor(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	for _, opts := range []usecase.CommitOptions{
+		{Message: "first", Author: "A U Thor"},
+		{Message: "first", AuthorDate: "someday"},
+	} {
+		if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), opts); err == nil {
+			t.Errorf("expect error with %+v", opts)
+		}
+	}
+
+}
+
+func TestCommitAmendKeepsAuthor(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "Original", "original@example.com", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// amendしてもauthorは元のまま、committerだけ変わる
+	if err := usecase.Commit(newContext(dir, "Amender", "amender@example.com", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "amended", Amend: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%an %at|%cn %ct", "Original 1694356071|Amender 1694356072\n")
+
+}
+
+func TestCommitSignoffAndTrailers(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	ctx := usecase.NewContext(context.Background(), dir, ".git", "User", "user@example.com", out, out, usecase.WithCommitter("Committer", "committer@example.com"))
+
+	opts := usecase.CommitOptions{Message: "first", Signoff: true, Trailers: []string{"Co-authored-by=A U Thor <author@example.com>"}}
+	if err := usecase.Commit(ctx, time.Unix(1694356071, 0), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%B", "first\n\nSigned-off-by: Committer <committer@example.com>\nCo-authored-by: A U Thor <author@example.com>\n\n")
+
+	// 最後のトレーラと同じSigned-off-byは足さない
+	createFile(t, dir, "b.txt", []byte("b\n"))
+	add(t, dir, dir)
+
+	opts = usecase.CommitOptions{Message: "second\n\nSigned-off-by: Committer <committer@example.com>\n", Signoff: true}
+	if err := usecase.Commit(ctx, time.Unix(1694356072, 0), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%(trailers:key=Signed-off-by,valueonly)", "Committer <committer@example.com>\n\n")
+
+	if err := usecase.Commit(ctx, time.Unix(1694356073, 0), usecase.CommitOptions{Message: "third", AllowEmpty: true, Trailers: []string{"bad token=a"}}); err == nil {
+		t.Fatal("expect error with invalid trailer")
+	}
+
+}
+
+func TestCommitCheckWhitespace(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+	createFile(t, dir, "a.txt", []byte("a\nb \n"))
+	add(t, dir, dir)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", CheckWhitespace: true})
+	if !errors.Is(err, usecase.ErrWhitespaceCommit) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if expect := "a.txt:2: trailing whitespace.\n+b \n"; out.String() != expect {
+		t.Fatalf("expect %q, got %q", expect, out)
+	}
+
+	testgitlog(t, dir, "%s", "first\n")
+
+	// 誤りのない変更はコミットできる
+	createFile(t, dir, "a.txt", []byte("a\nb\n"))
+	add(t, dir, dir)
+
+	out = &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356072, 0), usecase.CommitOptions{Message: "second", CheckWhitespace: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%s", "second\n")
+
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -21,8 +22,11 @@ type GotContextReader interface {
 	context.Context
 	WorkspaceRoot() string
 	GotRoot() string
+	WorkingDirectory() string
 	Username() string
 	Email() string
+	CommitterName() string
+	CommitterEmail() string
 }
 
 type GotContextWriter interface {
@@ -43,15 +47,53 @@ type gotContext struct {
 	context.Context
 	workspaceRoot string
 	gotRoot       string
+	workingDir    string
 	username      string
 	email         string
+	committer     string
+	committerMail string
 	w             io.Writer
 	e             io.Writer
 }
 
-func NewContext(ctx context.Context, workspaceRoot, gotroot, username, email string, out io.Writer, errOut io.Writer) GotContext {
+type ContextOption func(*gotContext)
+
+// WithCommitter authorとは別のcommitterを使う
+func WithCommitter(name, email string) ContextOption {
+
+	return func(g *gotContext) {
+		g.committer = name
+		g.committerMail = email
+	}
+
+}
+
+// WithWorkingDirectory 出力するパスをdirからの相対パスにする
+func WithWorkingDirectory(dir string) ContextOption {
+
+	return func(g *gotContext) {
+		g.workingDir = dir
+	}
 
-	return &gotContext{ctx, workspaceRoot, filepath.Join(workspaceRoot, gotroot), username, email, out, errOut}
+}
+
+func newGotContext(ctx context.Context, workspaceRoot, gotroot, username, email string, out io.Writer, errOut io.Writer, options ...ContextOption) *gotContext {
+
+	if !filepath.IsAbs(gotroot) {
+		gotroot = filepath.Join(workspaceRoot, gotroot)
+	}
+
+	g := &gotContext{ctx, workspaceRoot, gotroot, workspaceRoot, username, email, "", "", out, errOut}
+
+	for _, opt := range options {
+		opt(g)
+	}
+
+	return g
+}
+
+func NewContext(ctx context.Context, workspaceRoot, gotroot, username, email string, out io.Writer, errOut io.Writer, options ...ContextOption) GotContext {
+	return newGotContext(ctx, workspaceRoot, gotroot, username, email, out, errOut, options...)
 }
 
 func (g *gotContext) WorkspaceRoot() string {
@@ -62,6 +104,10 @@ func (g *gotContext) GotRoot() string {
 	return g.gotRoot
 }
 
+func (g *gotContext) WorkingDirectory() string {
+	return g.workingDir
+}
+
 func (g *gotContext) Username() string {
 	return g.username
 }
@@ -70,6 +116,37 @@ func (g *gotContext) Email() string {
 	return g.email
 }
 
+// CommitterName 指定がなければauthorと同じ
+func (g *gotContext) CommitterName() string {
+
+	if g.committer == "" {
+		return g.username
+	}
+
+	return g.committer
+}
+
+func (g *gotContext) CommitterEmail() string {
+
+	if g.committerMail == "" {
+		return g.email
+	}
+
+	return g.committerMail
+}
+
+var ErrNoWorkTree = errors.New("this operation must be run in a work tree")
+
+// requireWorkTree ベアリポジトリではワークスペースを使う操作はできない
+func requireWorkTree(ctx GotContextReader) error {
+
+	if ctx.WorkspaceRoot() == "" {
+		return ErrNoWorkTree
+	}
+
+	return nil
+}
+
 type ColorAttribute uint
 
 const (
@@ -78,6 +155,11 @@ const (
 	red
 	green
 	cyan
+	magenta
+	blue
+	yellow
+	faint
+	italic
 )
 
 func (g *gotContext) Out(msg string, c ColorAttribute) (err error) {
@@ -86,19 +168,27 @@ func (g *gotContext) Out(msg string, c ColorAttribute) (err error) {
 	return
 }
 
+// colorize cの属性をすべてつける
 func colorize(msg string, c ColorAttribute) string {
 
 	attrs := []color.Attribute{}
-	switch {
-	case c&bold != 0:
-		attrs = append(attrs, color.Bold)
-	case c&red != 0:
-		attrs = append(attrs, color.FgRed)
-	case c&green != 0:
-		attrs = append(attrs, color.FgGreen)
-	case c&cyan != 0:
-		attrs = append(attrs, color.FgCyan)
-	default:
+	for _, a := range []struct {
+		c    ColorAttribute
+		attr color.Attribute
+	}{
+		{bold, color.Bold},
+		{faint, color.Faint},
+		{italic, color.Italic},
+		{red, color.FgRed},
+		{green, color.FgGreen},
+		{cyan, color.FgCyan},
+		{magenta, color.FgMagenta},
+		{blue, color.FgBlue},
+		{yellow, color.FgYellow},
+	} {
+		if c&a.c != 0 {
+			attrs = append(attrs, a.attr)
+		}
 	}
 
 	return color.New(attrs...).Sprint(msg)
@@ -106,7 +196,7 @@ func colorize(msg string, c ColorAttribute) string {
 
 func (g *gotContext) OutError(e error) error {
 
-	_, err := g.w.Write([]byte(e.Error()))
+	_, err := fmt.Fprintln(g.e, e.Error())
 
 	return err
 }
@@ -121,7 +211,7 @@ type gotContextPager struct {
 	out io.WriteCloser
 }
 
-func NewContextPager(ctx context.Context, workspaceRoot, gotroot, username, email string, out io.Writer, errOut io.Writer) (GotContext, error) {
+func NewContextPager(ctx context.Context, workspaceRoot, gotroot, username, email string, out io.Writer, errOut io.Writer, options ...ContextOption) (GotContext, error) {
 
 	pager := "less"
 	if p := os.Getenv("GIT_PAGER"); p != "" {
@@ -147,7 +237,7 @@ func NewContextPager(ctx context.Context, workspaceRoot, gotroot, username, emai
 		return nil, err
 	}
 
-	return &gotContextPager{&gotContext{ctx, workspaceRoot, filepath.Join(workspaceRoot, gotroot), username, email, out, errOut}, cmd, stdout}, nil
+	return &gotContextPager{newGotContext(ctx, workspaceRoot, gotroot, username, email, out, errOut, options...), cmd, stdout}, nil
 }
 
 func (g *gotContextPager) Out(msg string, c ColorAttribute) (err error) {
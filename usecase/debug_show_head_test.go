@@ -22,7 +22,7 @@ func TestShowHead(t *testing.T) {
 	}
 
 	out := &bytes.Buffer{}
-	if err := usecase.Commit(newContext(dir, "Mizuho Ueda", "mi_ueda@u-m.dev", out, out), "commit\n", time.Unix(1694356071, 0)); err != nil {
+	if err := usecase.Commit(newContext(dir, "Mizuho Ueda", "mi_ueda@u-m.dev", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "commit\n"}); err != nil {
 		t.Fatal("expect exit code 0, got ", err)
 	}
 
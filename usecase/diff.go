@@ -1,51 +1,156 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/mizuho-u/got/io/database"
 	"github.com/mizuho-u/got/io/workspace"
 	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/types"
 )
 
-func Diff(ctx GotContextReaderWriter, staged bool) error {
+type DiffOptions struct {
+	Cached   bool
+	Relative bool
+	// Revisions ひとつならワークスペースかインデックスと、ふたつか範囲ならコミット同士を比べる
+	Revisions []string
+	Paths     []string
+	// 要約の形式。どれも指定しなければパッチを出力する
+	Raw        bool
+	NameOnly   bool
+	NameStatus bool
+	NumStat    bool
+	Stat       bool
+	ShortStat  bool
+	// NullTerminated -z。パスをNULで区切る
+	NullTerminated bool
+	// StatWidth --statの幅。0ならdefaultStatWidth
+	StatWidth int
+	// FindRenames -Mの類似度。50%や5のように書く。空ならdiff.renamesに従って50%
+	FindRenames string
+	// FindCopies -C。変更されたファイルからのコピーも見つける
+	FindCopies bool
+	NoRenames  bool
+	// Algorithm --diff-algorithm。空ならdiff.algorithmに従ってmyers
+	Algorithm string
+	// Minimal myersで長い入力でも最短の差分を探す
+	Minimal bool
+	// Context -U。nilならdiff.contextに従って3
+	Context *int
+	// InterHunkContext nilならdiff.interHunkContextに従って0
+	InterHunkContext *int
+	// 空白を無視して行を比べる
+	IgnoreAllSpace    bool
+	IgnoreSpaceChange bool
+	IgnoreSpaceAtEOL  bool
+	// IgnoreBlankLines 空行だけの変更を無視する
+	IgnoreBlankLines bool
+	// Binary バイナリの差分をgit applyで戻せるGIT binary patchで出す
+	Binary bool
+	// WordDiff --word-diff。plain、color、porcelainなら行のかわりに単語で比べて出す
+	WordDiff string
+	// WordDiffRegex 単語の正規表現。空ならドライバーのwordRegexかdiff.wordRegex
+	WordDiffRegex string
+	// ColorMoved --color-moved。空ならdiff.colorMovedに従う
+	ColorMoved string
+	// NoIndex リポジトリに関係なくPathsのふたつのパスを比べる
+	NoIndex bool
+	// Check 差分のかわりに、追加した行の空白の誤りを出す
+	Check bool
+}
+
+func Diff(ctx GotContextReaderWriter, opts DiffOptions) error {
+
+	if opts.NoIndex {
+		return diffNoIndex(ctx, opts)
+	}
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
 
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 	defer db.Close()
 
-	err := db.Index().OpenForUpdate()
+	pathspec, err := newPathspec(ctx, opts.Paths...)
 	if err != nil {
 		return err
 	}
 
-	opt := []repository.WorkspaceOption{}
-	if !db.Index().IsNew() {
-		opt = append(opt, repository.WithIndex(db.Index()))
+	r, err := types.NewRevisionRange(opts.Revisions...)
+	if err != nil {
+		return &InvalidRevisionError{Revision: strings.Join(opts.Revisions, " "), Reason: err.Error()}
 	}
 
-	opt = append(opt, repository.WithObjectLoader(db.Objects()))
-
-	repo, err := repository.NewRepository(opt...)
+	var diffs []repository.FileDiff
+	if len(opts.Revisions) == 0 || r.IsSingle() {
+		diffs, err = diffWorkspace(ctx, db, pathspec, r, opts.Cached)
+	} else {
+		diffs, err = diffCommits(ctx, db, pathspec, r)
+	}
 	if err != nil {
 		return err
 	}
 
-	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot())
-	if err != nil {
-		return err
+	_, err = showDiffs(ctx, db.Config(), diffs, opts)
+	return err
+}
+
+// showDiffs 名前の変更を見つけて、オプションに従って差分を出力する。changedは出力した差分があったか
+func showDiffs(ctx GotContextReaderWriter, config database.Config, diffs []repository.FileDiff, opts DiffOptions) (changed bool, err error) {
+
+	if diffs, err = detectRenames(config, diffs, opts); err != nil {
+		return false, err
 	}
-	head, err := db.Refs().Head()
+
+	diffOpts, err := diffOptions(ctx, config, diffs, opts)
 	if err != nil {
-		return err
+		return false, err
 	}
+	diffs = repository.UseDiffOptions(diffs, diffOpts...)
 
-	if repo.Scan(scanner, db.Objects().ScanTree(head.Tree())) != nil {
-		return err
+	if opts.ignoreChanges() {
+		diffs = repository.OmitUnchanged(diffs)
+	}
+
+	if opts.Relative {
+		diffs = repository.Relative(diffs, workingPrefix(ctx))
+	}
+
+	// gitと同じく、--checkなら要約も出さない
+	if opts.Check {
+
+		found, err := checkWhitespace(ctx, config, diffs)
+		if err != nil {
+			return false, err
+		}
+
+		if found {
+			return true, &WhitespaceCheckError{}
+		}
+		return len(diffs) > 0, nil
 	}
 
-	diffs, err := repo.Diff(staged)
+	if opts.summary() {
+		return len(diffs) > 0, printSummary(ctx, diffs, opts)
+	}
+
+	words, err := opts.wordDiffStyle()
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	// 単語で比べるときは移動した行を探さない
+	if words == nil {
+
+		moved, err := colorMoved(config, opts)
+		if err != nil {
+			return false, err
+		}
+		repository.MarkMoved(diffs, moved)
 	}
 
 	for _, diff := range diffs {
@@ -53,12 +158,23 @@ func Diff(ctx GotContextReaderWriter, staged bool) error {
 		ctx.Out(diff.PathLine(), bold)
 		ctx.Out(diff.ModeLine(), bold)
 		ctx.Out(diff.IndexLine(), bold)
+
+		if diff.Binary() {
+			ctx.Out(diff.BinaryPatch(), none)
+			continue
+		}
+
 		ctx.Out(diff.FileLine(), bold)
 
 		for _, hunk := range diff.Hunks() {
 
 			ctx.Out(fmt.Sprintln(hunk.Header()), cyan)
 
+			if words != nil {
+				printWords(ctx, hunk.WordDiff(), words)
+				continue
+			}
+
 			for _, edit := range hunk.Edits() {
 
 				color := none
@@ -70,16 +186,369 @@ func Diff(ctx GotContextReaderWriter, staged bool) error {
 				default:
 				}
 
-				ctx.Out(fmt.Sprintln(edit), color)
+				moved, alternative, dimmed := edit.Moved()
+				ctx.Out(fmt.Sprintln(edit), movedColor(color, moved, alternative, dimmed))
 			}
 
 		}
 
 	}
 
+	return len(diffs) > 0, nil
+}
+
+// diffWorkspace revisionがなければインデックスとワークスペース、cachedならHEADとインデックスを比べる。
+// revisionがあればHEADのかわりにそのコミットを使い、cachedでなければワークスペースと比べる
+func diffWorkspace(ctx GotContextReaderWriter, db database.Database, pathspec types.Pathspec, r *types.RevisionRange, cached bool) ([]repository.FileDiff, error) {
+
+	err := openIndexForUpdate(db)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := []repository.WorkspaceOption{repository.WithPathspec(pathspec)}
+	if !db.Index().IsNew() {
+		opt = append(opt, repository.WithIndex(db.Index()))
+	}
+
+	opt = append(opt, repository.WithObjectLoader(db.Objects()))
+
+	repo, err := repository.NewRepository(opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	scanOpts, err := scanOptions(ctx, db, repo.Tracked)
+	if err != nil {
+		return nil, err
+	}
+	scanOpts = append(scanOpts, workspace.WithPathspec(pathspec))
+
+	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot(), scanOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := db.Refs().Head()
+	if err != nil {
+		return nil, err
+	}
+	tree := head.Tree()
+
+	if r.IsSingle() {
+
+		oid, err := resolveTree(ctx, db, r.Include[0])
+		if err != nil {
+			return nil, err
+		}
+		tree = oid.String()
+	}
+
+	if err := repo.Scan(scanner, db.Objects().ScanTree(tree)); err != nil {
+		return nil, err
+	}
+
+	var diffs []repository.FileDiff
+	if r.IsSingle() && !cached {
+		diffs, err = repo.DiffTree()
+	} else {
+		diffs, err = repo.Diff(cached)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	if err := db.Index().Update(repo.Index()); err != nil {
-		return err
+		return nil, err
+	}
+
+	return diffs, nil
+}
+
+// diffCommits A B と A..B はAとB、A...B はAとBのマージベースとBを比べる
+func diffCommits(ctx GotContextReaderWriter, db database.Database, pathspec types.Pathspec, r *types.RevisionRange) ([]repository.FileDiff, error) {
+
+	var a, b types.Revision
+	switch {
+	case len(r.Symmetric) == 1 && len(r.Include) == 0 && len(r.Exclude) == 0:
+		return diffMergeBase(ctx, db, pathspec, r.Symmetric[0])
+	case len(r.Include) == 2 && len(r.Exclude) == 0 && len(r.Symmetric) == 0:
+		a, b = r.Include[0], r.Include[1]
+	case len(r.Include) == 1 && len(r.Exclude) == 1 && len(r.Symmetric) == 0:
+		a, b = r.Exclude[0], r.Include[0]
+	default:
+		return nil, errors.New("diff takes one or two revisions, A..B or A...B")
+	}
+
+	aTree, err := resolveTree(ctx, db, a)
+	if err != nil {
+		return nil, err
+	}
+
+	bTree, err := resolveTree(ctx, db, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffTrees(db, pathspec, aTree, bTree)
+}
+
+func diffMergeBase(ctx GotContextReaderWriter, db database.Database, pathspec types.Pathspec, pair [2]types.Revision) ([]repository.FileDiff, error) {
+
+	a, err := resolveCommit(ctx, db, pair[0])
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := resolveCommit(ctx, db, pair[1])
+	if err != nil {
+		return nil, err
+	}
+
+	bases, err := repository.NewRevList(db.Objects()).MergeBases(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%s...%s: no merge base", a, b)
+	}
+
+	return diffTrees(db, pathspec, bases[0], b)
+}
+
+func diffTrees(db database.Database, pathspec types.Pathspec, a, b types.ObjectID) ([]repository.FileDiff, error) {
+
+	td := repository.NewTreeDiff(db.Objects(), repository.TreeDiffPathspec(pathspec))
+	if err := td.Diff(a, b); err != nil {
+		return nil, err
+	}
+
+	return td.FileDiffs()
+}
+
+// detectRenames オプションがなければdiff.renamesの設定で名前の変更とコピーを見つける
+func detectRenames(config database.Config, diffs []repository.FileDiff, opts DiffOptions) ([]repository.FileDiff, error) {
+
+	renames, copies := renameConfig(config, "diff.renames")
+	if opts.FindRenames != "" || opts.FindCopies {
+		renames, copies = true, opts.FindCopies
+	}
+
+	if !renames || opts.NoRenames {
+		return diffs, nil
+	}
+
+	score, err := repository.ParseRenameScore(opts.FindRenames)
+	if err != nil {
+		return nil, err
+	}
+
+	renameOpts := []repository.RenameOption{repository.RenameScore(score)}
+	if copies {
+		renameOpts = append(renameOpts, repository.FindCopies())
+	}
+
+	return repository.DetectRenames(diffs, renameOpts...), nil
+}
+
+// renameConfig keysのうち最初に設定されているもの。copiesならコピーも見つける。設定がなければ名前の変更だけを見つける
+func renameConfig(config database.Config, keys ...string) (renames, copies bool) {
+
+	for _, key := range keys {
+
+		v, ok := config.Get(key)
+		if !ok {
+			continue
+		}
+
+		if v == "copies" || v == "copy" {
+			return true, true
+		}
+
+		if b, ok := config.Bool(key); ok {
+			return b, false
+		}
+	}
+
+	return true, false
+}
+
+// diffAlgorithm --diff-algorithm、diff.algorithm、myersの順に決める。--minimalならmyersを最短にする
+func diffAlgorithm(config database.Config, opts DiffOptions) (repository.DiffAlgorithm, error) {
+
+	name := opts.Algorithm
+	if name == "" {
+
+		v, ok := config.Get("diff.algorithm")
+		if !ok {
+			v = string(repository.DiffMyers)
+		}
+		name = v
+	}
+
+	algorithm, err := repository.ParseDiffAlgorithm(name)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Minimal && algorithm == repository.DiffMyers {
+		return repository.DiffMinimal, nil
+	}
+
+	return algorithm, nil
+}
+
+func (opts DiffOptions) ignoreChanges() bool {
+	return opts.IgnoreAllSpace || opts.IgnoreSpaceChange || opts.IgnoreSpaceAtEOL || opts.IgnoreBlankLines
+}
+
+// diffOptions アルゴリズム、ハンクの行数、無視する空白、バイナリの出し方、diff属性のドライバー
+func diffOptions(ctx GotContextReader, config database.Config, diffs []repository.FileDiff, opts DiffOptions) ([]repository.DiffOption, error) {
+
+	algorithm, err := diffAlgorithm(config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	diffOpts := []repository.DiffOption{repository.Algorithm(algorithm)}
+
+	if n, ok := contextOption(config, opts.Context, "diff.context"); ok {
+		diffOpts = append(diffOpts, repository.Context(n))
+	}
+
+	if n, ok := contextOption(config, opts.InterHunkContext, "diff.interHunkContext"); ok {
+		diffOpts = append(diffOpts, repository.InterHunkContext(n))
+	}
+
+	var ws repository.Whitespace
+	if opts.IgnoreAllSpace {
+		ws |= repository.IgnoreAllSpace
+	}
+	if opts.IgnoreSpaceChange {
+		ws |= repository.IgnoreSpaceChange
+	}
+	if opts.IgnoreSpaceAtEOL {
+		ws |= repository.IgnoreSpaceAtEOL
+	}
+	diffOpts = append(diffOpts, repository.IgnoreWhitespace(ws))
+
+	if opts.IgnoreBlankLines {
+		diffOpts = append(diffOpts, repository.IgnoreBlankLines())
+	}
+
+	if opts.Binary {
+		diffOpts = append(diffOpts, repository.BinaryPatch())
+	}
+
+	if opts.WordDiffRegex != "" {
+
+		re, err := repository.CompileWordRegex(opts.WordDiffRegex)
+		if err != nil {
+			return nil, err
+		}
+		diffOpts = append(diffOpts, repository.WordRegex(re))
+	}
+
+	if v, ok := config.Get("diff.wordRegex"); ok {
+
+		re, err := repository.CompileWordRegex(v)
+		if err != nil {
+			return nil, err
+		}
+		diffOpts = append(diffOpts, repository.DefaultWordRegex(re))
+	}
+
+	// リポジトリの外のファイルにはdiff属性がない
+	if opts.NoIndex {
+		return diffOpts, nil
+	}
+
+	drivers, err := diffDrivers(ctx, config, diffs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(diffOpts, repository.Drivers(drivers)), nil
+}
+
+// contextOption オプションがなければkeyの設定。負の値は使わない
+func contextOption(config database.Config, n *int, key string) (int, bool) {
+
+	if n != nil {
+		return *n, *n >= 0
+	}
+
+	v, ok := config.Int(key)
+
+	return v, ok && v >= 0
+}
+
+// diffDrivers パスのdiff属性のドライバー。-diffならバイナリ、diffならテキストとして比べる。
+// diff=<driver>ならdiff.<driver>.xfuncnameか組み込みのパターンで関数を探し、diff.<driver>.binaryならバイナリにする。
+// 単語はdiff.<driver>.wordRegexで区切る
+func diffDrivers(ctx GotContextReader, config database.Config, diffs []repository.FileDiff) (func(path string) *repository.DiffDriver, error) {
+
+	paths := []string{}
+	for _, d := range diffs {
+		paths = append(paths, d.OldPath(), d.Path())
+	}
+
+	attrs, err := workspace.LoadAttributes(ctx.WorkspaceRoot(), filepath.Join(ctx.GotRoot(), "info", "attributes"), paths...)
+	if err != nil {
+		return nil, err
+	}
+
+	drivers := map[string]*repository.DiffDriver{}
+	var failed error
+
+	f := func(path string) *repository.DiffDriver {
+
+		state, name := attrs.Get(path, "diff")
+		switch state {
+		case repository.AttributeUnset:
+			return repository.NewDiffDriver(repository.DriverBinary(true))
+		case repository.AttributeSet:
+			return repository.NewDiffDriver(repository.DriverBinary(false))
+		case repository.AttributeUnspecified:
+			return nil
+		}
+
+		if driver, ok := drivers[name]; ok {
+			return driver
+		}
+
+		funcname, _ := repository.BuiltinFuncname(name)
+		if xfuncname, ok := config.Get(fmt.Sprintf("diff.%s.xfuncname", name)); ok {
+
+			var err error
+			if funcname, err = repository.NewFuncname(xfuncname); err != nil {
+				failed = err
+			}
+		}
+
+		driverOpts := []repository.DiffDriverOption{repository.DriverFuncname(funcname)}
+		if binary, ok := config.Bool(fmt.Sprintf("diff.%s.binary", name)); ok {
+			driverOpts = append(driverOpts, repository.DriverBinary(binary))
+		}
+
+		if wordRegex, ok := config.Get(fmt.Sprintf("diff.%s.wordRegex", name)); ok {
+
+			re, err := repository.CompileWordRegex(wordRegex)
+			if err != nil {
+				failed = err
+			}
+			driverOpts = append(driverOpts, repository.DriverWordRegex(re))
+		}
+
+		drivers[name] = repository.NewDiffDriver(driverOpts...)
+		return drivers[name]
+	}
+
+	// 先にすべてのパスのドライバーを読んで、正規表現の誤りを返す
+	for _, p := range paths {
+		f(p)
 	}
 
-	return nil
+	return f, failed
 }
@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/repository"
+)
+
+// checkWhitespace core.whitespaceに従って追加した行の空白の誤りを探し、gitと同じく位置と説明、誤りのある行を出す。foundは誤りがあったか
+func checkWhitespace(ctx GotContextWriter, config database.Config, diffs []repository.FileDiff) (found bool, err error) {
+
+	v, _ := config.Get("core.whitespace")
+	ws, err := repository.ParseWhitespace(v)
+	if err != nil {
+		return false, err
+	}
+
+	errs := repository.CheckWhitespace(diffs, ws)
+	for _, e := range errs {
+
+		ctx.Out(fmt.Sprintln(e), none)
+		if !e.EOF() {
+			ctx.Out(fmt.Sprintln("+"+e.Text), green)
+		}
+	}
+
+	return len(errs) > 0, nil
+}
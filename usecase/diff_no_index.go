@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
+	"github.com/mizuho-u/got/repository"
+)
+
+// nullDevice gitと同じく、このパスは存在しないファイルとして比べる
+const nullDevice = "/dev/null"
+
+// diffNoIndex --no-index。Pathsのふたつのパスを比べて、差分があればDifferencesErrorを返す。
+// リポジトリの中なら設定を読み、外ならユーザーごとの設定だけを読む
+func diffNoIndex(ctx GotContextReaderWriter, opts DiffOptions) error {
+
+	if len(opts.Paths) != 2 {
+		return errors.New("usage: got diff --no-index <path> <path>")
+	}
+
+	config := database.GlobalConfig()
+	if ctx.GotRoot() != "" {
+		config = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot()).Config()
+	}
+
+	a, b := filepath.Clean(opts.Paths[0]), filepath.Clean(opts.Paths[1])
+
+	// ファイルとディレクトリなら、gitと同じくディレクトリの中の同じ名前のファイルと比べる
+	aDir, bDir := isDir(ctx, a), isDir(ctx, b)
+	switch {
+	case aDir && !bDir && b != nullDevice:
+		a = filepath.Join(a, filepath.Base(b))
+	case bDir && !aDir && a != nullDevice:
+		b = filepath.Join(b, filepath.Base(a))
+	}
+
+	aFiles, err := loadNoIndexFiles(ctx, a)
+	if err != nil {
+		return err
+	}
+
+	bFiles, err := loadNoIndexFiles(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	// /dev/nullとの差分は、もう片方のパスの追加か削除にする
+	if a == nullDevice {
+		a = b
+	}
+	if b == nullDevice {
+		b = a
+	}
+
+	diffs, err := repository.DiffNoIndex(filepath.ToSlash(a), aFiles, filepath.ToSlash(b), bFiles)
+	if err != nil {
+		return err
+	}
+
+	changed, err := showDiffs(ctx, config, diffs, opts)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		return &DifferencesError{}
+	}
+
+	return nil
+}
+
+// loadNoIndexFiles 作業ディレクトリからのpathのファイル。/dev/nullならファイルはない
+func loadNoIndexFiles(ctx GotContextReader, path string) (map[string]*repository.NoIndexFile, error) {
+
+	if path == nullDevice {
+		return map[string]*repository.NoIndexFile{}, nil
+	}
+
+	files, err := workspace.LoadFiles(workingPath(ctx, path))
+	if err != nil {
+		return nil, &AccessError{Path: path}
+	}
+
+	return files, nil
+}
+
+func isDir(ctx GotContextReader, path string) bool {
+
+	info, err := os.Stat(workingPath(ctx, path))
+	return err == nil && info.IsDir()
+}
+
+// workingPath 相対パスは作業ディレクトリからのパスにする
+func workingPath(ctx GotContextReader, path string) string {
+
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(ctx.WorkingDirectory(), path)
+}
@@ -0,0 +1,289 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mizuho-u/got/repository"
+)
+
+// defaultStatWidth 端末の幅がわからないときの--statの幅
+const defaultStatWidth = 80
+
+// summary パッチのかわりに要約を出力するか
+func (opts DiffOptions) summary() bool {
+	return opts.Raw || opts.NameOnly || opts.NameStatus || opts.NumStat || opts.Stat || opts.ShortStat
+}
+
+// printSummary gitと同じく、--raw、--name-only、--name-status、--numstat、--stat、--shortstatの順に出力する
+func printSummary(ctx GotContextWriter, diffs []repository.FileDiff, opts DiffOptions) error {
+
+	// -zならパスの後ろと、--rawと--name-statusの状態の後ろをNULにする
+	sep, term := "\t", "\n"
+	if opts.NullTerminated {
+		sep, term = "\x00", "\x00"
+	}
+
+	for _, d := range diffs {
+
+		var line string
+		switch {
+		case opts.Raw:
+			line = d.Raw() + sep + summaryPaths(d, sep) + term
+		case opts.NameOnly:
+			line = d.Path() + term
+		case opts.NameStatus:
+			line = d.NameStatus() + sep + summaryPaths(d, sep) + term
+		default:
+			continue
+		}
+
+		if err := ctx.Out(line, none); err != nil {
+			return err
+		}
+	}
+
+	if opts.NumStat {
+
+		for _, d := range diffs {
+
+			// -zなら名前の変更は空のパスの後ろに元と新しいパスを並べる
+			name := statName(d)
+			if opts.NullTerminated && d.OldPath() != d.Path() {
+				name = term + d.OldPath() + term + d.Path()
+			}
+
+			// バイナリは行数のかわりに-
+			added, deleted := d.Stat()
+			counts := fmt.Sprintf("%d\t%d", added, deleted)
+			if d.Binary() {
+				counts = "-\t-"
+			}
+
+			if err := ctx.Out(fmt.Sprintf("%s\t%s%s", counts, name, term), none); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Stat {
+		if err := printStat(ctx, diffs, opts.StatWidth); err != nil {
+			return err
+		}
+	}
+
+	if (opts.ShortStat || opts.Stat) && len(diffs) != 0 {
+		return ctx.Out(shortStat(diffs), none)
+	}
+
+	return nil
+}
+
+// summaryPaths --rawと--name-statusのパス。名前の変更とコピーは元のパスと新しいパスをsepで区切る
+func summaryPaths(d repository.FileDiff, sep string) string {
+
+	s := d.NameStatus()
+	if strings.HasPrefix(s, "R") || strings.HasPrefix(s, "C") {
+		return d.OldPath() + sep + d.Path()
+	}
+
+	// --no-indexでは別々のパスでも、gitと同じく追加以外は元のパスだけを出す
+	if s == "A" {
+		return d.Path()
+	}
+
+	return d.OldPath()
+}
+
+// statName --statと--numstatのパス。名前の変更とコピーはgitと同じく共通の前後を外に出して dir/{a => b}/file にする
+func statName(d repository.FileDiff) string {
+
+	from, to := d.OldPath(), d.Path()
+	if from == to {
+		return to
+	}
+
+	// 共通の前と後ろはどちらも/で区切られたところまで
+	prefix := 0
+	for i := 0; i < len(from) && i < len(to) && from[i] == to[i]; i++ {
+		if from[i] == '/' {
+			prefix = i + 1
+		}
+	}
+
+	// 前があればその最後の/も後ろと共有できる
+	adjust := 0
+	if prefix > 0 {
+		adjust = 1
+	}
+
+	suffix := 0
+	at := func(s string, i int) byte {
+		if i == len(s) {
+			return 0
+		}
+		return s[i]
+	}
+	for i, j := len(from), len(to); prefix-adjust <= i && prefix-adjust <= j && at(from, i) == at(to, j); i, j = i-1, j-1 {
+		if at(from, i) == '/' {
+			suffix = len(from) - i
+		}
+	}
+
+	fromMid, toMid := max(len(from)-prefix-suffix, 0), max(len(to)-prefix-suffix, 0)
+	if prefix+suffix == 0 {
+		return from + " => " + to
+	}
+
+	return from[:prefix] + "{" + from[prefix:prefix+fromMid] + " => " + to[prefix:prefix+toMid] + "}" + from[len(from)-suffix:]
+}
+
+// printStat ファイルごとの変更行数と、+と-のヒストグラムをwidthに収まるように出力する
+func printStat(ctx GotContextWriter, diffs []repository.FileDiff, width int) error {
+
+	if width <= 0 {
+		width = defaultStatWidth
+	}
+
+	type fileStat struct {
+		name           string
+		added, deleted int
+		binary         bool
+		before, after  int
+	}
+
+	stats := []fileStat{}
+	maxLen, maxChange, binary := 0, 0, false
+	for _, d := range diffs {
+
+		added, deleted := d.Stat()
+		before, after := d.BinarySize()
+		stats = append(stats, fileStat{statName(d), added, deleted, d.Binary(), before, after})
+
+		maxLen = max(maxLen, len([]rune(statName(d))))
+		maxChange = max(maxChange, added+deleted)
+		binary = binary || d.Binary()
+	}
+
+	// バイナリがあればgitと同じく数字の幅をBinの幅にそろえる
+	numberWidth := len(fmt.Sprint(maxChange))
+	if binary {
+		numberWidth = max(numberWidth, len("Bin"))
+	}
+
+	// ファイル名に5/8、数字とヒストグラムに3/8を割り当てる。最低でもファイル名に10、ヒストグラムに6
+	width = max(width, 16+6+numberWidth)
+	graphWidth, nameWidth := maxChange, maxLen
+
+	if nameWidth+numberWidth+6+graphWidth > width {
+
+		if graphWidth > width*3/8-numberWidth-6 {
+			graphWidth = max(width*3/8-numberWidth-6, 6)
+		}
+
+		if nameWidth > width-numberWidth-6-graphWidth {
+			nameWidth = width - numberWidth - 6 - graphWidth
+		} else {
+			graphWidth = width - numberWidth - 6 - nameWidth
+		}
+	}
+
+	for _, s := range stats {
+
+		name, prefix := []rune(s.name), ""
+		if len(name) > nameWidth {
+
+			// 先頭を削って...をつける。途中に/があればそこから
+			prefix = "..."
+			name = name[len(name)-max(nameWidth-3, 0):]
+			if i := strings.IndexRune(string(name), '/'); i != -1 {
+				name = []rune(string(name)[i:])
+			}
+		}
+		padding := max(nameWidth-len(prefix)-len(name), 0)
+
+		if s.binary {
+
+			line := fmt.Sprintf(" %s%s%s | Bin %d -> %d bytes\n", prefix, string(name), strings.Repeat(" ", padding), s.before, s.after)
+			if err := ctx.Out(line, none); err != nil {
+				return err
+			}
+			continue
+		}
+
+		add, del := s.added, s.deleted
+		if graphWidth <= maxChange {
+
+			total := scaleLinear(add+del, graphWidth, maxChange)
+			if total < 2 && add != 0 && del != 0 {
+				total = 2
+			}
+
+			if add < del {
+				add = scaleLinear(add, graphWidth, maxChange)
+				del = total - add
+			} else {
+				del = scaleLinear(del, graphWidth, maxChange)
+				add = total - del
+			}
+		}
+
+		space := ""
+		if s.added+s.deleted != 0 {
+			space = " "
+		}
+
+		line := fmt.Sprintf(" %s%s%s | %*d%s", prefix, string(name), strings.Repeat(" ", padding), numberWidth, s.added+s.deleted, space)
+		if err := ctx.Out(line, none); err != nil {
+			return err
+		}
+		if err := ctx.Out(strings.Repeat("+", add), green); err != nil {
+			return err
+		}
+		if err := ctx.Out(strings.Repeat("-", del), red); err != nil {
+			return err
+		}
+		if err := ctx.Out("\n", none); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scaleLinear 変更があれば少なくともひとつは+か-を出すように、幅をひとつ狭くして縮めてから1を足す
+func scaleLinear(n, width, maxChange int) int {
+
+	if n == 0 {
+		return 0
+	}
+
+	return 1 + n*(width-1)/maxChange
+}
+
+// shortStat " 2 files changed, 3 insertions(+), 1 deletion(-)"
+func shortStat(diffs []repository.FileDiff) string {
+
+	insertions, deletions := 0, 0
+	for _, d := range diffs {
+		added, deleted := d.Stat()
+		insertions, deletions = insertions+added, deletions+deleted
+	}
+
+	plural := func(n int, s string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, s)
+		}
+		return fmt.Sprintf("%d %ss", n, s)
+	}
+
+	line := " " + plural(len(diffs), "file") + " changed"
+	if insertions != 0 || deletions == 0 {
+		line += ", " + plural(insertions, "insertion") + "(+)"
+	}
+	if deletions != 0 || insertions == 0 {
+		line += ", " + plural(deletions, "deletion") + "(-)"
+	}
+
+	return line + "\n"
+}
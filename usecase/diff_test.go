@@ -2,7 +2,13 @@ package usecase_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -102,7 +108,7 @@ index 43dd47e..0000000
 			}
 
 			out := &bytes.Buffer{}
-			if err := usecase.Diff(newContext(dir, "", "", out, out), false); err != nil {
+			if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{}); err != nil {
 				t.Error(err)
 			}
 
@@ -218,7 +224,7 @@ index 43dd47e..0000000
 			}
 
 			out := &bytes.Buffer{}
-			if err := usecase.Diff(newContext(dir, "", "", out, out), true); err != nil {
+			if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Cached: true}); err != nil {
 				t.Error(err)
 			}
 
@@ -231,3 +237,707 @@ index 43dd47e..0000000
 	}
 
 }
+
+func TestDiffPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "1.txt", []byte("one"))
+	createFile(t, dir, "a/2.txt", []byte("two"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "commit massage", time.Unix(1677142145, 0))
+
+	createFile(t, dir, "1.txt", []byte("changed"))
+	createFile(t, dir, "a/2.txt", []byte("changed"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Paths: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `diff --git a/a/2.txt b/a/2.txt
+index 64c5e58..21fb1ec 100644
+--- a/a/2.txt
++++ b/a/2.txt
+@@ -1,1 +1,1 @@
+-two
++changed
+`
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestDiffRevisions(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "1.txt", []byte("one\n")), createFile(t, dir, "a/2.txt", []byte("two\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "1.txt", []byte("one\nuno\n")), createFile(t, dir, "a/3.txt", []byte("three\n")))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	runGit(t, dir, "branch", "side", "HEAD~1")
+	createFile(t, dir, "1.txt", []byte("uno\n"))
+
+	secondToFirst := `diff --git a/1.txt b/1.txt
+index 5626abf..6f5a93f 100644
+--- a/1.txt
++++ b/1.txt
+@@ -1,1 +1,2 @@
+ one
++uno
+diff --git a/a/3.txt b/a/3.txt
+new file mode 100644
+index 0000000..2bdf67a
+--- /dev/null
++++ b/a/3.txt
+@@ -0,0 +1,1 @@
++three
+`
+
+	testt := []struct {
+		description string
+		opts        usecase.DiffOptions
+		expect      string
+	}{
+		{
+			description: "workspace against a commit",
+			opts:        usecase.DiffOptions{Revisions: []string{"HEAD~1"}},
+			expect: `diff --git a/1.txt b/1.txt
+index 5626abf..e438487 100644
+--- a/1.txt
++++ b/1.txt
+@@ -1,1 +1,1 @@
+-one
++uno
+diff --git a/a/3.txt b/a/3.txt
+new file mode 100644
+index 0000000..2bdf67a
+--- /dev/null
++++ b/a/3.txt
+@@ -0,0 +1,1 @@
++three
+`,
+		},
+		{
+			description: "index against a commit",
+			opts:        usecase.DiffOptions{Cached: true, Revisions: []string{"HEAD~1"}},
+			expect:      secondToFirst,
+		},
+		{
+			description: "two commits",
+			opts:        usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}},
+			expect:      secondToFirst,
+		},
+		{
+			description: "range",
+			opts:        usecase.DiffOptions{Revisions: []string{"HEAD~1..HEAD"}},
+			expect:      secondToFirst,
+		},
+		{
+			description: "merge base",
+			opts:        usecase.DiffOptions{Revisions: []string{"side...HEAD"}},
+			expect:      secondToFirst,
+		},
+		{
+			description: "merge base is the other side",
+			opts:        usecase.DiffOptions{Revisions: []string{"HEAD...side"}},
+			expect:      "",
+		},
+		{
+			description: "limited with paths",
+			opts:        usecase.DiffOptions{Revisions: []string{"HEAD", "HEAD~1"}, Paths: []string{"a"}},
+			expect: `diff --git a/a/3.txt b/a/3.txt
+deleted file mode 100644
+index 2bdf67a..0000000
+--- a/a/3.txt
++++ /dev/null
+@@ -1,1 +0,0 @@
+-three
+`,
+		},
+	}
+
+	for _, tc := range testt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			out := &bytes.Buffer{}
+			if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.expect {
+				t.Errorf("expect \n%s, got \n%s", tc.expect, out)
+			}
+		})
+	}
+
+}
+
+func TestSplitRevisionArgs(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "1.txt", []byte("one\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	ctx := newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{})
+
+	revisions, paths, err := usecase.SplitRevisionArgs(ctx, []string{"HEAD", "main..HEAD", "1.txt", "*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(revisions, paths) != "[HEAD main..HEAD] [1.txt *.go]" {
+		t.Errorf("expect [HEAD main..HEAD] [1.txt *.go], got %v %v", revisions, paths)
+	}
+
+	for _, args := range [][]string{{"nope"}, {"HEAD", "1.txt", "nope"}} {
+
+		if _, _, err := usecase.SplitRevisionArgs(ctx, args); err == nil || !strings.Contains(err.Error(), "ambiguous argument 'nope'") {
+			t.Errorf("%v: expect ambiguous argument error, got %v", args, err)
+		}
+	}
+
+}
+
+func TestDiffSummaryLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	long := "very/long/directory/name/for/testing/the/stat/output/file.txt"
+	add(t, dir,
+		createFile(t, dir, "big", []byte(strings.Repeat("line\n", 100))),
+		createFile(t, dir, "small", []byte("1\n2\n3\n")),
+		createFile(t, dir, "gone", []byte("gone\n")),
+		createFile(t, dir, long, []byte("x\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	removeAll(t, dir, "gone")
+	add(t, dir,
+		createFile(t, dir, "big", []byte(strings.Repeat("changed\n", 60))),
+		createFile(t, dir, "small", []byte("1\n3\n4\n")),
+		createFile(t, dir, "new", []byte("new\n")),
+		createFile(t, dir, long, []byte("x\ny\n")))
+	runGit(t, dir, "rm", "-q", "--cached", "gone")
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	testt := []struct {
+		args  []string
+		width int
+		opts  usecase.DiffOptions
+	}{
+		{args: []string{"--stat"}, opts: usecase.DiffOptions{Stat: true}},
+		{args: []string{"--stat"}, width: 40, opts: usecase.DiffOptions{Stat: true}},
+		{args: []string{"--numstat"}, opts: usecase.DiffOptions{NumStat: true}},
+		{args: []string{"--numstat", "-z"}, opts: usecase.DiffOptions{NumStat: true, NullTerminated: true}},
+		{args: []string{"--shortstat"}, opts: usecase.DiffOptions{ShortStat: true}},
+		{args: []string{"--name-only"}, opts: usecase.DiffOptions{NameOnly: true}},
+		{args: []string{"--name-status"}, opts: usecase.DiffOptions{NameStatus: true}},
+		{args: []string{"--name-status", "-z"}, opts: usecase.DiffOptions{NameStatus: true, NullTerminated: true}},
+		{args: []string{"--raw"}, opts: usecase.DiffOptions{Raw: true}},
+		{args: []string{"--raw", "-z"}, opts: usecase.DiffOptions{Raw: true, NullTerminated: true}},
+	}
+
+	for _, tc := range testt {
+
+		width := tc.width
+		if width == 0 {
+			width = 80
+		}
+
+		cmd := exec.Command("git", append([]string{"-C", dir, "diff"}, append(tc.args, "HEAD~1", "HEAD")...)...)
+		cmd.Env = append(os.Environ(), fmt.Sprintf("COLUMNS=%d", width))
+		expect, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+		tc.opts.StatWidth = tc.width
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v width %d: expect \n%q, got \n%q", tc.args, width, expect, out)
+		}
+	}
+
+}
+
+func TestDiffRenamesLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir,
+		createFile(t, dir, "b.txt", []byte(strings.Repeat("line\n", 30))),
+		createFile(t, dir, "src/pkg/a.txt", []byte("a\n")),
+		createFile(t, dir, "dir/a/file", []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n")),
+		createFile(t, dir, "mod.txt", []byte("1\n2\n3\n4\n5\n")),
+		createFile(t, dir, "e1", []byte{}))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	removeAll(t, dir, "b.txt")
+	removeAll(t, dir, "src/pkg")
+	removeAll(t, dir, "dir/a")
+	removeAll(t, dir, "e1")
+	createFile(t, dir, "c.txt", []byte(strings.Repeat("line\n", 30)+strings.Repeat("more\n", 28)))
+	createFile(t, dir, "src/lib/a.txt", []byte("a\n"))
+	createFile(t, dir, "copy.txt", []byte("a\n"))
+	createFile(t, dir, "dir/b/file", []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"))
+	createFile(t, dir, "mod.txt", []byte("1\n2\n3\n4\n5\n6\n"))
+	createFile(t, dir, "modcopy.txt", []byte("1\n2\n3\n4\n5\n6\n"))
+	createFile(t, dir, "e2", []byte{})
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "second")
+
+	testt := []struct {
+		args []string
+		opts usecase.DiffOptions
+	}{
+		{args: []string{"--name-status"}, opts: usecase.DiffOptions{NameStatus: true}},
+		{args: []string{"--name-status", "-M90%"}, opts: usecase.DiffOptions{NameStatus: true, FindRenames: "90%"}},
+		{args: []string{"--name-status", "-C"}, opts: usecase.DiffOptions{NameStatus: true, FindCopies: true}},
+		{args: []string{"--name-status", "--no-renames"}, opts: usecase.DiffOptions{NameStatus: true, NoRenames: true}},
+		{args: []string{"--raw", "-z"}, opts: usecase.DiffOptions{Raw: true, NullTerminated: true}},
+		{args: []string{"--numstat"}, opts: usecase.DiffOptions{NumStat: true}},
+		{args: []string{"--numstat", "-z"}, opts: usecase.DiffOptions{NumStat: true, NullTerminated: true}},
+		{args: []string{"--stat"}, opts: usecase.DiffOptions{Stat: true}},
+		{args: []string{"--", "src/pkg", "copy.txt", "e1", "e2"}, opts: usecase.DiffOptions{Paths: []string{"src/pkg", "copy.txt", "e1", "e2"}}},
+	}
+
+	for _, tc := range testt {
+
+		cmd := exec.Command("git", append([]string{"-C", dir, "diff", "HEAD~1", "HEAD"}, tc.args...)...)
+		cmd.Env = append(os.Environ(), "COLUMNS=80")
+		expect, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect \n%q, got \n%q", tc.args, expect, out)
+		}
+	}
+
+}
+
+func TestDiffAlgorithmLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "f.c", []byte(`int foo() {
+    int x = 1;
+    return x;
+}
+
+int bar() {
+    int y = 2;
+    return y;
+}
+
+int main() {
+    foo();
+    bar();
+    return 0;
+}
+`)))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "f.c", []byte(`int baz() {
+    int z = 3;
+    return z;
+}
+
+int foo() {
+    int x = 1;
+    return x;
+}
+
+int main() {
+    foo();
+    baz();
+    return 0;
+}
+`)))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	testt := []struct {
+		args   []string
+		config string
+		opts   usecase.DiffOptions
+	}{
+		{args: []string{"--diff-algorithm=myers"}, opts: usecase.DiffOptions{Algorithm: "myers"}},
+		{args: []string{"--minimal"}, opts: usecase.DiffOptions{Minimal: true}},
+		{args: []string{"--diff-algorithm=patience"}, opts: usecase.DiffOptions{Algorithm: "patience"}},
+		{args: []string{"--diff-algorithm=histogram"}, opts: usecase.DiffOptions{Algorithm: "histogram"}},
+		{config: "patience"},
+		{args: []string{"--diff-algorithm=default"}, config: "histogram", opts: usecase.DiffOptions{Algorithm: "default"}},
+	}
+
+	for _, tc := range testt {
+
+		if tc.config != "" {
+			gitConfig(t, dir, "diff.algorithm", tc.config)
+		}
+
+		expect, err := exec.Command("git", append([]string{"-C", dir, "diff", "HEAD~1", "HEAD"}, tc.args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v %s: expect \n%s, got \n%s", tc.args, tc.config, expect, out)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Algorithm: "nope"}); err == nil {
+		t.Error("expect an error for an unknown algorithm")
+	}
+
+}
+
+func TestDiffHunkOptionsLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, ".gitattributes", []byte("*.go diff=golang\n*.txt diff=mine\n")))
+	add(t, dir, createFile(t, dir, "main.go", []byte(`package main
+
+func foo() int {
+	x := 1
+	y := 2
+	z := 3
+	return x + y + z
+}
+
+type bar struct {
+	a int
+	b int
+	c int
+	d int
+}
+`)))
+	add(t, dir, createFile(t, dir, "notes.txt", []byte("# one\na\nb\nc\nd\ne\n# two\nf\ng\nh\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "main.go", []byte(`package main
+
+func foo() int {
+	x := 1
+	y :=   2
+
+	z := 3
+	return x + y + z
+}
+
+type bar struct {
+	a int
+	b int
+	c int
+	d string
+}
+`)))
+	add(t, dir, createFile(t, dir, "notes.txt", []byte("# one\na\nb\nc\nd\ne\n# two\nf\nG\nh\n")))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	gitConfig(t, dir, "diff.mine.xfuncname", "^# (.*)$")
+
+	one, two := 1, 2
+	testt := []struct {
+		args []string
+		opts usecase.DiffOptions
+	}{
+		{args: []string{}},
+		{args: []string{"-U1"}, opts: usecase.DiffOptions{Context: &one}},
+		{args: []string{"-U1", "--inter-hunk-context=2"}, opts: usecase.DiffOptions{Context: &one, InterHunkContext: &two}},
+		{args: []string{"-w"}, opts: usecase.DiffOptions{IgnoreAllSpace: true}},
+		{args: []string{"-b", "-U1"}, opts: usecase.DiffOptions{IgnoreSpaceChange: true, Context: &one}},
+		{args: []string{"--ignore-space-at-eol", "--ignore-blank-lines", "-U1"}, opts: usecase.DiffOptions{IgnoreSpaceAtEOL: true, IgnoreBlankLines: true, Context: &one}},
+		{args: []string{"-w", "--ignore-blank-lines", "--numstat"}, opts: usecase.DiffOptions{IgnoreAllSpace: true, IgnoreBlankLines: true, NumStat: true}},
+	}
+
+	for _, tc := range testt {
+
+		expect, err := exec.Command("git", append([]string{"-C", dir, "diff", "HEAD~1", "HEAD"}, tc.args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect \n%s, got \n%s", tc.args, expect, out)
+		}
+	}
+
+	gitConfig(t, dir, "diff.mine.xfuncname", "([")
+
+	out := &bytes.Buffer{}
+	if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}}); err == nil {
+		t.Error("expect an error for an invalid xfuncname")
+	}
+
+}
+
+func TestDiffBinaryLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, ".gitattributes", []byte("*.dat -diff\nforced.bin diff\n")))
+	add(t, dir, createFile(t, dir, "image.bin", append([]byte("PNG\x00"), bytes.Repeat([]byte("pixels"), 100)...)))
+	add(t, dir, createFile(t, dir, "text.dat", []byte("looks like text\n")))
+	add(t, dir, createFile(t, dir, "forced.bin", []byte("a\x00b\nx\ny\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "image.bin", append([]byte("PNG\x00"), bytes.Repeat([]byte("pixelz"), 120)...)))
+	add(t, dir, createFile(t, dir, "text.dat", []byte("looks like text\nbut is not\n")))
+	add(t, dir, createFile(t, dir, "forced.bin", []byte("a\x00c\nx\nz\n")))
+	add(t, dir, createFile(t, dir, "new.bin", []byte{0, 1, 2}))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	testt := []struct {
+		args []string
+		opts usecase.DiffOptions
+	}{
+		{args: []string{}},
+		{args: []string{"--stat"}, opts: usecase.DiffOptions{Stat: true}},
+		{args: []string{"--numstat"}, opts: usecase.DiffOptions{NumStat: true}},
+	}
+
+	for _, tc := range testt {
+
+		expect, err := exec.Command("git", append([]string{"-C", dir, "diff", "HEAD~1", "HEAD"}, tc.args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect \n%s, got \n%s", tc.args, expect, out)
+		}
+	}
+
+	// --binaryのパッチはgit applyで元に戻せる
+	out := &bytes.Buffer{}
+	if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}, Binary: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "GIT binary patch\n") {
+		t.Fatalf("expect a binary patch, got \n%s", out)
+	}
+
+	apply := exec.Command("git", "-C", dir, "apply", "-R", "--index")
+	apply.Stdin = out
+	if msg, err := apply.CombinedOutput(); err != nil {
+		t.Fatalf("%s\n%s", msg, out)
+	}
+
+	tree, err := exec.Command("git", "-C", dir, "write-tree").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD~1^{tree}").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(tree) != string(expect) {
+		t.Errorf("expect tree %s, got %s", expect, tree)
+	}
+
+}
+
+func TestDiffWordDiffLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, ".gitattributes", []byte("*.go diff=golang\n")))
+	add(t, dir, createFile(t, dir, "notes.txt", []byte("ctx a\none two three\nalpha beta gamma delete me\n\nctx b\nfoo  bar\nx y\nremoved line\nctx c\n")))
+	add(t, dir, createFile(t, dir, "main.go", []byte("func(a, b int) {\n\treturn foo(a)+bar(b)\n}\nz\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "notes.txt", []byte("ctx a\none 2 three\nalpha gamma delta\n\nctx b\nfoo bar\nx y\nnew\nadded line\nctx c\n")))
+	add(t, dir, createFile(t, dir, "main.go", []byte("func(a, c int) {\n\treturn foo(c)*bar(b)\n}\nz\n")))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	gitConfig(t, dir, "diff.golang.wordRegex", "[[:alnum:]]+|[^[:space:]]")
+
+	testt := []struct {
+		args []string
+		opts usecase.DiffOptions
+	}{
+		{args: []string{"--word-diff"}, opts: usecase.DiffOptions{WordDiff: "plain"}},
+		{args: []string{"--word-diff=porcelain"}, opts: usecase.DiffOptions{WordDiff: "porcelain"}},
+		{args: []string{"--word-diff-regex=."}, opts: usecase.DiffOptions{WordDiffRegex: "."}},
+		{args: []string{"--word-diff=porcelain", "--word-diff-regex=[a-z]+"}, opts: usecase.DiffOptions{WordDiff: "porcelain", WordDiffRegex: "[a-z]+"}},
+	}
+
+	for _, tc := range testt {
+
+		expect, err := exec.Command("git", append([]string{"-C", dir, "diff", "HEAD~1", "HEAD"}, tc.args...)...).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tc.opts.Revisions = []string{"HEAD~1", "HEAD"}
+
+		out := &bytes.Buffer{}
+		if err := usecase.Diff(newContext(dir, "", "", out, out), tc.opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect \n%s, got \n%s", tc.args, expect, out)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}, WordDiff: "words"}); err == nil {
+		t.Error("expect an error for an unknown --word-diff mode")
+	}
+
+}
+
+func TestDiffNoIndexLikeGit(t *testing.T) {
+
+	dir := t.TempDir()
+
+	createFile(t, dir, "f1", []byte("a\nb\nc\n"))
+	createFile(t, dir, "f2", []byte("a\nB\nc\n"))
+	createFile(t, dir, "d1/same", []byte("x\ny\n"))
+	createFile(t, dir, "d2/same", []byte("x\ny\n"))
+	createFile(t, dir, "d1/sub/m", []byte("1\n2\n3\n"))
+	createFile(t, dir, "d2/sub/m", []byte("1\n22\n3\n"))
+	createFile(t, dir, "d1/old", []byte("gone\nfile\n"))
+	createFile(t, dir, "d2/new", []byte("new\nfile\n"))
+	createFile(t, dir, "d2/f1", []byte("a\nb\nc\nd\n"))
+
+	testt := []struct {
+		args []string
+		opts usecase.DiffOptions
+	}{
+		{args: []string{"f1", "f2"}},
+		{args: []string{"d1", "d2"}},
+		{args: []string{"f1", "d2"}},
+		{args: []string{"/dev/null", "f1"}},
+		{args: []string{"--no-renames", "d1", "d2"}, opts: usecase.DiffOptions{NoRenames: true}},
+		{args: []string{"--stat", "d1", "d2"}, opts: usecase.DiffOptions{Stat: true}},
+		{args: []string{"--name-status", "d1", "d2"}, opts: usecase.DiffOptions{NameStatus: true}},
+	}
+
+	for _, tc := range testt {
+
+		// 差分があればgitと同じく終了コード1
+		git := exec.Command("git", append([]string{"diff", "--no-index"}, tc.args...)...)
+		git.Dir = dir
+		expect, err := git.Output()
+		if exit, ok := err.(*exec.ExitError); !ok || exit.ExitCode() != usecase.ExitError {
+			t.Fatalf("%v: expect git to exit with 1, got %v", tc.args, err)
+		}
+
+		tc.opts.NoIndex = true
+		tc.opts.Paths = tc.args[len(tc.args)-2:]
+
+		out := &bytes.Buffer{}
+		ctx := usecase.NewContext(context.Background(), "", "", "", "", out, out, usecase.WithWorkingDirectory(dir))
+
+		var differences *usecase.DifferencesError
+		if err := usecase.Diff(ctx, tc.opts); !errors.As(err, &differences) {
+			t.Fatalf("%v: expect differences, got %v", tc.args, err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect \n%s, got \n%s", tc.args, expect, out)
+		}
+	}
+
+	ctx := usecase.NewContext(context.Background(), "", "", "", "", &bytes.Buffer{}, &bytes.Buffer{}, usecase.WithWorkingDirectory(dir))
+	if err := usecase.Diff(ctx, usecase.DiffOptions{NoIndex: true, Paths: []string{"d1/same", "d2/same"}}); err != nil {
+		t.Errorf("expect no differences, got %v", err)
+	}
+
+	var access *usecase.AccessError
+	if err := usecase.Diff(ctx, usecase.DiffOptions{NoIndex: true, Paths: []string{"f1", "nope"}}); !errors.As(err, &access) {
+		t.Errorf("expect an access error, got %v", err)
+	}
+
+}
+
+func TestDiffCheckLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir, createFile(t, dir, "f.txt", []byte("a\nb\n\n\n")))
+	add(t, dir, createFile(t, dir, "g.txt", []byte("x\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	add(t, dir, createFile(t, dir, "f.txt", []byte("a \nb\n\tc\n  \tx\n        eight\n \t \tk\ncr\r\n\n\n\n")))
+	add(t, dir, createFile(t, dir, "g.txt", []byte("x\n\n")))
+	commit(t, dir, "", "", "second", time.Unix(1677142146, 0))
+
+	for _, ws := range []string{"", "indent-with-non-tab", "tab-in-indent,cr-at-eol", "-trailing,tabwidth=4,indent", "-blank-at-eof"} {
+
+		gitConfig(t, dir, "core.whitespace", ws)
+
+		expect, _ := exec.Command("git", "-C", dir, "diff", "--check", "HEAD~1", "HEAD").Output()
+
+		out := &bytes.Buffer{}
+		err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}, Check: true})
+
+		var check *usecase.WhitespaceCheckError
+		if !errors.As(err, &check) {
+			t.Fatalf("%q: expect WhitespaceCheckError, got %v", ws, err)
+		}
+
+		if msg, code := usecase.Report(err); msg != "" || code != usecase.ExitCheck {
+			t.Errorf("%q: unexpected report %q %d", ws, msg, code)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%q: expect \n%s, got \n%s", ws, expect, out)
+		}
+	}
+
+	gitConfig(t, dir, "core.whitespace", "tab-in-indent,indent-with-non-tab")
+
+	out := &bytes.Buffer{}
+	err := usecase.Diff(newContext(dir, "", "", out, out), usecase.DiffOptions{Revisions: []string{"HEAD~1", "HEAD"}, Check: true})
+	if msg, code := usecase.Report(err); msg != "fatal: cannot enforce both tab-in-indent and indent-with-non-tab" || code != usecase.ExitFatal {
+		t.Errorf("unexpected report %q %d", msg, code)
+	}
+
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/repository"
+)
+
+// wordStyle 単語の前後につける文字と色
+type wordStyle struct {
+	prefix, suffix string
+	color          ColorAttribute
+}
+
+// wordDiffStyle --word-diffの出し方。newlineは単語の中の改行のかわりに出す
+type wordDiffStyle struct {
+	old, new, context wordStyle
+	newline           string
+}
+
+// wordDiffStyles gitと同じく、plainは[-old-]{+new+}、colorは色だけ、porcelainは行の先頭に記号をつけて改行を~にする
+var wordDiffStyles = map[string]*wordDiffStyle{
+	"plain": {
+		old:     wordStyle{"[-", "-]", red},
+		new:     wordStyle{"{+", "+}", green},
+		newline: "\n",
+	},
+	"color": {
+		old:     wordStyle{"", "", red},
+		new:     wordStyle{"", "", green},
+		newline: "\n",
+	},
+	"porcelain": {
+		old:     wordStyle{"-", "\n", none},
+		new:     wordStyle{"+", "\n", none},
+		context: wordStyle{" ", "\n", none},
+		newline: "~\n",
+	},
+}
+
+// wordDiffStyle 単語で比べなければnil。--word-diff-regexだけならplainにする
+func (opts DiffOptions) wordDiffStyle() (*wordDiffStyle, error) {
+
+	mode := opts.WordDiff
+	if mode == "" && opts.WordDiffRegex != "" {
+		mode = "plain"
+	}
+
+	if mode == "" || mode == "none" {
+		return nil, nil
+	}
+
+	style, ok := wordDiffStyles[mode]
+	if !ok {
+		return nil, fmt.Errorf("bad --word-diff argument: %s", mode)
+	}
+
+	return style, nil
+}
+
+// printWords 単語ごとに、改行で区切った部分をスタイルの文字で囲んで出す。空の部分は変更のない行だけ出す
+func printWords(ctx GotContextWriter, words []*repository.Word, style *wordDiffStyle) {
+
+	for _, w := range words {
+
+		st := style.context
+		switch w.Diff() {
+		case repository.Deletion:
+			st = style.old
+		case repository.Insertion:
+			st = style.new
+		default:
+		}
+
+		text := w.Text()
+		for text != "" {
+
+			segment, rest, newline := strings.Cut(text, "\n")
+			if segment != "" || w.Line() {
+				ctx.Out(st.prefix+segment+st.suffix, st.color)
+			}
+
+			if !newline {
+				break
+			}
+
+			ctx.Out(style.newline, none)
+			text = rest
+		}
+	}
+}
+
+// colorMoved --color-moved、diff.colorMoved、noの順に決める
+func colorMoved(config database.Config, opts DiffOptions) (repository.ColorMoved, error) {
+
+	v := opts.ColorMoved
+	if v == "" {
+
+		var ok bool
+		if v, ok = config.Get("diff.colorMoved"); !ok {
+			v = string(repository.MovedNo)
+		}
+	}
+
+	return repository.ParseColorMoved(v)
+}
+
+// movedColor 移動した行の色。gitと同じく削除はmagentaとblue、追加はcyanとyellowを交互に使い、ブロックの中の行は薄くする
+func movedColor(c ColorAttribute, moved, alternative, dimmed bool) ColorAttribute {
+
+	if !moved {
+		return c
+	}
+
+	switch {
+	case c == red && alternative:
+		c = blue
+	case c == red:
+		c = magenta
+	case c == green && alternative:
+		c = yellow
+	case c == green:
+		c = cyan
+	}
+
+	switch {
+	case dimmed && alternative:
+		return c | faint | italic
+	case dimmed:
+		return c | faint
+	}
+
+	return c | bold
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mizuho-u/got/io/database"
+)
+
+// editor GIT_EDITOR、core.editor、VISUAL、EDITORの順に使うエディタを決める
+func editor(config database.Config) string {
+
+	if e := os.Getenv("GIT_EDITOR"); e != "" {
+		return e
+	}
+
+	if e, ok := config.Get("core.editor"); ok && e != "" {
+		return e
+	}
+
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := os.Getenv(env); e != "" {
+			return e
+		}
+	}
+
+	return "vi"
+}
+
+// launchEditor pathをエディタで開いて、閉じられるまで待つ
+func launchEditor(config database.Config, path string) error {
+
+	e := editor(config)
+	if e == ":" {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", e+` "$@"`, e, path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("there was a problem with the editor '%s'", e)
+	}
+
+	return nil
+}
+
+// editMessage COMMIT_EDITMSGにtemplateを書き込み、editならエディタで編集させる。
+// hooksがあればcommit-msgフックにも渡し、書き換えられたメッセージを読み直す
+func editMessage(ctx GotContextReader, config database.Config, template string, edit bool, hooks *hooks) (string, error) {
+
+	path := filepath.Join(ctx.GotRoot(), "COMMIT_EDITMSG")
+
+	if err := os.WriteFile(path, []byte(template), 0644); err != nil {
+		return "", err
+	}
+
+	if edit {
+		if err := launchEditor(config, path); err != nil {
+			return "", err
+		}
+	}
+
+	if hooks != nil {
+		if err := hooks.run("commit-msg", nil, path); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,256 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/mizuho-u/got/io/database"
+)
+
+// gitと同じ終了コード
+const (
+	ExitOK    = 0
+	ExitError = 1
+	// ExitCheck diff --checkで空白の誤りがあった
+	ExitCheck = 2
+	ExitFatal = 128
+	ExitUsage = 129
+)
+
+// ExitCoder 終了コードを決めているエラー
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+type NotRepositoryError struct {
+	Err error
+}
+
+func (e *NotRepositoryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NotRepositoryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NotRepositoryError) ExitCode() int {
+	return ExitFatal
+}
+
+type InvalidRevisionError struct {
+	Revision string
+	Reason   string
+}
+
+func (e *InvalidRevisionError) Error() string {
+	return e.Reason
+}
+
+func (e *InvalidRevisionError) ExitCode() int {
+	return ExitFatal
+}
+
+type AmbiguousObjectError struct {
+	Prefix string
+	Hints  []string
+}
+
+func (e *AmbiguousObjectError) Error() string {
+
+	msg := fmt.Sprintf("short SHA1 %s is ambiguous\n", e.Prefix)
+	for _, h := range e.Hints {
+		msg += fmt.Sprintf("hint: %s\n", h)
+	}
+
+	return msg
+}
+
+func (e *AmbiguousObjectError) ExitCode() int {
+	return ExitFatal
+}
+
+// ConflictError ワークスペースの変更が失われるので中断した
+type ConflictError struct {
+	Conflicts []error
+}
+
+func (e *ConflictError) Error() string {
+	return errors.Join(e.Conflicts...).Error()
+}
+
+func (e *ConflictError) ExitCode() int {
+	return ExitError
+}
+
+// LockHeldError ほかのプロセスがロックファイルを作っている
+type LockHeldError struct {
+	Path string
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("Unable to create '%s': File exists.", e.Path)
+}
+
+func (e *LockHeldError) ExitCode() int {
+	return ExitFatal
+}
+
+// NothingToCommitError 状態はすでに出力しているので、終了コードだけを返す
+type NothingToCommitError struct {
+	Reason string
+}
+
+func (e *NothingToCommitError) Error() string {
+	return e.Reason
+}
+
+func (e *NothingToCommitError) ExitCode() int {
+	return ExitError
+}
+
+// DifferencesError --no-indexで差分があった。差分はすでに出力しているので、gitと同じく終了コードだけを返す
+type DifferencesError struct{}
+
+func (e *DifferencesError) Error() string {
+	return "files differ"
+}
+
+func (e *DifferencesError) ExitCode() int {
+	return ExitError
+}
+
+// WhitespaceCheckError --checkで空白の誤りがあった。誤りはすでに出力しているので、gitと同じく終了コードだけを返す
+type WhitespaceCheckError struct{}
+
+func (e *WhitespaceCheckError) Error() string {
+	return "whitespace errors"
+}
+
+func (e *WhitespaceCheckError) ExitCode() int {
+	return ExitCheck
+}
+
+// AccessError --no-indexで比べるパスが読めない
+type AccessError struct {
+	Path string
+}
+
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("Could not access '%s'", e.Path)
+}
+
+func (e *AccessError) ExitCode() int {
+	return ExitError
+}
+
+// HookError フックが失敗した。理由はフック自身が出力している
+type HookError struct {
+	Name string
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook failed: %s", e.Name, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HookError) ExitCode() int {
+	return ExitError
+}
+
+// lockError ロックファイルが作れなかったエラーをLockHeldErrorにする
+func lockError(err error) error {
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && errors.Is(err, fs.ErrExist) && strings.HasSuffix(pathErr.Path, ".lock") {
+		return &LockHeldError{Path: pathErr.Path}
+	}
+
+	return err
+}
+
+// openIndexForUpdate インデックスをロックして開く
+func openIndexForUpdate(db database.Database) error {
+	return lockError(db.Index().OpenForUpdate())
+}
+
+const lockHeldHint = `Another git process seems to be running in this repository, e.g.
+an editor opened by 'git commit'. Please make sure all processes
+are terminated then try again. If it still fails, a git process
+may have crashed in this repository earlier:
+remove the file manually to continue.`
+
+// Report errをgitと同じ形式のメッセージと終了コードにする。メッセージは標準エラーに出力する
+func Report(err error) (message string, code int) {
+
+	var (
+		notRepository   *NotRepositoryError
+		invalidRevision *InvalidRevisionError
+		ambiguous       *AmbiguousObjectError
+		conflict        *ConflictError
+		lockHeld        *LockHeldError
+		nothingToCommit *NothingToCommitError
+		differences     *DifferencesError
+		whitespace      *WhitespaceCheckError
+		hook            *HookError
+		applyInput      *ApplyInputError
+		applyFailed     *ApplyFailedError
+		applyWhitespace *WhitespaceApplyError
+	)
+
+	switch {
+	case err == nil:
+		return "", ExitOK
+	case errors.Is(err, ErrNoPathIgnored):
+		return "", ExitError
+	case errors.Is(err, ErrEmptyCommitMessage), errors.Is(err, ErrWhitespaceCommit):
+		return err.Error(), ExitError
+	case errors.As(err, &nothingToCommit):
+		return "", nothingToCommit.ExitCode()
+	case errors.As(err, &differences):
+		return "", differences.ExitCode()
+	case errors.As(err, &whitespace):
+		return "", whitespace.ExitCode()
+	case errors.As(err, &hook):
+		return "", hook.ExitCode()
+	case errors.As(err, &applyFailed):
+		return "", applyFailed.ExitCode()
+	case errors.As(err, &applyInput):
+		return "error: " + applyInput.Error(), applyInput.ExitCode()
+	case errors.As(err, &applyWhitespace):
+		return "error: " + applyWhitespace.Error(), applyWhitespace.ExitCode()
+	case errors.As(err, &notRepository):
+		return "fatal: " + notRepository.Error(), notRepository.ExitCode()
+	case errors.As(err, &invalidRevision):
+		return "fatal: " + invalidRevision.Error(), invalidRevision.ExitCode()
+	case errors.As(err, &ambiguous):
+		return fmt.Sprintf("error: %sfatal: ambiguous argument '%s': unknown revision or path not in the working tree.", ambiguous, ambiguous.Prefix), ambiguous.ExitCode()
+	case errors.As(err, &conflict):
+		msgs := []string{}
+		for _, c := range conflict.Conflicts {
+			msgs = append(msgs, "error: "+c.Error())
+		}
+		return strings.Join(msgs, "\n") + "\nAborting", conflict.ExitCode()
+	case errors.As(err, &lockHeld):
+		return fmt.Sprintf("fatal: %s\n\n%s", lockHeld, lockHeldHint), lockHeld.ExitCode()
+	}
+
+	code = ExitFatal
+	var coder ExitCoder
+	if errors.As(err, &coder) {
+		code = coder.ExitCode()
+	}
+
+	if code == ExitError || code == ExitUsage {
+		return "error: " + err.Error(), code
+	}
+
+	return "fatal: " + err.Error(), code
+}
@@ -0,0 +1,62 @@
+package usecase_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestReport(t *testing.T) {
+
+	testt := []struct {
+		err     error
+		message string
+		code    int
+	}{
+		{err: nil, message: "", code: 0},
+		{err: errors.New("something wrong"), message: "fatal: something wrong", code: 128},
+		{err: usecase.ErrNoPathIgnored, message: "", code: 1},
+		{err: &usecase.NothingToCommitError{Reason: "nothing to commit"}, message: "", code: 1},
+		{err: &usecase.NotRepositoryError{Err: errors.New("not a git repository")}, message: "fatal: not a git repository", code: 128},
+		{err: &usecase.InvalidRevisionError{Revision: "x", Reason: "not a valid object name: x"}, message: "fatal: not a valid object name: x", code: 128},
+		{
+			err:     &usecase.AmbiguousObjectError{Prefix: "dd", Hints: []string{"dd91746 commit"}},
+			message: "error: short SHA1 dd is ambiguous\nhint: dd91746 commit\nfatal: ambiguous argument 'dd': unknown revision or path not in the working tree.",
+			code:    128,
+		},
+		{err: &usecase.ConflictError{Conflicts: []error{errors.New("a"), errors.New("b")}}, message: "error: a\nerror: b\nAborting", code: 1},
+	}
+
+	for _, tc := range testt {
+
+		message, code := usecase.Report(tc.err)
+
+		if message != tc.message || code != tc.code {
+			t.Errorf("expect (%q, %d), got (%q, %d)", tc.message, tc.code, message, code)
+		}
+
+	}
+
+}
+
+func TestLockHeldError(t *testing.T) {
+
+	dir := initDir(t)
+	f := createFile(t, dir, "hello.txt", []byte("hello.\n"))
+	createFile(t, dir, ".git/index.lock", []byte(""))
+
+	out := &bytes.Buffer{}
+	err := usecase.Add(newContext(dir, "", "", out, out), f)
+
+	var lockHeld *usecase.LockHeldError
+	if !errors.As(err, &lockHeld) {
+		t.Fatalf("expect LockHeldError, got %v", err)
+	}
+
+	if _, code := usecase.Report(err); code != 128 {
+		t.Errorf("expect exit code 128, got %d", code)
+	}
+
+}
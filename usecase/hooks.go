@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/repository"
+)
+
+// hooks core.hooksPath、なければ.git/hooksにあるフックを実行する
+type hooks struct {
+	ctx GotContextReaderWriter
+	dir string
+	// env フックに渡す環境変数
+	env []string
+}
+
+func newHooks(ctx GotContextReaderWriter, config database.Config) *hooks {
+
+	dir := filepath.Join(ctx.GotRoot(), "hooks")
+
+	if path, ok := config.Get("core.hooksPath"); ok && path != "" {
+
+		dir = path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(hookWorkingDirectory(ctx), dir)
+		}
+	}
+
+	return &hooks{ctx: ctx, dir: dir}
+}
+
+// hookWorkingDirectory gitと同じくワークスペースのルート、ベアリポジトリならリポジトリで実行する
+func hookWorkingDirectory(ctx GotContextReader) string {
+
+	if ctx.WorkspaceRoot() == "" {
+		return ctx.GotRoot()
+	}
+
+	return ctx.WorkspaceRoot()
+}
+
+// find 実行できるフックのパス
+func (h *hooks) find(name string) (string, bool) {
+
+	path := filepath.Join(h.dir, name)
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+
+	if info.Mode()&0111 == 0 {
+		h.ctx.OutError(fmt.Errorf("hint: The '%s' hook was ignored because it's not set as executable.", path))
+		return "", false
+	}
+
+	return path, true
+}
+
+// run フックがなければ何もしない。フックの標準出力は標準エラーに出す。
+// pre-pushのように標準入力を読むフックにはstdinを渡す
+func (h *hooks) run(name string, stdin io.Reader, args ...string) error {
+
+	path, ok := h.find(name)
+	if !ok {
+		return nil
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = hookWorkingDirectory(h.ctx)
+	cmd.Env = append(os.Environ(), h.env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, os.Stderr, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return &HookError{Name: name, Err: err}
+	}
+
+	return nil
+}
+
+// withIndex フックからステージした内容が見えるように、コミットするインデックスを書き出してGIT_INDEX_FILEで渡す。
+// フックがステージし直すこともあるので、書き出したパスを返す
+func (h *hooks) withIndex(index repository.Index) (path string, cleanup func(), err error) {
+
+	data, err := index.Serialize()
+	if err != nil {
+		return "", nil, err
+	}
+
+	path = filepath.Join(h.ctx.GotRoot(), fmt.Sprintf("next-index-%d.lock", os.Getpid()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", nil, err
+	}
+
+	h.env = append(h.env, "GIT_INDEX_FILE="+path)
+
+	return path, func() {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			h.ctx.OutError(err)
+		}
+	}, nil
+}
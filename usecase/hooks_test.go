@@ -0,0 +1,204 @@
+package usecase_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestPreCommitHook(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	// フックからはステージした内容が見える
+	createHook(t, dir, ".git/hooks", "pre-commit", `git ls-files > "$(dirname "$GIT_INDEX_FILE")/staged"; exit 1`)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"})
+
+	var hook *usecase.HookError
+	if !errors.As(err, &hook) || hook.Name != "pre-commit" {
+		t.Fatalf("expect pre-commit hook error, got %v", err)
+	}
+
+	if msg, code := usecase.Report(err); msg != "" || code != usecase.ExitError {
+		t.Fatalf("unexpected report %q %d", msg, code)
+	}
+
+	testFileContent(t, dir, ".git/staged", "a.txt\n")
+
+	if exists(dir, ".git/refs/heads/master") {
+		t.Fatal("commit should be aborted")
+	}
+
+	// --no-verifyならフックを実行しない
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first", NoVerify: true}); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func TestPreCommitHookStagesFile(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	// フックがステージしたファイルもコミットに入る
+	createHook(t, dir, ".git/hooks", "pre-commit", `echo generated > b.txt && git add b.txt`)
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testlstree(t, dir, "a.txt\nb.txt\n")
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status) != 0 {
+		t.Fatalf("index should match the commit. %s", status)
+	}
+
+}
+
+func TestCommitMsgHook(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	createHook(t, dir, ".git/hooks", "commit-msg", `sed -i -e '1s/^/[TICKET-1] /' "$1"`)
+	createHook(t, dir, ".git/hooks", "post-commit", `git log -1 --format=%s > .git/post-commit; exit 1`)
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%s", "[TICKET-1] first\n")
+	testFileContent(t, dir, ".git/post-commit", "[TICKET-1] first\n")
+
+}
+
+func TestCommitMsgHookRejects(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	createHook(t, dir, ".git/hooks", "commit-msg", `grep -q '^TICKET-[0-9]' "$1"`)
+
+	out := &bytes.Buffer{}
+	err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"})
+
+	var hook *usecase.HookError
+	if !errors.As(err, &hook) || hook.Name != "commit-msg" {
+		t.Fatalf("expect commit-msg hook error, got %v", err)
+	}
+
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "TICKET-1 first"}); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func TestHooksPath(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	add(t, dir, dir)
+
+	gitConfig(t, dir, "core.hooksPath", "githooks")
+
+	createHook(t, dir, ".git/hooks", "pre-commit", "exit 1")
+	createHook(t, dir, "githooks", "pre-commit", "exit 0")
+
+	// 実行権限のないフックは無視する
+	createHook(t, dir, "githooks", "commit-msg", "exit 1")
+	modifyFileMode(t, dir, "githooks/commit-msg", 0644)
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, errOut), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "hint: The '" + filepath.Join(dir, "githooks", "commit-msg") + "' hook was ignored because it's not set as executable.\n"
+	if errOut.String() != expect {
+		t.Fatalf("expect %q, got %q", expect, errOut)
+	}
+
+}
+
+func TestPostCheckoutHook(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+	commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+	add(t, dir, createFile(t, dir, "a.txt", []byte("b\n")))
+	commit(t, dir, "", "", "second", time.Unix(1694356072, 0))
+
+	createHook(t, dir, ".git/hooks", "post-checkout", `echo "$@" >> .git/post-checkout; exit 1`)
+
+	revs, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD", "HEAD^").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, first := string(bytes.Fields(revs)[0]), string(bytes.Fields(revs)[1])
+
+	// フックが失敗してもチェックアウトはされる
+	out := &bytes.Buffer{}
+	err = usecase.Checkout(newContext(dir, "", "", out, out), mustRevision(t, "HEAD^"))
+
+	var hook *usecase.HookError
+	if !errors.As(err, &hook) || hook.Name != "post-checkout" {
+		t.Fatalf("expect post-checkout hook error, got %v", err)
+	}
+	testFileContent(t, dir, "a.txt", "a\n")
+
+	createFile(t, dir, "a.txt", []byte("c\n"))
+	usecase.CheckoutPaths(newContext(dir, "", "", out, out), mustRevision(t, ""), "a.txt")
+
+	expect := second + " " + first + " 1\n" + first + " " + first + " 0\n"
+	testFileContent(t, dir, ".git/post-checkout", expect)
+
+}
+
+func createHook(t *testing.T, dir, hooksdir, name, script string) {
+
+	t.Helper()
+
+	// createFileは閉じずに残すので、実行できるように書き込んだらすぐ閉じる
+	if err := os.MkdirAll(filepath.Join(dir, hooksdir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, hooksdir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func mustRevision(t *testing.T, s string) types.Revision {
+
+	t.Helper()
+
+	rev, err := types.NewRevision(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rev
+}
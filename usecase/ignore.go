@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
+	"github.com/mizuho-u/got/repository"
+)
+
+func loadIgnore(ctx GotContextReader, db database.Database) (repository.Ignore, error) {
+
+	return workspace.LoadIgnore(ctx.WorkspaceRoot(), excludesFile(db.Config()), filepath.Join(ctx.GotRoot(), "info", "exclude"))
+}
+
+func excludesFile(config database.Config) string {
+
+	if path, ok := config.Get("core.excludesFile"); ok {
+		return expandHome(path)
+	}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+
+	return expandHome("~/.config/git/ignore")
+}
+
+func expandHome(path string) string {
+
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(home, path[2:])
+}
+
+func scanOptions(ctx GotContextReader, db database.Database, tracked func(path string) bool) ([]workspace.ScanOption, error) {
+
+	ignore, err := loadIgnore(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return []workspace.ScanOption{workspace.WithIgnore(ignore), workspace.WithTracked(tracked)}, nil
+}
@@ -1,20 +1,66 @@
 package usecase
 
 import (
+	"os"
+
 	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/types"
 )
 
 type ExitCode int
 
-func InitDir(ctx GotContextReaderWriter) error {
+const defaultBranch = "main"
+
+type InitOptions struct {
+	InitialBranch string
+	Bare          bool
+	Template      string
+}
+
+func InitDir(ctx GotContextReaderWriter, opts InitOptions) error {
 
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 
-	if err := db.Init(); err != nil {
+	name := opts.InitialBranch
+	if name == "" {
+		if n, ok := db.Config().Get("init.defaultBranch"); ok {
+			name = n
+		} else {
+			name = defaultBranch
+		}
+	}
+
+	branch, err := types.NewBranchName(name)
+	if err != nil {
+		return err
+	}
+
+	template := opts.Template
+	if template == "" {
+		template = os.Getenv("GIT_TEMPLATE_DIR")
+	}
+	if template == "" {
+		if dir, ok := db.Config().Get("init.templateDir"); ok {
+			template = expandHome(dir)
+		}
+	}
+
+	reinitialized, err := db.Init(database.InitOptions{InitialBranch: branch, Bare: opts.Bare, TemplateDir: template})
+	if err != nil {
 		return err
 	}
 
-	ctx.Out("Initialized empty Got repository in "+ctx.WorkspaceRoot(), none)
+	root := ctx.WorkspaceRoot()
+	if opts.Bare {
+		root = ctx.GotRoot()
+	}
+
+	if reinitialized {
+		ctx.Out("Reinitialized existing Got repository in "+root, none)
+		return nil
+	}
+
+	ctx.Out("Initialized empty Got repository in "+root, none)
 
 	return nil
 }
@@ -2,8 +2,12 @@ package usecase_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/mizuho-u/got/usecase"
@@ -17,7 +21,7 @@ func TestInitDir(t *testing.T) {
 	out := &bytes.Buffer{}
 
 	// act
-	err := usecase.InitDir(newContext(dir, "", "", out, out))
+	err := usecase.InitDir(newContext(dir, "", "", out, out), usecase.InitOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -40,3 +44,154 @@ func TestInitDir(t *testing.T) {
 	}
 
 }
+
+func TestInitDirOptions(t *testing.T) {
+
+	testt := []struct {
+		description string
+		opts        usecase.InitOptions
+		global      string
+		bare        bool
+		head        string
+	}{
+		{
+			description: "default branch",
+			head:        "ref: refs/heads/main",
+		},
+		{
+			description: "initial branch",
+			opts:        usecase.InitOptions{InitialBranch: "trunk"},
+			head:        "ref: refs/heads/trunk",
+		},
+		{
+			description: "init.defaultBranch config",
+			global:      "[init]\n\tdefaultBranch = develop\n",
+			head:        "ref: refs/heads/develop",
+		},
+		{
+			description: "initial branch overrides config",
+			opts:        usecase.InitOptions{InitialBranch: "trunk"},
+			global:      "[init]\n\tdefaultBranch = develop\n",
+			head:        "ref: refs/heads/trunk",
+		},
+		{
+			description: "bare repository",
+			opts:        usecase.InitOptions{Bare: true},
+			bare:        true,
+			head:        "ref: refs/heads/main",
+		},
+	}
+
+	for _, tc := range testt {
+
+		t.Run(tc.description, func(t *testing.T) {
+
+			global := filepath.Join(t.TempDir(), "gitconfig")
+			createFile(t, filepath.Dir(global), "gitconfig", []byte(tc.global))
+			t.Setenv("GIT_CONFIG_GLOBAL", global)
+
+			dir := t.TempDir()
+
+			ctx := newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{})
+			gotroot := filepath.Join(dir, ".git")
+			if tc.bare {
+				ctx = usecase.NewContext(context.Background(), "", dir, "", "", &bytes.Buffer{}, &bytes.Buffer{})
+				gotroot = dir
+			}
+
+			if err := usecase.InitDir(ctx, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			head, err := os.ReadFile(filepath.Join(gotroot, "HEAD"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(head) != tc.head {
+				t.Errorf("expect HEAD %s, got %s", tc.head, head)
+			}
+
+			config, err := os.ReadFile(filepath.Join(gotroot, "config"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expect := fmt.Sprintf("bare = %t", tc.bare); !strings.Contains(string(config), expect) {
+				t.Errorf("expect config contains %s, got %s", expect, config)
+			}
+
+		})
+
+	}
+
+}
+
+func TestInitDirReinitialize(t *testing.T) {
+
+	dir := initDir(t)
+
+	createFile(t, dir, ".git/HEAD", []byte("ref: refs/heads/topic"))
+
+	template := t.TempDir()
+	createFile(t, template, "hooks/pre-commit", []byte("#!/bin/sh\nexit 0\n"))
+	modifyFileMode(t, template, "hooks/pre-commit", 0755)
+	createFile(t, template, "info/exclude", []byte("*.swp\n"))
+	createFile(t, dir, ".git/info/exclude", []byte("*.local\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.InitDir(newContext(dir, "", "", out, out), usecase.InitOptions{InitialBranch: "other", Template: template}); err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := "Reinitialized existing Got repository in " + dir; out.String() != expect {
+		t.Errorf("expect %s, got %s", expect, out)
+	}
+
+	head, err := os.ReadFile(filepath.Join(dir, ".git", "HEAD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(head) != "ref: refs/heads/topic" {
+		t.Errorf("HEAD was clobbered: %s", head)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, ".git", "hooks", "pre-commit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm()&0111 == 0 {
+		t.Errorf("hook is not executable: %s", info.Mode())
+	}
+
+	exclude, err := os.ReadFile(filepath.Join(dir, ".git", "info", "exclude"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(exclude) != "*.local\n" {
+		t.Errorf("existing file was overwritten: %s", exclude)
+	}
+
+}
+
+func TestBareRepositoryHasNoWorkTree(t *testing.T) {
+
+	dir := t.TempDir()
+
+	ctx := usecase.NewContext(context.Background(), "", dir, "", "", &bytes.Buffer{}, &bytes.Buffer{})
+	if err := usecase.InitDir(ctx, usecase.InitOptions{Bare: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		t.Error("bare repository has .git directory")
+	}
+
+	if err := usecase.Status(ctx, true); !errors.Is(err, usecase.ErrNoWorkTree) {
+		t.Errorf("expect %v, got %v", usecase.ErrNoWorkTree, err)
+	}
+
+}
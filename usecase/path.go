@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mizuho-u/got/types"
+)
+
+// displayPath ワークスペースルートからの相対パスを作業ディレクトリからの相対パスにする
+func displayPath(ctx GotContextReader, path string) string {
+
+	if ctx.WorkingDirectory() == ctx.WorkspaceRoot() {
+		return path
+	}
+
+	rel, err := filepath.Rel(ctx.WorkingDirectory(), filepath.Join(ctx.WorkspaceRoot(), path))
+	if err != nil {
+		return path
+	}
+
+	if strings.HasSuffix(path, "/") {
+		rel += "/"
+	}
+
+	return rel
+}
+
+// workingPrefix ワークスペースルートから作業ディレクトリへの相対パス
+func workingPrefix(ctx GotContextReader) string {
+
+	rel, err := filepath.Rel(ctx.WorkspaceRoot(), ctx.WorkingDirectory())
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+
+	return rel
+}
+
+// newPathspec 引数のpathspecを作業ディレクトリからのパスとして解釈する
+func newPathspec(ctx GotContextReader, specs ...string) (types.Pathspec, error) {
+	return types.NewPathspec(ctx.WorkspaceRoot(), ctx.WorkingDirectory(), specs...)
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/types"
+)
+
+type RevListOptions struct {
+	TopoOrder    bool
+	DateOrder    bool
+	Reverse      bool
+	FirstParent  bool
+	AncestryPath bool
+	// MaxCount 負なら制限しない
+	MaxCount int
+	Since    string
+	Until    string
+	Parents  bool
+	Count    bool
+	Objects  bool
+}
+
+// RevList 範囲のコミットを1行ずつ出力する
+func RevList(ctx GotContextReaderWriter, r *types.RevisionRange, opts RevListOptions) error {
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	walkOpts, err := opts.walkOptions()
+	if err != nil {
+		return err
+	}
+
+	include, exclude, err := rangeCommits(ctx, db, r)
+	if err != nil {
+		return err
+	}
+
+	rl := repository.NewRevList(db.Objects(), walkOpts...)
+	commits, err := rl.Walk(include, exclude)
+	if err != nil {
+		return err
+	}
+
+	if opts.Count {
+		return ctx.Out(fmt.Sprintf("%d\n", len(commits)), none)
+	}
+
+	for _, c := range commits {
+
+		line := []string{c.OID()}
+		if opts.Parents {
+			line = append(line, c.Parents()...)
+		}
+
+		if err := ctx.Out(strings.Join(line, " ")+"\n", none); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Objects {
+		return nil
+	}
+
+	objects, err := rl.Objects(commits, exclude)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		if err := ctx.Out(o.OID+" "+o.Path+"\n", none); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (opts RevListOptions) walkOptions() ([]repository.RevListOption, error) {
+
+	walkOpts := []repository.RevListOption{repository.RevListMaxCount(opts.MaxCount)}
+
+	switch {
+	case opts.TopoOrder:
+		walkOpts = append(walkOpts, repository.RevListTopoOrder())
+	case opts.DateOrder:
+		walkOpts = append(walkOpts, repository.RevListDateOrder())
+	}
+
+	if opts.Reverse {
+		walkOpts = append(walkOpts, repository.RevListReverse())
+	}
+	if opts.FirstParent {
+		walkOpts = append(walkOpts, repository.RevListFirstParent())
+	}
+	if opts.AncestryPath {
+		walkOpts = append(walkOpts, repository.RevListAncestryPath())
+	}
+
+	if opts.Since != "" {
+
+		since, err := types.ParseDate(opts.Since, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		walkOpts = append(walkOpts, repository.RevListSince(since))
+	}
+
+	if opts.Until != "" {
+
+		until, err := types.ParseDate(opts.Until, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		walkOpts = append(walkOpts, repository.RevListUntil(until))
+	}
+
+	return walkOpts, nil
+}
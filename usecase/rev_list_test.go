@@ -0,0 +1,111 @@
+package usecase_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestRevListLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	// mainとsideを交互にコミットして、sideをマージする
+	//
+	//	A - B - C ----- M - D
+	//	     \         /
+	//	      S1 --- S2
+	// 最初のコミットはgotで作る
+	add(t, dir, createFile(t, dir, "a.txt", []byte("A\n")))
+	commit(t, dir, "", "", "A", time.Unix(1697245201, 0))
+	commitAt(t, dir, "a.txt", "B", 2)
+	runGit(t, dir, "checkout", "-q", "-b", "side")
+	commitAt(t, dir, "dir/side.txt", "S1", 3)
+	runGit(t, dir, "checkout", "-q", "main")
+	commitAt(t, dir, "a.txt", "C", 4)
+	runGit(t, dir, "checkout", "-q", "side")
+	commitAt(t, dir, "dir/sub/side.txt", "S2", 5)
+	runGit(t, dir, "checkout", "-q", "main")
+	runGitAt(t, dir, 6, "merge", "-q", "--no-ff", "side", "-m", "M")
+	commitAt(t, dir, "a.txt", "D", 7)
+
+	testt := []struct {
+		args []string
+		opts usecase.RevListOptions
+	}{
+		{args: []string{"HEAD"}},
+		{args: []string{"--topo-order", "HEAD"}, opts: usecase.RevListOptions{TopoOrder: true}},
+		{args: []string{"--date-order", "HEAD"}, opts: usecase.RevListOptions{DateOrder: true}},
+		{args: []string{"--reverse", "HEAD"}, opts: usecase.RevListOptions{Reverse: true}},
+		{args: []string{"--reverse", "--max-count=2", "HEAD"}, opts: usecase.RevListOptions{Reverse: true, MaxCount: 2}},
+		{args: []string{"--parents", "HEAD"}, opts: usecase.RevListOptions{Parents: true}},
+		{args: []string{"--parents", "--first-parent", "HEAD"}, opts: usecase.RevListOptions{Parents: true, FirstParent: true}},
+		{args: []string{"--count", "side..main"}, opts: usecase.RevListOptions{Count: true}},
+		{args: []string{"main...side"}},
+		{args: []string{"main", "--not", "side"}},
+		{args: []string{"--ancestry-path", "HEAD~3..HEAD"}, opts: usecase.RevListOptions{AncestryPath: true}},
+		{args: []string{"--since=@1697245204", "--until=@1697245206", "HEAD"}, opts: usecase.RevListOptions{Since: "@1697245204", Until: "@1697245206"}},
+		{args: []string{"--objects", "HEAD"}, opts: usecase.RevListOptions{Objects: true}},
+		{args: []string{"--objects", "main..side"}, opts: usecase.RevListOptions{Objects: true}},
+		{args: []string{"--objects", "HEAD~1..HEAD"}, opts: usecase.RevListOptions{Objects: true}},
+	}
+
+	for _, tc := range testt {
+
+		expect, err := exec.Command("git", append([]string{"-C", dir, "rev-list"}, tc.args...)...).Output()
+		if err != nil {
+			t.Fatalf("git rev-list %v: %s", tc.args, err)
+		}
+
+		if tc.opts.MaxCount == 0 {
+			tc.opts.MaxCount = -1
+		}
+
+		revisions := []string{}
+		for _, arg := range tc.args {
+			if arg == "--not" || arg[0] != '-' {
+				revisions = append(revisions, arg)
+			}
+		}
+
+		out := &bytes.Buffer{}
+		if err := usecase.RevList(newContext(dir, "", "", out, out), mustRange(t, revisions...), tc.opts); err != nil {
+			t.Fatalf("%v: %s", tc.args, err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%v: expect\n%s\ngot\n%s", tc.args, expect, out)
+		}
+	}
+
+}
+
+// commitAt nameにmessageを書いてコミットする。日時は1697245200からsec秒後
+func commitAt(t *testing.T, dir, name, message string, sec int) {
+
+	t.Helper()
+
+	createFile(t, dir, name, []byte(message+"\n"))
+	runGit(t, dir, "add", name)
+	runGitAt(t, dir, sec, "commit", "-q", "-m", message)
+}
+
+func runGitAt(t *testing.T, dir string, sec int, args ...string) {
+
+	t.Helper()
+
+	date := fmt.Sprintf("@%d +0900", 1697245200+sec)
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=git", "GIT_AUTHOR_EMAIL=git@example.com", "GIT_COMMITTER_NAME=git", "GIT_COMMITTER_EMAIL=git@example.com", "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+
+}
@@ -0,0 +1,35 @@
+package usecase
+
+import (
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/types"
+)
+
+// RevParse revisionが指すオブジェクトのIDを1行ずつ出力する。範囲なら除くものに^をつける
+func RevParse(ctx GotContextReaderWriter, ranges ...*types.RevisionRange) error {
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	for _, r := range ranges {
+
+		include, exclude, err := resolveRange(ctx, db, r)
+		if err != nil {
+			return err
+		}
+
+		for _, oid := range include {
+			if err := ctx.Out(oid.String()+"\n", none); err != nil {
+				return err
+			}
+		}
+
+		for _, oid := range exclude {
+			if err := ctx.Out("^"+oid.String()+"\n", none); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
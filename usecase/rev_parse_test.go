@@ -0,0 +1,169 @@
+package usecase_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/types"
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestRevParseLikeGit(t *testing.T) {
+
+	dir := initDir(t)
+
+	createDir(t, dir, "cmd")
+	add(t, dir, createFile(t, dir, "cmd/root.go", []byte("1\n")))
+	commit(t, dir, "", "", "first commit", time.Unix(1697245200, 0))
+
+	// マージ、タグ、reflog、upstreamはgitで作る
+	runGit(t, dir, "checkout", "-q", "-b", "side")
+	runGit(t, dir, "add", createFile(t, dir, "side.txt", []byte("s\n")))
+	runGit(t, dir, "commit", "-q", "-m", "fix bug on side")
+	runGit(t, dir, "checkout", "-q", "main")
+	runGit(t, dir, "add", createFile(t, dir, "cmd/root.go", []byte("2\n")))
+	runGit(t, dir, "commit", "-q", "-m", "second")
+	runGit(t, dir, "merge", "-q", "--no-ff", "side", "-m", "merge side")
+	runGit(t, dir, "tag", "-a", "v1.0", "-m", "annotated", "HEAD~1")
+	gitConfig(t, dir, "branch.main.remote", ".")
+	gitConfig(t, dir, "branch.main.merge", "refs/heads/side")
+
+	for _, rev := range []string{
+		"HEAD^2", "HEAD^0", "HEAD~", "HEAD^2~1",
+		"v1.0", "v1.0^{}", "v1.0^{tree}", "HEAD^{tree}",
+		"HEAD:cmd/root.go", "HEAD~1:cmd", "HEAD:",
+		":/fix", "HEAD^{/second}",
+		"@{1}", "main@{2}", "HEAD@{1}", "@{u}", "main@{upstream}^",
+		"HEAD~1..side", "side..", "^v1.0", "side...HEAD~1", "v1.0...side",
+	} {
+
+		expect, err := exec.Command("git", "-C", dir, "rev-parse", rev).Output()
+		if err != nil {
+			t.Fatalf("git rev-parse %s: %s", rev, err)
+		}
+
+		out := &bytes.Buffer{}
+		if err := usecase.RevParse(newContext(dir, "", "", out, out), mustRange(t, rev)); err != nil {
+			t.Fatalf("%s: %s", rev, err)
+		}
+
+		if out.String() != string(expect) {
+			t.Errorf("%s: expect %s, got %s", rev, expect, out)
+		}
+	}
+
+}
+
+func TestRevParseErrors(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+	commit(t, dir, "", "", "first", time.Unix(1697245200, 0))
+
+	testt := []struct {
+		rev, expect string
+	}{
+		{"HEAD^2", "no parents found"},
+		{"HEAD^{blob}", "expected blob type, but the object dereferences to commit type"},
+		{"HEAD:nope", "path 'nope' does not exist in"},
+		{":1:a.txt", "path 'a.txt' is in the index, but not at stage 1"},
+		{":nope", "path 'nope' does not exist in the index"},
+		{"@{1}", "log for 'main' only has 1 entries"},
+		{"@{u}", "no upstream configured for branch 'main'"},
+		{":/nothing", "no commit message matches 'nothing'"},
+	}
+
+	for _, tc := range testt {
+
+		out := &bytes.Buffer{}
+		err := usecase.RevParse(newContext(dir, "", "", out, out), mustRange(t, tc.rev))
+
+		var invalid *usecase.InvalidRevisionError
+		if !errors.As(err, &invalid) || !strings.Contains(err.Error(), tc.expect) {
+			t.Errorf("%s: expect %q, got %v", tc.rev, tc.expect, err)
+		}
+	}
+
+}
+
+func TestRevParseReflogAfterCommits(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+	commit(t, dir, "", "", "first", time.Unix(1697245200, 0))
+	add(t, dir, createFile(t, dir, "a.txt", []byte("b\n")))
+	commit(t, dir, "", "", "second\n\nbody", time.Unix(1697245260, 0))
+
+	first, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD~1").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rev := range []string{"HEAD@{1}", "@{1}", "main@{1}"} {
+
+		out := &bytes.Buffer{}
+		if err := usecase.RevParse(newContext(dir, "", "", out, out), mustRange(t, rev)); err != nil {
+			t.Fatalf("%s: %s", rev, err)
+		}
+
+		if out.String() != string(first) {
+			t.Errorf("%s: expect %s, got %s", rev, first, out)
+		}
+	}
+
+	// gitからも同じreflogが読める
+	subjects, err := exec.Command("git", "-C", dir, "log", "-g", "--format=%gs", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := "commit: second\ncommit (initial): first\n"; string(subjects) != expect {
+		t.Errorf("expect %q, got %q", expect, subjects)
+	}
+
+	branch, _ := types.NewBranchName("topic")
+	startPoint, _ := types.NewRevision("HEAD~1")
+	if err := usecase.Branch(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), branch, startPoint); err != nil {
+		t.Fatal(err)
+	}
+
+	subjects, err = exec.Command("git", "-C", dir, "log", "-g", "--format=%gs", "topic").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := "branch: Created from HEAD~1\n"; string(subjects) != expect {
+		t.Errorf("expect %q, got %q", expect, subjects)
+	}
+
+}
+
+func mustRange(t *testing.T, args ...string) *types.RevisionRange {
+
+	t.Helper()
+
+	r, err := types.NewRevisionRange(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=git", "GIT_AUTHOR_EMAIL=git@example.com", "GIT_COMMITTER_NAME=git", "GIT_COMMITTER_EMAIL=git@example.com")
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+
+}
@@ -0,0 +1,621 @@
+package usecase
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/repository"
+	"github.com/mizuho-u/got/repository/object"
+	"github.com/mizuho-u/got/types"
+)
+
+// resolver types.Revisionをリポジトリのオブジェクトにする
+type resolver struct {
+	ctx     GotContextReaderWriter
+	refs    database.Refs
+	objects database.Objects
+	config  database.Config
+}
+
+func newResolver(ctx GotContextReaderWriter, db database.Database) *resolver {
+	return &resolver{ctx: ctx, refs: db.Refs(), objects: db.Objects(), config: db.Config()}
+}
+
+// resolveCommit コミットを指すrevision。タグはたどる
+func resolveCommit(ctx GotContextReaderWriter, db database.Database, revision types.Revision) (types.ObjectID, error) {
+
+	r := newResolver(ctx, db)
+
+	oid, err := revision.Resolve(r)
+	if err != nil || oid == "" {
+		return oid, err
+	}
+
+	return r.peelTo(oid, "commit")
+}
+
+// resolveTree ツリーを指すrevision。コミットならそのツリー
+func resolveTree(ctx GotContextReaderWriter, db database.Database, revision types.Revision) (types.ObjectID, error) {
+
+	r := newResolver(ctx, db)
+
+	oid, err := revision.Resolve(r)
+	if err != nil || oid == "" {
+		return oid, err
+	}
+
+	return r.peelTo(oid, "tree")
+}
+
+// peelTo classでなければ "object ... is a blob, not a commit" のエラーにする
+func (r *resolver) peelTo(oid types.ObjectID, class string) (types.ObjectID, error) {
+
+	peeled, err := r.Peel(oid, class)
+
+	var invalid *InvalidRevisionError
+	if errors.As(err, &invalid) {
+
+		if obj, loadErr := r.objects.Load(oid.String()); loadErr == nil {
+			return "", &InvalidRevisionError{Revision: oid.String(), Reason: fmt.Sprintf("object %s is a %s, not a %s", oid, obj.Class(), class)}
+		}
+	}
+
+	return peeled, err
+}
+
+func (r *resolver) Ref(name string) (types.ObjectID, error) {
+
+	oid, err := r.refs.ReadRef(name)
+	switch {
+	case err == nil && oid != "":
+		return types.NewObjectID(oid)
+	case err == nil:
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("not a valid object name: %s", name)}
+	case !errors.Is(err, database.ErrRefNotFound):
+		return "", err
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{2,40}$`).MatchString(name) {
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("not a valid object name: %s", name)}
+	}
+
+	objects, err := r.objects.LoadPrefix(name)
+
+	if err != nil || len(objects) == 0 {
+
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("not a valid object name: %s", name)}
+
+	} else if len(objects) == 1 {
+
+		return types.NewObjectID(objects[0].OID())
+
+	} else {
+		hints := []string{}
+
+		for _, o := range objects {
+
+			if c, err := object.ParseCommit(o); err == nil {
+				hints = append(hints, fmt.Sprintf("%s %s %s", object.ShortOID(o.OID()), o.Class(), c.TitleLine()))
+			} else {
+				hints = append(hints, fmt.Sprintf("%s %s", object.ShortOID(o.OID()), o.Class()))
+			}
+		}
+
+		return "", &AmbiguousObjectError{Prefix: name, Hints: hints}
+
+	}
+
+}
+
+func (r *resolver) Parent(oid types.ObjectID, n int) (types.ObjectID, error) {
+
+	oid, err := r.peelTo(oid, "commit")
+	if err != nil || n == 0 {
+		return oid, err
+	}
+
+	commit, err := r.objects.LoadCommit(oid.String())
+	if err != nil {
+		return "", err
+	}
+
+	parents := commit.Parents()
+	if len(parents) == 0 {
+		return "", &InvalidRevisionError{Revision: oid.String(), Reason: fmt.Sprintf("no parents found: %s", oid)}
+	}
+
+	if n > len(parents) {
+		return "", &InvalidRevisionError{Revision: oid.String(), Reason: fmt.Sprintf("commit %s has only %d parent(s), no parent %d", oid, len(parents), n)}
+	}
+
+	return types.NewObjectID(parents[n-1])
+}
+
+func (r *resolver) Peel(oid types.ObjectID, class string) (types.ObjectID, error) {
+
+	for {
+
+		obj, err := r.objects.Load(oid.String())
+		if err != nil {
+			return "", err
+		}
+
+		if class == "object" || string(obj.Class()) == class || (class == "" && obj.Class() != object.ClassTag) {
+			return oid, nil
+		}
+
+		switch obj.Class() {
+		case object.ClassTag:
+
+			tag, err := object.ParseTag(obj)
+			if err != nil {
+				return "", err
+			}
+
+			if oid, err = types.NewObjectID(tag.Target()); err != nil {
+				return "", err
+			}
+			continue
+
+		case object.ClassCommit:
+
+			if class == "tree" {
+
+				commit, err := object.ParseCommit(obj)
+				if err != nil {
+					return "", err
+				}
+
+				return types.NewObjectID(commit.Tree())
+			}
+		}
+
+		return "", &InvalidRevisionError{Revision: oid.String(), Reason: fmt.Sprintf("%s: expected %s type, but the object dereferences to %s type", oid, class, obj.Class())}
+	}
+}
+
+// Search コミット日時の新しい順にたどって、メッセージがpatternにマッチする最初のコミット
+func (r *resolver) Search(from types.ObjectID, pattern *regexp.Regexp) (types.ObjectID, error) {
+
+	starts := []types.ObjectID{from}
+	if from == "" {
+
+		names, err := r.refs.List()
+		if err != nil {
+			return "", err
+		}
+
+		starts = nil
+		for _, name := range append([]string{"HEAD"}, names...) {
+
+			oid, err := r.refs.ReadRef(name)
+			if err != nil || oid == "" {
+				continue
+			}
+			starts = append(starts, types.ObjectID(oid))
+		}
+	}
+
+	queue := &repository.CommitQueue{}
+	seen := map[string]bool{}
+
+	push := func(oid types.ObjectID) error {
+
+		oid, err := r.Peel(oid, "")
+		if err != nil || seen[oid.String()] {
+			return err
+		}
+		seen[oid.String()] = true
+
+		commit, err := r.objects.LoadCommit(oid.String())
+		if err != nil {
+			// タグがコミット以外を指していればたどらない
+			if from == "" {
+				return nil
+			}
+			return err
+		}
+
+		heap.Push(queue, commit)
+		return nil
+	}
+
+	for _, oid := range starts {
+		if err := push(oid); err != nil {
+			return "", err
+		}
+	}
+
+	for queue.Len() != 0 {
+
+		commit := heap.Pop(queue).(object.Commit)
+		if pattern.MatchString(commit.Message()) {
+			return types.NewObjectID(commit.OID())
+		}
+
+		for _, p := range commit.Parents() {
+			if err := push(types.ObjectID(p)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return "", &InvalidRevisionError{Revision: pattern.String(), Reason: fmt.Sprintf("no commit message matches '%s'", pattern)}
+}
+
+func (r *resolver) TreeEntry(oid types.ObjectID, name string) (types.ObjectID, error) {
+
+	tree, err := r.peelTo(oid, "tree")
+	if err != nil {
+		return "", err
+	}
+
+	name, err = r.treePath(name)
+	if err != nil || name == "" {
+		return tree, err
+	}
+
+	for _, base := range strings.Split(name, "/") {
+
+		obj, err := r.objects.Load(tree.String())
+		if err != nil {
+			return "", err
+		}
+
+		entry, ok := parseTreeChildren(obj)[base]
+		if obj.Class() != object.ClassTree || !ok {
+			return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("path '%s' does not exist in '%s'", name, oid)}
+		}
+
+		if tree, err = types.NewObjectID(entry.OID()); err != nil {
+			return "", err
+		}
+	}
+
+	return tree, nil
+}
+
+func parseTreeChildren(obj object.Object) map[string]object.TreeEntry {
+
+	if obj.Class() != object.ClassTree {
+		return nil
+	}
+
+	tree, err := object.ParseTree(obj)
+	if err != nil {
+		return nil
+	}
+
+	return tree.ChildrenMap()
+}
+
+// treePath ./と../で始まるパスは作業ディレクトリから、それ以外はワークスペースルートから
+func (r *resolver) treePath(name string) (string, error) {
+
+	if name == "." || strings.HasPrefix(name, "./") || name == ".." || strings.HasPrefix(name, "../") {
+		name = path.Join(workingPrefix(r.ctx), name)
+	}
+
+	clean := path.Clean(strings.TrimPrefix(name, "/"))
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("'%s' is outside repository", name)
+	}
+
+	if clean == "." {
+		return "", nil
+	}
+
+	return clean, nil
+}
+
+func (r *resolver) IndexEntry(stage int, name string) (types.ObjectID, error) {
+
+	name, err := r.treePath(name)
+	if err != nil {
+		return "", err
+	}
+
+	// 呼び出し元が開いているインデックスとは別に読む
+	db := database.NewFSDB(r.ctx.WorkspaceRoot(), r.ctx.GotRoot())
+	defer db.Close()
+
+	if err := db.Index().OpenForRead(); err != nil {
+		return "", err
+	}
+
+	index, err := repository.NewIndex(repository.IndexSource(db.Index()))
+	if err != nil {
+		return "", err
+	}
+
+	entry, ok := index.Get(name)
+	switch {
+	case !ok:
+		return "", &InvalidRevisionError{Revision: ":" + name, Reason: fmt.Sprintf("path '%s' does not exist in the index", name)}
+	case stage != 0:
+		// インデックスはステージを持たないので、すべてステージ0
+		return "", &InvalidRevisionError{Revision: ":" + name, Reason: fmt.Sprintf("path '%s' is in the index, but not at stage %d", name, stage)}
+	}
+
+	return types.NewObjectID(entry.TreeEntry().OID())
+}
+
+// reflogName 空なら今のブランチ。HEADはHEAD自身の記録
+func (r *resolver) reflogName(name string) (string, error) {
+
+	if name == "" {
+
+		head, err := r.refs.HeadRef()
+		if err != nil || head != "" {
+			return head, err
+		}
+		return "HEAD", nil
+	}
+
+	full, err := r.refs.ExpandRef(name)
+	if errors.Is(err, database.ErrRefNotFound) {
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("not a valid object name: %s", name)}
+	}
+
+	return full, err
+}
+
+func (r *resolver) Reflog(name string, n int) (types.ObjectID, error) {
+
+	full, err := r.reflogName(name)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := r.refs.Reflog(full)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case n < len(entries):
+		return types.NewObjectID(entries[len(entries)-1-n].New)
+	case n == len(entries) && n != 0 && !isNullOID(entries[0].Old):
+		return types.NewObjectID(entries[0].Old)
+	}
+
+	return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("log for '%s' only has %d entries", shortRefName(full), len(entries))}
+}
+
+func (r *resolver) ReflogAt(name string, when time.Time) (types.ObjectID, error) {
+
+	full, err := r.reflogName(name)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := r.refs.Reflog(full)
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 0 {
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("log for '%s' is empty", shortRefName(full))}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Committer.When().After(when) {
+			return types.NewObjectID(entries[i].New)
+		}
+	}
+
+	// 記録より前ならgitと同じく一番古いものを使う
+	oldest := entries[0]
+	r.ctx.OutError(fmt.Errorf("warning: log for '%s' only goes back to %s", shortRefName(full), oldest.Committer.When().Format("Mon Jan 2 15:04:05 2006 -0700")))
+
+	if isNullOID(oldest.Old) {
+		return types.NewObjectID(oldest.New)
+	}
+	return types.NewObjectID(oldest.Old)
+}
+
+// Upstream branch.<name>.remoteとbranch.<name>.mergeのリモート追跡ブランチ
+func (r *resolver) Upstream(name string) (types.ObjectID, error) {
+
+	full, err := r.reflogName(name)
+	if err != nil {
+		return "", err
+	}
+
+	branch, ok := strings.CutPrefix(full, "refs/heads/")
+	if !ok {
+		if name == "" {
+			return "", &InvalidRevisionError{Revision: "@{upstream}", Reason: "HEAD does not point to a branch"}
+		}
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("no such branch: '%s'", name)}
+	}
+
+	remote, _ := r.config.Get("branch." + branch + ".remote")
+	merge, _ := r.config.Get("branch." + branch + ".merge")
+	if remote == "" || merge == "" {
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("no upstream configured for branch '%s'", branch)}
+	}
+
+	tracking := merge
+	if remote != "." {
+		tracking = "refs/remotes/" + remote + "/" + strings.TrimPrefix(merge, "refs/heads/")
+	}
+
+	oid, err := r.refs.ReadRef(tracking)
+	if errors.Is(err, database.ErrRefNotFound) || (err == nil && oid == "") {
+		return "", &InvalidRevisionError{Revision: name, Reason: fmt.Sprintf("upstream branch '%s' not stored as a remote-tracking branch", merge)}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return types.NewObjectID(oid)
+}
+
+func isNullOID(oid string) bool {
+	return strings.Trim(oid, "0") == ""
+}
+
+// shortRefName refs/heads/mainをmainにする
+func shortRefName(full string) string {
+
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if name, ok := strings.CutPrefix(full, prefix); ok {
+			return name
+		}
+	}
+
+	return full
+}
+
+// resolveRange 範囲のrevisionを含めるものと除くものにする。A...BはBとAを含めて、AとBのマージベースを除く
+func resolveRange(ctx GotContextReaderWriter, db database.Database, r *types.RevisionRange) (include, exclude []types.ObjectID, err error) {
+
+	resolver := newResolver(ctx, db)
+
+	resolve := func(revisions ...types.Revision) ([]types.ObjectID, error) {
+
+		oids := []types.ObjectID{}
+		for _, revision := range revisions {
+
+			oid, err := revision.Resolve(resolver)
+			if err != nil {
+				return nil, err
+			}
+			oids = append(oids, oid)
+		}
+
+		return oids, nil
+	}
+
+	if include, err = resolve(r.Include...); err != nil {
+		return nil, nil, err
+	}
+
+	for _, pair := range r.Symmetric {
+
+		oids, err := resolve(pair[1], pair[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		include = append(include, oids...)
+
+		commits, err := resolver.peelCommits(oids)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bases, err := repository.NewRevList(db.Objects()).MergeBases(commits[1], commits[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		exclude = append(exclude, bases...)
+	}
+
+	excluded, err := resolve(r.Exclude...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return include, append(excluded, exclude...), nil
+}
+
+// peelCommits タグをたどってコミットにする
+func (r *resolver) peelCommits(oids []types.ObjectID) ([]types.ObjectID, error) {
+
+	commits := []types.ObjectID{}
+	for _, oid := range oids {
+
+		commit, err := r.peelTo(oid, "commit")
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+// rangeCommits 範囲をたどるコミットにする。logやrev-listで使う
+func rangeCommits(ctx GotContextReaderWriter, db database.Database, r *types.RevisionRange) (include, exclude []types.ObjectID, err error) {
+
+	include, exclude, err = resolveRange(ctx, db, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolver := newResolver(ctx, db)
+
+	if include, err = resolver.peelCommits(include); err != nil {
+		return nil, nil, err
+	}
+
+	if exclude, err = resolver.peelCommits(exclude); err != nil {
+		return nil, nil, err
+	}
+
+	return include, exclude, nil
+}
+
+const separateHint = `Use '--' to separate paths from revisions, like this:
+'git <command> [<revision>...] -- [<file>...]'`
+
+// SplitRevisionArgs --のない引数を先頭のrevisionと残りのパスに分ける。revisionでもファイルでもない引数や、両方にとれる引数はエラー
+func SplitRevisionArgs(ctx GotContextReaderWriter, args []string) (revisions, paths []string, err error) {
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	for i, arg := range args {
+
+		if !isRevision(ctx, db, arg) {
+
+			// パスの後ろはすべてパス
+			for _, path := range args[i:] {
+				if !isPath(ctx, path) {
+					return nil, nil, &InvalidRevisionError{Revision: path, Reason: fmt.Sprintf("ambiguous argument '%s': unknown revision or path not in the working tree.\n%s", path, separateHint)}
+				}
+			}
+
+			return revisions, args[i:], nil
+		}
+
+		if _, err := os.Lstat(filepath.Join(ctx.WorkingDirectory(), arg)); err == nil {
+			return nil, nil, &InvalidRevisionError{Revision: arg, Reason: fmt.Sprintf("ambiguous argument '%s': both revision and filename\n%s", arg, separateHint)}
+		}
+
+		revisions = append(revisions, arg)
+	}
+
+	return revisions, nil, nil
+}
+
+// isPath ワークスペースにあるか、ワイルドカードを含むパス
+func isPath(ctx GotContextReaderWriter, arg string) bool {
+
+	if strings.ContainsAny(arg, "*?[") {
+		return true
+	}
+
+	_, err := os.Lstat(filepath.Join(ctx.WorkingDirectory(), arg))
+	return err == nil
+}
+
+func isRevision(ctx GotContextReaderWriter, db database.Database, arg string) bool {
+
+	r, err := types.NewRevisionRange(arg)
+	if err != nil {
+		return false
+	}
+
+	_, _, err = resolveRange(ctx, db, r)
+	return err == nil
+}
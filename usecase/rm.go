@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/mizuho-u/got/internal"
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/io/workspace"
+	"github.com/mizuho-u/got/repository"
+)
+
+var ErrNoPathspec = errors.New("No pathspec was given. Which files should I remove?")
+
+type RmOptions struct {
+	Cached    bool
+	Force     bool
+	Recursive bool
+}
+
+func Rm(ctx GotContextReaderWriter, opts RmOptions, paths ...string) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return ErrNoPathspec
+	}
+
+	pathspec, err := newPathspec(ctx, paths...)
+	if err != nil {
+		return err
+	}
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	err = openIndexForUpdate(db)
+	if err != nil {
+		return err
+	}
+
+	opt := []repository.WorkspaceOption{repository.WithPathspec(pathspec)}
+	if !db.Index().IsNew() {
+		opt = append(opt, repository.WithIndex(db.Index()))
+	}
+
+	repo, err := repository.NewRepository(opt...)
+	if err != nil {
+		return err
+	}
+
+	scanOpts, err := scanOptions(ctx, db, repo.Tracked)
+	if err != nil {
+		return err
+	}
+	scanOpts = append(scanOpts, workspace.WithPathspec(pathspec))
+
+	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot(), scanOpts...)
+	if err != nil {
+		return err
+	}
+
+	head, err := db.Refs().Head()
+	if err != nil {
+		return err
+	}
+
+	if err := repo.Scan(scanner, db.Objects().ScanTree(head.Tree())); err != nil {
+		return err
+	}
+
+	if unmatched := pathspec.Unmatched(internal.Keys(repo.Index().Iter())); len(unmatched) != 0 {
+		return fmt.Errorf("pathspec '%s' did not match any files", unmatched[0])
+	}
+
+	removed, err := repo.Remove(repository.RemoveOptions{Cached: opts.Cached, Force: opts.Force, Recursive: opts.Recursive})
+	if err != nil {
+		return err
+	}
+
+	if !opts.Cached {
+		if err := removeFiles(workspace.New(ctx.WorkspaceRoot()), removed); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range removed {
+		ctx.Out(fmt.Sprintf("rm '%s'\n", f), none)
+	}
+
+	if err := db.Index().Update(repo.Index()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// removeFiles ファイルを削除して、空になったディレクトリも削除する
+func removeFiles(ws repository.Workspace, files []string) error {
+
+	dirs := map[string]struct{}{}
+
+	for _, f := range files {
+
+		if err := ws.RemoveFile(f); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		for _, d := range internal.ParentDirs(f) {
+			dirs[d] = struct{}{}
+		}
+	}
+
+	// 深いディレクトリから消す。空でなければ消えない
+	sorted := internal.Keys(dirs)
+	sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+	for _, d := range sorted {
+		ws.RemoveDirectory(d)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestRm(t *testing.T) {
+
+	testt := []struct {
+		description string
+		opts        usecase.RmOptions
+		paths       []string
+		lsfiles     string
+		removed     []string
+		kept        []string
+	}{
+		{
+			description: "remove a file",
+			paths:       []string{"a.txt"},
+			lsfiles:     "b/c.txt\nb/d.txt\n",
+			removed:     []string{"a.txt"},
+			kept:        []string{"b/c.txt"},
+		},
+		{
+			description: "remove a directory recursively",
+			opts:        usecase.RmOptions{Recursive: true},
+			paths:       []string{"b"},
+			lsfiles:     "a.txt\n",
+			removed:     []string{"b/c.txt", "b/d.txt", "b"},
+			kept:        []string{"a.txt"},
+		},
+		{
+			description: "remove from the index only",
+			opts:        usecase.RmOptions{Cached: true},
+			paths:       []string{"*.txt", ":!b/d.txt"},
+			lsfiles:     "b/d.txt\n",
+			kept:        []string{"a.txt", "b/c.txt", "b/d.txt"},
+		},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			dir := initDir(t)
+			createFile(t, dir, "a.txt", []byte("a\n"))
+			createFile(t, dir, "b/c.txt", []byte("c\n"))
+			createFile(t, dir, "b/d.txt", []byte("d\n"))
+			add(t, dir, dir)
+			commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+			out := &bytes.Buffer{}
+			if err := usecase.Rm(newContext(dir, "", "", out, out), tc.opts, tc.paths...); err != nil {
+				t.Fatal(err)
+			}
+
+			testlsfiles(t, dir, tc.lsfiles)
+
+			for _, f := range tc.removed {
+				if exists(dir, f) {
+					t.Errorf("%s still exists", f)
+				}
+			}
+
+			for _, f := range tc.kept {
+				if !exists(dir, f) {
+					t.Errorf("%s is removed", f)
+				}
+			}
+
+		})
+	}
+
+}
+
+func TestRmRefusesToLoseChanges(t *testing.T) {
+
+	testt := []struct {
+		description string
+		arrange     func(t *testing.T, dir string)
+		opts        usecase.RmOptions
+		expect      string
+	}{
+		{
+			description: "directory without -r",
+			arrange:     func(t *testing.T, dir string) {},
+			expect:      "not removing 'b' recursively without -r",
+		},
+		{
+			description: "local modifications",
+			arrange: func(t *testing.T, dir string) {
+				createFile(t, dir, "b/c.txt", []byte("c2\n"))
+			},
+			opts:   usecase.RmOptions{Recursive: true},
+			expect: "the following file has local modifications:\n    b/c.txt",
+		},
+		{
+			description: "changes staged in the index",
+			arrange: func(t *testing.T, dir string) {
+				createFile(t, dir, "b/c.txt", []byte("c2\n"))
+				add(t, dir, "b/c.txt")
+			},
+			opts:   usecase.RmOptions{Recursive: true},
+			expect: "the following file has changes staged in the index:\n    b/c.txt",
+		},
+		{
+			description: "staged content different from both the file and HEAD",
+			arrange: func(t *testing.T, dir string) {
+				createFile(t, dir, "b/c.txt", []byte("c2\n"))
+				add(t, dir, "b/c.txt")
+				createFile(t, dir, "b/c.txt", []byte("c3\n"))
+			},
+			opts:   usecase.RmOptions{Recursive: true, Cached: true},
+			expect: "the following file has staged content different from both the\nfile and the HEAD:\n    b/c.txt",
+		},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			dir := initDir(t)
+			createFile(t, dir, "b/c.txt", []byte("c\n"))
+			add(t, dir, dir)
+			commit(t, dir, "", "", "first", time.Unix(1694356071, 0))
+
+			tc.arrange(t, dir)
+
+			out := &bytes.Buffer{}
+			err := usecase.Rm(newContext(dir, "", "", out, out), tc.opts, "b")
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expect) {
+				t.Fatalf("expect error %q, got %v", tc.expect, err)
+			}
+
+			testlsfiles(t, dir, "b/c.txt\n")
+
+			// -fなら削除できる
+			tc.opts.Force = true
+			tc.opts.Recursive = true
+			if err := usecase.Rm(newContext(dir, "", "", out, out), tc.opts, "b"); err != nil {
+				t.Fatal(err)
+			}
+
+			testlsfiles(t, dir, "")
+
+		})
+	}
+
+}
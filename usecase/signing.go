@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mizuho-u/got/internal/sshsig"
+	"github.com/mizuho-u/got/io/database"
+	"github.com/mizuho-u/got/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// gitはsshsigのnamespaceにgitを使う
+const signatureNamespace = "git"
+
+// SignatureError 署名がない、または検証できなかった
+type SignatureError struct {
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	return e.Reason
+}
+
+func (e *SignatureError) ExitCode() int {
+	return ExitError
+}
+
+// commitSigner -Sかcommit.gpgSignのときの署名関数。署名しないならnil
+func commitSigner(ctx GotContextReader, config database.Config, opts CommitOptions) (func(payload []byte) (string, error), error) {
+
+	sign := opts.Sign
+	if !sign {
+		sign, _ = config.Bool("commit.gpgSign")
+	}
+
+	if !sign {
+		return nil, nil
+	}
+
+	format, ok := config.Get("gpg.format")
+	if !ok {
+		format = "openpgp"
+	}
+
+	if format != "ssh" {
+		return nil, fmt.Errorf("gpg.format '%s' is not supported. only ssh signing is available", format)
+	}
+
+	key := opts.SigningKey
+	if key == "" {
+		key, _ = config.Get("user.signingKey")
+	}
+
+	if key == "" {
+		return nil, errors.New("user.signingkey needs to be set for ssh signing")
+	}
+
+	signer, err := loadSSHSigner(expandPath(ctx, key))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(payload []byte) (string, error) {
+		return sshsig.Sign(signer, signatureNamespace, payload)
+	}, nil
+}
+
+// loadSSHSigner 公開鍵を指定されたら、同じ場所の秘密鍵を使う
+func loadSSHSigner(path string) (ssh.Signer, error) {
+
+	data, err := os.ReadFile(strings.TrimSuffix(path, ".pub"))
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(data)
+
+	var passphrase *ssh.PassphraseMissingError
+	if errors.As(err, &passphrase) {
+		return nil, fmt.Errorf("signing key %s is protected by a passphrase, which is not supported", path)
+	}
+
+	return signer, err
+}
+
+// expandPath ~をホームディレクトリに、相対パスを作業ディレクトリからのパスにする
+func expandPath(ctx GotContextReader, path string) string {
+
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+
+	if !filepath.IsAbs(path) {
+		return filepath.Join(ctx.WorkingDirectory(), path)
+	}
+
+	return path
+}
+
+// VerifyCommit コミットのsshsigの署名を検証して、gpg.ssh.allowedSignersFileで許可された鍵か確かめる
+func VerifyCommit(ctx GotContextReaderWriter, revision types.Revision) error {
+
+	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
+	defer db.Close()
+
+	oid, err := resolveCommit(ctx, db, revision)
+	if err != nil {
+		return err
+	}
+
+	commit, err := db.Objects().LoadCommit(oid.String())
+	if err != nil {
+		return err
+	}
+
+	signature, payload, ok := commit.Signature()
+	if !ok {
+		return &SignatureError{Reason: fmt.Sprintf("%s: no signature found", oid)}
+	}
+
+	allowed, ok := db.Config().Get("gpg.ssh.allowedSignersFile")
+	if !ok {
+		return &SignatureError{Reason: "gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature verification"}
+	}
+
+	data, err := os.ReadFile(expandPath(ctx, allowed))
+	if err != nil {
+		return err
+	}
+
+	signers, err := sshsig.ParseAllowedSigners(data)
+	if err != nil {
+		return err
+	}
+
+	sig, err := sshsig.Verify(signature, signatureNamespace, payload)
+	if err != nil {
+		return &SignatureError{Reason: fmt.Sprintf("Could not verify signature: %s", err)}
+	}
+
+	keyType, fingerprint := sshKeyType(sig.PublicKey), ssh.FingerprintSHA256(sig.PublicKey)
+
+	principal, ok := sshsig.FindPrincipal(signers, sig.PublicKey, signatureNamespace, commit.Committer().When())
+	if !ok {
+		ctx.OutError(fmt.Errorf(`Good "%s" signature with %s key %s`, signatureNamespace, keyType, fingerprint))
+		return &SignatureError{Reason: "No principal matched."}
+	}
+
+	return ctx.OutError(fmt.Errorf(`Good "%s" signature for %s with %s key %s`, signatureNamespace, principal, keyType, fingerprint))
+}
+
+// sshKeyType ssh-keygenと同じ表記
+func sshKeyType(pub ssh.PublicKey) string {
+
+	switch t := pub.Type(); {
+	case strings.HasPrefix(t, "ecdsa-"):
+		return "ECDSA"
+	case strings.HasPrefix(t, "sk-ecdsa-"):
+		return "ECDSA-SK"
+	case strings.HasPrefix(t, "sk-ssh-ed25519"):
+		return "ED25519-SK"
+	default:
+		return strings.ToUpper(strings.TrimPrefix(t, "ssh-"))
+	}
+}
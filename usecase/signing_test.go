@@ -0,0 +1,176 @@
+package usecase_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mizuho-u/got/usecase"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommitSignAndVerify(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+
+	pub := generateSigningKey(t, dir, "key")
+	gitConfig(t, dir, "gpg.format", "ssh")
+	gitConfig(t, dir, "user.signingKey", filepath.Join(dir, "key.pub"))
+	gitConfig(t, dir, "gpg.ssh.allowedSignersFile", filepath.Join(dir, "allowed_signers"))
+	writeAllowedSigners(t, dir, "me@example.com "+pub)
+
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "me", "me@example.com", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "signed", Sign: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	object, err := exec.Command("git", "-C", dir, "cat-file", "-p", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(object), "\ngpgsig -----BEGIN SSH SIGNATURE-----\n ") {
+		t.Fatalf("commit has no gpgsig header\n%s", object)
+	}
+
+	errOut := &bytes.Buffer{}
+	if err := usecase.VerifyCommit(newContext(dir, "", "", out, errOut), mustRevision(t, "HEAD")); err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := ssh.FingerprintSHA256(mustParseAuthorizedKey(t, pub))
+	expect := `Good "git" signature for me@example.com with ED25519 key ` + fingerprint + "\n"
+	if errOut.String() != expect {
+		t.Fatalf("expect %q, got %q", expect, errOut)
+	}
+
+	// 許可されていない鍵
+	writeAllowedSigners(t, dir, "other@example.com "+generateSigningKey(t, dir, "other"))
+
+	errOut = &bytes.Buffer{}
+	err = usecase.VerifyCommit(newContext(dir, "", "", out, errOut), mustRevision(t, "HEAD"))
+
+	var signatureErr *usecase.SignatureError
+	if !errors.As(err, &signatureErr) || signatureErr.Reason != "No principal matched." {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expect = `Good "git" signature with ED25519 key ` + fingerprint + "\n"
+	if errOut.String() != expect {
+		t.Fatalf("expect %q, got %q", expect, errOut)
+	}
+
+}
+
+func TestCommitSignWithConfig(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+
+	generateSigningKey(t, dir, "key")
+	gitConfig(t, dir, "commit.gpgSign", "true")
+	gitConfig(t, dir, "user.signingKey", filepath.Join(dir, "key"))
+
+	// gpg.formatがsshでなければ署名できない
+	out := &bytes.Buffer{}
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "signed"}); err == nil {
+		t.Fatal("expect error with openpgp format")
+	}
+
+	gitConfig(t, dir, "gpg.format", "ssh")
+	if err := usecase.Commit(newContext(dir, "", "", out, out), time.Unix(1694356071, 0), usecase.CommitOptions{Message: "signed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testgitlog(t, dir, "%s", "signed\n")
+
+}
+
+func TestVerifyUnsignedCommit(t *testing.T) {
+
+	dir := initDir(t)
+	add(t, dir, createFile(t, dir, "a.txt", []byte("a\n")))
+	commit(t, dir, "", "", "unsigned", time.Unix(1694356071, 0))
+
+	out := &bytes.Buffer{}
+	err := usecase.VerifyCommit(newContext(dir, "", "", out, out), mustRevision(t, "HEAD"))
+
+	var signatureErr *usecase.SignatureError
+	if !errors.As(err, &signatureErr) || !strings.HasSuffix(signatureErr.Reason, ": no signature found") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+}
+
+// generateSigningKey 秘密鍵をname、公開鍵をname.pubに書き出して、公開鍵を返す
+func generateSigningKey(t *testing.T, dir, name string) string {
+
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if err := os.WriteFile(filepath.Join(dir, name+".pub"), []byte(pub+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return pub
+}
+
+func writeAllowedSigners(t *testing.T, dir string, lines ...string) {
+
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, "allowed_signers"), []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
+func mustParseAuthorizedKey(t *testing.T, key string) ssh.PublicKey {
+
+	t.Helper()
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pub
+}
+
+func gitConfig(t *testing.T, dir, key, value string) {
+
+	t.Helper()
+
+	if out, err := exec.Command("git", "-C", dir, "config", key, value).CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+
+}
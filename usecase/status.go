@@ -8,27 +8,43 @@ import (
 	"github.com/mizuho-u/got/repository"
 )
 
-func Status(ctx GotContextReaderWriter, porcelain bool) error {
+func Status(ctx GotContextReaderWriter, porcelain bool, paths ...string) error {
+
+	if err := requireWorkTree(ctx); err != nil {
+		return err
+	}
 
 	var db database.Database = database.NewFSDB(ctx.WorkspaceRoot(), ctx.GotRoot())
 	defer db.Close()
 
-	err := db.Index().OpenForUpdate()
+	err := openIndexForUpdate(db)
 	if err != nil {
 		return err
 	}
 
-	opt := []repository.WorkspaceOption{}
+	pathspec, err := newPathspec(ctx, paths...)
+	if err != nil {
+		return err
+	}
+
+	opt := []repository.WorkspaceOption{repository.WithPathspec(pathspec)}
 	if !db.Index().IsNew() {
 		opt = append(opt, repository.WithIndex(db.Index()))
 	}
+	opt = append(opt, repository.WithObjectLoader(db.Objects()))
 
 	repo, err := repository.NewRepository(opt...)
 	if err != nil {
 		return err
 	}
 
-	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot())
+	opts, err := scanOptions(ctx, db, repo.Tracked)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, workspace.WithPathspec(pathspec))
+
+	scanner, err := workspace.Scan(ctx.WorkspaceRoot(), ctx.WorkspaceRoot(), ctx.GotRoot(), opts...)
 	if err != nil {
 		return err
 	}
@@ -38,14 +54,25 @@ func Status(ctx GotContextReaderWriter, porcelain bool) error {
 		return err
 	}
 
-	if repo.Scan(scanner, db.Objects().ScanTree(head.Tree())) != nil {
+	if err := repo.Scan(scanner, db.Objects().ScanTree(head.Tree())); err != nil {
 		return err
 	}
 
+	if renames, _ := renameConfig(db.Config(), "status.renames", "diff.renames"); renames {
+		if err := repo.DetectIndexRenames(); err != nil {
+			return err
+		}
+	}
+
 	if porcelain {
 		files, types := repo.Changed()
 		for _, f := range files {
-			ctx.Out(fmt.Sprintf("%s %s\n", types[f].ShortFormat(), f), none)
+
+			name := f
+			if from, ok := repo.RenamedFrom(f); ok {
+				name = from + " -> " + f
+			}
+			ctx.Out(fmt.Sprintf("%s %s\n", types[f].ShortFormat(), name), none)
 		}
 
 		for _, v := range repo.Untracked() {
@@ -57,7 +84,12 @@ func Status(ctx GotContextReaderWriter, porcelain bool) error {
 		if files, types := repo.IndexChanges(); len(files) != 0 {
 			ctx.Out("Changes to be commited:\n\n", none)
 			for _, f := range files {
-				ctx.Out(fmt.Sprintf("\t%8s: %s\n", types[f].LongFormat(), f), green)
+
+				name := displayPath(ctx, f)
+				if from, ok := repo.RenamedFrom(f); ok {
+					name = displayPath(ctx, from) + " -> " + name
+				}
+				ctx.Out(fmt.Sprintf("\t%8s: %s\n", types[f].LongFormat(), name), green)
 			}
 			ctx.Out("\n", none)
 
@@ -68,7 +100,7 @@ func Status(ctx GotContextReaderWriter, porcelain bool) error {
 		if files, types := repo.WorkspaceChanges(); len(files) != 0 {
 			ctx.Out("Changes not staged for commit:\n\n", none)
 			for _, f := range files {
-				ctx.Out(fmt.Sprintf("\t%8s: %s\n", types[f].LongFormat(), f), red)
+				ctx.Out(fmt.Sprintf("\t%8s: %s\n", types[f].LongFormat(), displayPath(ctx, f)), red)
 			}
 			ctx.Out("\n", none)
 
@@ -79,7 +111,7 @@ func Status(ctx GotContextReaderWriter, porcelain bool) error {
 		if files := repo.Untracked(); len(files) != 0 {
 			ctx.Out("Untracked files:\n\n", none)
 			for _, f := range files {
-				ctx.Out(fmt.Sprintf("\t%-8s\n", f), red)
+				ctx.Out(fmt.Sprintf("\t%-8s\n", displayPath(ctx, f)), red)
 			}
 			ctx.Out("\n", none)
 
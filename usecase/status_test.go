@@ -2,9 +2,11 @@ package usecase_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -424,3 +426,111 @@ func TestStatusLongFormat(t *testing.T) {
 	}
 
 }
+
+func TestStatusFromSubdirectory(t *testing.T) {
+
+	dir := initDir(t)
+
+	f := createFile(t, dir, "src/pkg/a.txt", []byte("a\n"))
+	createFile(t, dir, "top.txt", []byte("top\n"))
+	add(t, dir, f)
+
+	out := &bytes.Buffer{}
+	ctx := usecase.NewContext(context.Background(), dir, ".git", "", "", out, out, usecase.WithWorkingDirectory(filepath.Join(dir, "src", "pkg")))
+	if err := usecase.Status(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "Changes to be commited:\n\n\tnew file: a.txt\n\nUntracked files:\n\n\t../../top.txt\n\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+	out.Reset()
+	if err := usecase.Status(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+
+	expect = "A  src/pkg/a.txt\n?? top.txt\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestStatusPathspec(t *testing.T) {
+
+	dir := initDir(t)
+	createFile(t, dir, "a.txt", []byte("a\n"))
+	createFile(t, dir, "b/c.txt", []byte("c\n"))
+	add(t, dir, dir)
+	commit(t, dir, "", "", "commit message", time.Unix(1677142145, 0))
+
+	createFile(t, dir, "a.txt", []byte("a2\n"))
+	createFile(t, dir, "b/c.txt", []byte("c2\n"))
+	createFile(t, dir, "b/d.txt", []byte("d\n"))
+	createFile(t, dir, "e.txt", []byte("e\n"))
+
+	out := &bytes.Buffer{}
+	if err := usecase.Status(newContext(dir, "", "", out, out), true, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := " M b/c.txt\n?? b/d.txt\n"
+	if out.String() != expect {
+		t.Fatalf("expect \n%s, got \n%s", expect, out)
+	}
+
+}
+
+func TestStatusRenames(t *testing.T) {
+
+	dir := initDir(t)
+
+	add(t, dir,
+		createFile(t, dir, "a.txt", []byte(strings.Repeat("line\n", 10))),
+		createFile(t, dir, "b.txt", []byte("b\n")))
+	commit(t, dir, "", "", "first", time.Unix(1677142145, 0))
+
+	removeAll(t, dir, "a.txt")
+	removeAll(t, dir, "b.txt")
+	createFile(t, dir, "moved/a.txt", []byte(strings.Repeat("line\n", 10)+"more\n"))
+	createFile(t, dir, "c.txt", []byte("c\n"))
+	removeAll(t, dir, ".git/index")
+	add(t, dir, dir)
+
+	testt := []struct {
+		porcelain bool
+		expect    string
+	}{
+		{
+			porcelain: true,
+			expect: `D  b.txt
+A  c.txt
+R  a.txt -> moved/a.txt
+`,
+		},
+		{
+			expect: `Changes to be commited:
+
+	 deleted: b.txt
+	new file: c.txt
+	 renamed: a.txt -> moved/a.txt
+
+`,
+		},
+	}
+
+	for _, tc := range testt {
+
+		out := &bytes.Buffer{}
+		if err := usecase.Status(newContext(dir, "", "", out, out), tc.porcelain); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != tc.expect {
+			t.Errorf("expect \n%s, got \n%s", tc.expect, out)
+		}
+	}
+
+}
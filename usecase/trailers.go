@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/mizuho-u/got/repository/object"
+)
+
+type InterpretTrailersOptions struct {
+	// Trailers --trailerで指定された "token=value"
+	Trailers []string
+	// Where 足す位置。end、start、after、before
+	Where string
+	// IfExists 同じtokenのトレーラがあるときの動作
+	IfExists string
+	// IfMissing 同じtokenのトレーラがないときの動作
+	IfMissing string
+	// Parse メッセージのトレーラだけを継続行をつないで出力する
+	Parse bool
+}
+
+// InterpretTrailers git interpret-trailersと同じく、messageにトレーラを足して出力する
+func InterpretTrailers(ctx GotContextWriter, message string, opts InterpretTrailersOptions) error {
+
+	if opts.Parse {
+
+		for _, t := range object.ParseTrailers(message) {
+			if err := ctx.Out(t.String()+"\n", none); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	trailerOpts, err := object.NewTrailerOptions(opts.Where, opts.IfExists, opts.IfMissing)
+	if err != nil {
+		return err
+	}
+
+	trailers, err := parseTrailers(opts.Trailers)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Out(object.AddTrailers(message, trailers, trailerOpts), none)
+}
+
+// commitTrailers -sと--trailerのトレーラをメッセージに足す。gitと同じくSigned-off-byが先
+func commitTrailers(ctx GotContextReader, message string, opts CommitOptions) (string, error) {
+
+	trailers, err := parseTrailers(opts.Trailers)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Signoff {
+		trailers = append([]object.Trailer{{Token: "Signed-off-by", Value: signoff(ctx)}}, trailers...)
+	}
+
+	if len(trailers) == 0 {
+		return message, nil
+	}
+
+	// -mのメッセージは改行で終わっていないので、段落を分けるために改行を足す
+	if message != "" && !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+
+	trailerOpts, _ := object.NewTrailerOptions("", "", "")
+
+	return object.AddTrailers(message, trailers, trailerOpts), nil
+}
+
+func parseTrailers(args []string) ([]object.Trailer, error) {
+
+	trailers := make([]object.Trailer, 0, len(args))
+	for _, arg := range args {
+
+		t, err := object.ParseTrailer(arg)
+		if err != nil {
+			return nil, err
+		}
+		trailers = append(trailers, t)
+	}
+
+	return trailers, nil
+}
+
+func signoff(ctx GotContextReader) string {
+	return ctx.CommitterName() + " <" + ctx.CommitterEmail() + ">"
+}
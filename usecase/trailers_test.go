@@ -0,0 +1,44 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mizuho-u/got/usecase"
+)
+
+func TestInterpretTrailers(t *testing.T) {
+
+	message := "subject\n\nbody\n\nFoo: a\n  continued\nBar: b\n"
+
+	testt := []struct {
+		description string
+		opts        usecase.InterpretTrailersOptions
+		expect      string
+	}{
+		{"parse", usecase.InterpretTrailersOptions{Parse: true, Trailers: []string{"baz=c"}}, "Foo: a continued\nBar: b\n"},
+		{"add", usecase.InterpretTrailersOptions{Trailers: []string{"baz=c", "Bar: b"}}, "subject\n\nbody\n\nFoo: a\n  continued\nBar: b\nbaz: c\nBar: b\n"},
+		{"where and if exists", usecase.InterpretTrailersOptions{Trailers: []string{"foo=d"}, Where: "before", IfExists: "replace"}, "subject\n\nbody\n\nfoo: d\nBar: b\n"},
+	}
+
+	for _, tc := range testt {
+		t.Run(tc.description, func(t *testing.T) {
+
+			out := &bytes.Buffer{}
+			if err := usecase.InterpretTrailers(usecase.NewContext(context.Background(), "", "", "", "", out, out), message, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.expect {
+				t.Errorf("expect %q, got %q", tc.expect, out)
+			}
+		})
+	}
+
+	out := &bytes.Buffer{}
+	if err := usecase.InterpretTrailers(usecase.NewContext(context.Background(), "", "", "", "", out, out), message, usecase.InterpretTrailersOptions{Where: "middle"}); err == nil {
+		t.Fatal("expect error with unknown where")
+	}
+
+}
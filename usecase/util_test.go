@@ -19,7 +19,7 @@ func initDir(t testing.TB) string {
 
 	dir := t.TempDir()
 
-	err := usecase.InitDir(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}))
+	err := usecase.InitDir(newContext(dir, "", "", &bytes.Buffer{}, &bytes.Buffer{}), usecase.InitOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -44,7 +44,7 @@ func commit(t *testing.T, dir, email, user, msg string, time time.Time) string {
 	t.Helper()
 
 	out := &bytes.Buffer{}
-	if err := usecase.Commit(newContext(dir, email, user, out, out), msg, time); err != nil {
+	if err := usecase.Commit(newContext(dir, email, user, out, out), time, usecase.CommitOptions{Message: msg}); err != nil {
 		t.Fatal(err)
 	}
 